@@ -1,6 +1,7 @@
 package consensus
 
 import (
+	txtypes "github.com/Fantom-foundation/go-txflow/types"
 	amino "github.com/tendermint/go-amino"
 	"github.com/tendermint/tendermint/consensus"
 	"github.com/tendermint/tendermint/types"
@@ -12,4 +13,5 @@ func init() {
 	RegisterConsensusMessages(cdc)
 	consensus.RegisterWALMessages(cdc)
 	types.RegisterBlockAmino(cdc)
+	txtypes.RegisterTxVoteEvidences(cdc)
 }
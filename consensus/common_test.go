@@ -26,6 +26,7 @@ import (
 	abci "github.com/tendermint/tendermint/abci/types"
 	cfg "github.com/tendermint/tendermint/config"
 	cmn "github.com/tendermint/tendermint/libs/common"
+	dbm "github.com/tendermint/tendermint/libs/db"
 	"github.com/tendermint/tendermint/libs/log"
 	tmpubsub "github.com/tendermint/tendermint/libs/pubsub"
 	mempl "github.com/tendermint/tendermint/mempool"
@@ -33,7 +34,6 @@ import (
 	"github.com/tendermint/tendermint/privval"
 	ttypes "github.com/tendermint/tendermint/types"
 	tmtime "github.com/tendermint/tendermint/types/time"
-	dbm "github.com/tendermint/tm-cmn/db"
 )
 
 const (
@@ -295,11 +295,7 @@ func newConsensusStateWithConfigAndBlockStore(thisConfig *cfg.Config, state sm.S
 		mempool.EnableTxsAvailable()
 	}
 
-	commitpool := mempl.NewCListMempool(thisConfig.Mempool, proxyAppConnMem, 0)
-	commitpool.SetLogger(log.TestingLogger().With("module", "commitpool"))
-	if thisConfig.Consensus.WaitForTxs() {
-		commitpool.EnableTxsAvailable()
-	}
+	commitpool := sm.MockCommitPool{}
 
 	// mock the evidence pool
 	evpool := sm.MockEvidencePool{}
@@ -26,7 +26,7 @@ import (
 	"github.com/Fantom-foundation/go-txflow/types"
 	"github.com/Fantom-foundation/go-txflow/store"
 	ttypes "github.com/tendermint/tendermint/types"
-	dbm "github.com/tendermint/tm-cmn/db"
+	dbm "github.com/tendermint/tendermint/libs/db"
 )
 
 //----------------------------------------------
@@ -148,12 +148,8 @@ func TestReactorWithEvidence(t *testing.T) {
 			mempool.EnableTxsAvailable()
 		}
 
-		// Make Mempool
-		commitpool := mempl.NewCListMempool(thisConfig.Mempool, nil, 0)
-		commitpool.SetLogger(log.TestingLogger().With("module", "commitpool"))
-		if thisConfig.Consensus.WaitForTxs() {
-			commitpool.EnableTxsAvailable()
-		}
+		// Make CommitPool
+		commitpool := sm.MockCommitPool{}
 
 		// mock the evidence pool
 		// everyone includes evidence of another double signing
@@ -13,9 +13,10 @@ import (
 
 	abci "github.com/tendermint/tendermint/abci/types"
 	//auto "github.com/tendermint/tendermint/libs/autofile"
-	dbm "github.com/tendermint/tm-cmn/db"
+	dbm "github.com/tendermint/tendermint/libs/db"
 
 	sm "github.com/Fantom-foundation/go-txflow/state"
+	"github.com/Fantom-foundation/go-txflow/txflowstate"
 	"github.com/Fantom-foundation/go-txflow/types"
 	"github.com/tendermint/tendermint/consensus"
 	"github.com/tendermint/tendermint/libs/log"
@@ -206,7 +207,16 @@ type Handshaker struct {
 	genDoc       *ttypes.GenesisDoc
 	logger       log.Logger
 
+	// order and journal of the Vtxs executed after each block
+	txOrderer sm.TxOrderer
+	txJournal sm.TxJournal
+	// Seq of the last fast-path tx the app committed
+	appJournalSeq int64
+	// Seq of the last fast-path tx replayed or skipped
+	journalSeq int64
+
 	nBlocks int // number of blocks applied to the state
+	nTxs    int // number of fast-path txs replayed to the app
 }
 
 func NewHandshaker(stateDB dbm.DB, state sm.State,
@@ -233,11 +243,30 @@ func (h *Handshaker) SetEventBus(eventBus ttypes.BlockEventPublisher) {
 	h.eventBus = eventBus
 }
 
+// SetTxJournal - sets the journal of the fast-path txs, which tells the Vtxs
+// the app committed from those it lost.
+// If not called, the Vtxs of blocks are not replayed.
+func (h *Handshaker) SetTxJournal(txJournal sm.TxJournal) {
+	h.txJournal = txJournal
+}
+
+// SetTxOrderer - sets the order the Vtxs of blocks are replayed in, which
+// must be the one of the TxSequencer executing them.
+// If not called, the Vtxs of blocks are not replayed.
+func (h *Handshaker) SetTxOrderer(txOrderer sm.TxOrderer) {
+	h.txOrderer = txOrderer
+}
+
 // NBlocks returns the number of blocks applied to the state.
 func (h *Handshaker) NBlocks() int {
 	return h.nBlocks
 }
 
+// NTxs returns the number of fast-path txs replayed to the app.
+func (h *Handshaker) NTxs() int {
+	return h.nTxs
+}
+
 // TODO: retry the handshake/replay if it fails ?
 func (h *Handshaker) Handshake(proxyApp proxy.AppConns) error {
 
@@ -266,12 +295,23 @@ func (h *Handshaker) Handshake(proxyApp proxy.AppConns) error {
 		sm.SaveState(h.stateDB, h.initialState)
 	}
 
+	// Find the last fast-path tx the app committed.
+	if err := h.loadAppJournalSeq(appHash, proxyApp); err != nil {
+		return err
+	}
+
 	// Replay blocks up to the latest in the blockstore.
-	_, err = h.ReplayBlocks(h.initialState, appHash, blockHeight, proxyApp)
+	appHash, err = h.ReplayBlocks(h.initialState, appHash, blockHeight, proxyApp)
 	if err != nil {
 		return fmt.Errorf("error on replay: %v", err)
 	}
 
+	// Every journaled tx is a Vtx of a block in the blockstore.
+	if h.replaysVtxs() && h.journalSeq != h.txJournal.LastJournalSeq() {
+		return fmt.Errorf("Journal holds fast-path txs %d to %d, which no block sequences",
+			h.journalSeq+1, h.txJournal.LastJournalSeq())
+	}
+
 	h.logger.Info("Completed ABCI Handshake - Tendermint and App are synced",
 		"appHeight", blockHeight, "appHash", fmt.Sprintf("%X", appHash))
 
@@ -337,6 +377,15 @@ func (h *Handshaker) ReplayBlocks(
 		}
 	}
 
+	// The app may have stopped amid the Vtxs of its last block.
+	if appBlockHeight > 0 && appBlockHeight <= storeBlockHeight {
+		var err error
+		appHash, err = h.resumeVtxs(appHash, appBlockHeight, proxyApp.Consensus())
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// First handle edge cases and constraints on the storeBlockHeight.
 	if storeBlockHeight == 0 {
 		assertAppHashEqualsOneFromState(appHash, state)
@@ -363,7 +412,7 @@ func (h *Handshaker) ReplayBlocks(
 		// Either the app is asking for replay, or we're all synced up.
 		if appBlockHeight < storeBlockHeight {
 			// the app is behind, so replay blocks, but no need to go through WAL (state is already synced to store)
-			return h.replayBlocks(state, proxyApp, appBlockHeight, storeBlockHeight, false)
+			return h.replayBlocks(state, proxyApp, appHash, appBlockHeight, storeBlockHeight, false)
 
 		} else if appBlockHeight == storeBlockHeight {
 			// We're good!
@@ -377,7 +426,7 @@ func (h *Handshaker) ReplayBlocks(
 		if appBlockHeight < stateBlockHeight {
 			// the app is further behind than it should be, so replay blocks
 			// but leave the last block to go through the WAL
-			return h.replayBlocks(state, proxyApp, appBlockHeight, storeBlockHeight, true)
+			return h.replayBlocks(state, proxyApp, appHash, appBlockHeight, storeBlockHeight, true)
 
 		} else if appBlockHeight == stateBlockHeight {
 			// We haven't run Commit (both the state and app are one block behind),
@@ -390,6 +439,7 @@ func (h *Handshaker) ReplayBlocks(
 
 		} else if appBlockHeight == storeBlockHeight {
 			// We ran Commit, but didn't save the state, so replayBlock with mock app.
+			// The app hash is the one after the Vtxs of the block, resumed above.
 			abciResponses, err := tsm.LoadABCIResponses(h.stateDB, storeBlockHeight)
 			if err != nil {
 				return nil, err
@@ -406,7 +456,7 @@ func (h *Handshaker) ReplayBlocks(
 		appBlockHeight, storeBlockHeight, stateBlockHeight))
 }
 
-func (h *Handshaker) replayBlocks(state sm.State, proxyApp proxy.AppConns, appBlockHeight, storeBlockHeight int64, mutateState bool) ([]byte, error) {
+func (h *Handshaker) replayBlocks(state sm.State, proxyApp proxy.AppConns, appHash []byte, appBlockHeight, storeBlockHeight int64, mutateState bool) ([]byte, error) {
 	// App is further behind than it should be, so we need to replay blocks.
 	// We replay all blocks from appBlockHeight+1.
 	//
@@ -416,8 +466,14 @@ func (h *Handshaker) replayBlocks(state sm.State, proxyApp proxy.AppConns, appBl
 	// TODO: Load the historical information to fix this and just use state.ApplyBlock
 	//
 	// If mutateState == true, the final block is replayed with h.replayBlock()
+	//
+	// The Vtxs of each block are replayed right after it, so the app hash
+	// matches the one of the next block. The app hash after InitChain is not
+	// checked.
 
-	var appHash []byte
+	if appBlockHeight == 0 {
+		appHash = nil
+	}
 	var err error
 	finalBlock := storeBlockHeight
 	if mutateState {
@@ -436,6 +492,16 @@ func (h *Handshaker) replayBlocks(state sm.State, proxyApp proxy.AppConns, appBl
 			return nil, err
 		}
 
+		if h.replaysVtxs() {
+			vtxsAppHash, err := h.replayVtxs(proxyApp.Consensus(), i, sm.ExecutableVtxs(block, h.stateDB))
+			if err != nil {
+				return nil, err
+			}
+			if vtxsAppHash != nil {
+				appHash = vtxsAppHash
+			}
+		}
+
 		h.nBlocks++
 	}
 
@@ -457,8 +523,11 @@ func (h *Handshaker) replayBlock(state sm.State, height int64, proxyApp proxy.Ap
 	block := h.store.LoadBlock(height)
 	meta := h.store.LoadBlockMeta(height)
 
-	blockExec := sm.NewBlockExecutor(h.stateDB, h.logger, proxyApp, mock.Mempool{}, mock.Mempool{}, sm.MockEvidencePool{})
+	blockExec := sm.NewBlockExecutor(h.stateDB, h.logger, proxyApp, mock.Mempool{}, sm.MockCommitPool{}, sm.MockEvidencePool{})
 	blockExec.SetEventBus(h.eventBus)
+	if h.replaysVtxs() {
+		blockExec.SetTxSequencer(vtxReplayer{h, proxyApp})
+	}
 
 	var err error
 	state, err = blockExec.ApplyBlock(state, meta.BlockID, block)
@@ -481,6 +550,163 @@ Block: %v
 	}
 }
 
+// loadAppJournalSeq asks the app for the Seq of the last fast-path tx it
+// committed. The app is only asked once the journal holds txs. If the app
+// committed a batch of intents, saved before a crash inside the
+// BatchExecutor, the batch is completed with appHash, the app's hash.
+func (h *Handshaker) loadAppJournalSeq(appHash []byte, proxyApp proxy.AppConns) error {
+	if h.txJournal == nil || h.txJournal.LastJournalSeq() == 0 {
+		return nil
+	}
+	res, err := proxyApp.Query().QuerySync(abci.RequestQuery{Path: types.QueryPathJournalSeq})
+	if err != nil {
+		return fmt.Errorf("Error querying the journal seq: %v", err)
+	}
+	if res.IsErr() {
+		return fmt.Errorf("App failed to report its journal seq: %s", res.Log)
+	}
+	seq, err := types.DecodeJournalSeq(res.Value)
+	if err != nil {
+		return fmt.Errorf("App reported an invalid journal seq: %v", err)
+	}
+	if lastSeq := h.txJournal.LastJournalSeq(); seq > lastSeq {
+		return fmt.Errorf("App committed fast-path tx %d, but the journal ends at %d", seq, lastSeq)
+	}
+	h.appJournalSeq = seq
+
+	if entry := h.txJournal.LoadJournalEntry(seq); entry != nil && !entry.IsApplied() {
+		h.logger.Info("App committed journaled txs", "seq", seq)
+		entry.AppHash = appHash
+		h.txJournal.SaveJournalEntry(entry)
+	}
+	return nil
+}
+
+// replaysVtxs returns true if the Vtxs of blocks are replayed, see
+// replayVtxs.
+func (h *Handshaker) replaysVtxs() bool {
+	return h.txOrderer != nil && h.txJournal != nil
+}
+
+// resumeVtxs replays the Vtxs of the block at height, the app's last block,
+// which the app did not commit.
+// Returns the final AppHash or an error.
+func (h *Handshaker) resumeVtxs(appHash []byte, height int64, proxyApp proxy.AppConnConsensus) ([]byte, error) {
+	if !h.replaysVtxs() {
+		return appHash, nil
+	}
+
+	// Find the journal entries of the Vtxs of earlier blocks
+	h.journalSeq = h.appJournalSeq
+	for h.journalSeq > 0 {
+		entry := h.txJournal.LoadJournalEntry(h.journalSeq)
+		if entry == nil {
+			return nil, fmt.Errorf("Missing journal entry of tx %d", h.journalSeq)
+		}
+		if entry.Height != height {
+			break
+		}
+		h.journalSeq--
+	}
+
+	block := h.store.LoadBlock(height)
+	vtxsAppHash, err := h.replayVtxs(proxyApp, height, sm.ExecutableVtxs(block, h.stateDB))
+	if err != nil {
+		return nil, err
+	}
+	if vtxsAppHash == nil {
+		return appHash, nil
+	}
+	return vtxsAppHash, nil
+}
+
+/*
+replayVtxs executes vtxs, the Vtxs of the block at height, in the order and
+batches of the TxOrderer, like the TxSequencer does after applying the block.
+
+The journal holds the Vtxs of the blocks one after another, so the position
+of a tx in the sequence gives its Seq. Txs up to the Seq the app reported
+(see QueryPathJournalSeq) are skipped, the app committed them. The others are
+executed, checked against their journal entry if they have one, and journaled
+otherwise, so they are found again if the node crashes before the end of the
+replay. The last batch of the journal may consist of intents only, saved
+before a crash inside the BatchExecutor: its last entry is saved again with
+the app hash once the app committed it.
+
+Returns the app hash after the last executed batch, or nil if no tx was
+executed.
+*/
+func (h *Handshaker) replayVtxs(proxyApp proxy.AppConnConsensus, height int64, vtxs ttypes.Txs) ([]byte, error) {
+	var appHash []byte
+	for _, batch := range h.txOrderer.Sequence(vtxs) {
+		var (
+			txs  ttypes.Txs
+			last *types.TxJournalEntry
+		)
+		for _, tx := range batch {
+			seq := h.journalSeq + 1
+			txHash := types.TxHash(tx)
+			entry := h.txJournal.LoadJournalEntry(seq)
+			switch {
+			case entry == nil && seq <= h.txJournal.LastJournalSeq():
+				return nil, fmt.Errorf("Missing journal entry of tx %d", seq)
+			case entry == nil:
+				entry = &types.TxJournalEntry{Seq: seq, Height: height, TxHash: txHash, Tx: tx}
+				h.txJournal.SaveJournalEntry(entry)
+			case entry.Height != height || entry.TxHash != txHash:
+				return nil, fmt.Errorf("Journal entry %v does not match Vtx %s of block %d", entry, txHash, height)
+			}
+			h.journalSeq = seq
+
+			if seq > h.appJournalSeq {
+				txs = append(txs, tx)
+				last = entry
+			}
+		}
+		if len(txs) == 0 {
+			continue
+		}
+
+		var err error
+		appHash, err = txflowstate.ExecCommitTxs(proxyApp, txs, h.logger)
+		if err != nil {
+			return nil, err
+		}
+		h.nTxs += len(txs)
+		h.appJournalSeq = h.journalSeq
+
+		switch {
+		case last.IsApplied():
+			if !bytes.Equal(last.AppHash, appHash) {
+				return nil, fmt.Errorf("AppHash after replaying journaled tx %d (%s) does not match. Got %X, expected %X",
+					last.Seq, last.TxHash, appHash, last.AppHash)
+			}
+		case last.Seq == h.txJournal.LastJournalSeq():
+			last.AppHash = appHash
+			h.txJournal.SaveJournalEntry(last)
+		default:
+			return nil, fmt.Errorf("Journal entry %v ends a batch on replay, but was not committed", last)
+		}
+	}
+	return appHash, nil
+}
+
+// vtxReplayer replays the Vtxs of the block the Handshaker applies to the
+// state, in place of the TxSequencer of the node.
+type vtxReplayer struct {
+	h        *Handshaker
+	proxyApp proxy.AppConnConsensus
+}
+
+var _ sm.TxSequencer = vtxReplayer{}
+
+func (r vtxReplayer) Lock() error { return nil }
+func (r vtxReplayer) Unlock()     {}
+
+func (r vtxReplayer) SequenceBlock(block *types.Block, vtxs ttypes.Txs) ([]byte, error) {
+	return r.h.replayVtxs(r.proxyApp, block.Height, vtxs)
+}
+
 func assertAppHashEqualsOneFromState(appHash []byte, state sm.State) {
 	if !bytes.Equal(appHash, state.AppHash) {
 		panic(fmt.Sprintf(`state.AppHash does not match AppHash after replay. Got
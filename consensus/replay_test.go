@@ -27,13 +27,14 @@ import (
 	"github.com/tendermint/tendermint/privval"
 	"github.com/tendermint/tendermint/proxy"
 	sm "github.com/Fantom-foundation/go-txflow/state"
+	"github.com/Fantom-foundation/go-txflow/tx"
 	"github.com/Fantom-foundation/go-txflow/types"
 	ttypes "github.com/tendermint/tendermint/types"
 	tmtime "github.com/tendermint/tendermint/types/time"
 	"github.com/tendermint/tendermint/version"
 	tsm "github.com/tendermint/tendermint/state"
 	"github.com/tendermint/tendermint/consensus"
-	dbm "github.com/tendermint/tm-cmn/db"
+	dbm "github.com/tendermint/tendermint/libs/db"
 )
 
 func TestMain(m *testing.M) {
@@ -282,7 +283,7 @@ const (
 
 var (
 	mempool = mock.Mempool{}
-	commitpool = mock.Mempool{}
+	commitpool = sm.MockCommitPool{}
 	evpool  = sm.MockEvidencePool{}
 
 	sim testSim
@@ -1107,3 +1108,150 @@ func (ica *initChainApp) InitChain(req abci.RequestInitChain) abci.ResponseInitC
 		Validators: ica.vals,
 	}
 }
+
+func TestHandshakeResumesVtxsFromAppSeq(t *testing.T) {
+	config := ResetConfig("handshake_test_")
+	defer os.RemoveAll(config.RootDir)
+	privVal := privval.LoadFilePV(config.PrivValidatorKeyFile(), config.PrivValidatorStateFile())
+	stateDB, state, store := stateAndStore(config, privVal.GetPubKey(), 0x0)
+	genDoc, _ := sm.MakeGenesisDocFromFile(config.GenesisFile())
+	state.LastValidators = state.Validators.Copy()
+
+	// block 1 sequences four txs, the second and the fourth leave the app at
+	// the same app hash
+	vtxs := ttypes.Txs{ttypes.Tx("a1"), ttypes.Tx("b1"), ttypes.Tx("a2"), ttypes.Tx("b2")}
+	store.chain = makeBlocks(1, &state, privVal)
+	store.chain[0].Vtxs = vtxs
+	state.AppHash = []byte("b")
+	sm.SaveState(stateDB, state)
+
+	// the app committed two txs, the third was delivered but its app hash
+	// never journaled
+	app := &journalSeqApp{height: 1, seq: 2, value: vtxs[1]}
+	txJournal := tx.NewTxStore(dbm.NewMemDB())
+	for i, vtx := range vtxs[:3] {
+		entry := &types.TxJournalEntry{Seq: int64(i + 1), Height: 1, TxHash: types.TxHash(vtx), Tx: vtx}
+		if i < 2 {
+			entry.AppHash = cmn.HexBytes(vtx[:1])
+		}
+		txJournal.SaveJournalEntry(entry)
+	}
+
+	handshaker := NewHandshaker(stateDB, state, store, genDoc)
+	handshaker.SetTxJournal(txJournal)
+	handshaker.SetTxOrderer(singleTxOrderer{})
+	proxyApp := proxy.NewAppConns(proxy.NewLocalClientCreator(app))
+	if err := proxyApp.Start(); err != nil {
+		t.Fatalf("Error starting proxy app connections: %v", err)
+	}
+	defer proxyApp.Stop()
+	if err := handshaker.Handshake(proxyApp); err != nil {
+		t.Fatalf("Error on abci handshake: %v", err)
+	}
+
+	assert.Equal(t, 2, handshaker.NTxs())
+	assert.Equal(t, int64(4), app.seq)
+	assert.Equal(t, int64(4), txJournal.LastJournalSeq())
+	assert.Equal(t, cmn.HexBytes("a"), txJournal.LoadJournalEntry(3).AppHash)
+	assert.Equal(t, types.TxHash(vtxs[3]), txJournal.LoadJournalEntry(4).TxHash)
+	assert.Equal(t, cmn.HexBytes("b"), txJournal.LoadJournalEntry(4).AppHash)
+}
+
+func TestHandshakeReplaysVtxsAfterEachBlock(t *testing.T) {
+	config := ResetConfig("handshake_test_")
+	defer os.RemoveAll(config.RootDir)
+	privVal := privval.LoadFilePV(config.PrivValidatorKeyFile(), config.PrivValidatorStateFile())
+	stateDB, state, store := stateAndStore(config, privVal.GetPubKey(), 0x0)
+	genDoc, _ := sm.MakeGenesisDocFromFile(config.GenesisFile())
+	state.LastValidators = state.Validators.Copy()
+
+	// block 2 carries the app hash after the Vtxs of block 1
+	vtxs := ttypes.Txs{ttypes.Tx("a1"), ttypes.Tx("b1"), ttypes.Tx("c1")}
+	store.chain = makeBlocks(2, &state, privVal)
+	store.chain[0].Vtxs = vtxs[:2]
+	store.chain[1].Vtxs = vtxs[2:]
+	store.chain[1].AppHash = []byte("b")
+	state.AppHash = []byte("c")
+	sm.SaveState(stateDB, state)
+
+	// the app committed the first tx of block 1 and then crashed
+	app := &journalSeqApp{height: 1, seq: 1, value: vtxs[0]}
+	txJournal := tx.NewTxStore(dbm.NewMemDB())
+	txJournal.SaveJournalEntry(&types.TxJournalEntry{
+		Seq: 1, Height: 1, TxHash: types.TxHash(vtxs[0]), Tx: vtxs[0], AppHash: []byte("a"),
+	})
+
+	handshaker := NewHandshaker(stateDB, state, store, genDoc)
+	handshaker.SetTxJournal(txJournal)
+	handshaker.SetTxOrderer(singleTxOrderer{})
+	proxyApp := proxy.NewAppConns(proxy.NewLocalClientCreator(app))
+	if err := proxyApp.Start(); err != nil {
+		t.Fatalf("Error starting proxy app connections: %v", err)
+	}
+	defer proxyApp.Stop()
+	if err := handshaker.Handshake(proxyApp); err != nil {
+		t.Fatalf("Error on abci handshake: %v", err)
+	}
+
+	assert.Equal(t, 1, handshaker.NBlocks())
+	assert.Equal(t, 2, handshaker.NTxs())
+	assert.Equal(t, int64(3), app.seq)
+	require.Equal(t, int64(3), txJournal.LastJournalSeq())
+	assert.Equal(t, int64(1), txJournal.LoadJournalEntry(2).Height)
+	assert.Equal(t, int64(2), txJournal.LoadJournalEntry(3).Height)
+}
+
+// sequences every tx in a batch of its own, in the order of the block
+type singleTxOrderer struct{}
+
+func (singleTxOrderer) Sequence(vtxs ttypes.Txs) []ttypes.Txs {
+	batches := make([]ttypes.Txs, len(vtxs))
+	for i, vtx := range vtxs {
+		batches[i] = ttypes.Txs{vtx}
+	}
+	return batches
+}
+
+// keeps the first byte of the last tx as app hash and counts the fast-path
+// txs, delivered outside of a block
+type journalSeqApp struct {
+	abci.BaseApplication
+	height  int64
+	seq     int64
+	value   []byte
+	inBlock bool
+}
+
+func (app *journalSeqApp) Info(req abci.RequestInfo) abci.ResponseInfo {
+	return abci.ResponseInfo{LastBlockHeight: app.height, LastBlockAppHash: app.value[:1]}
+}
+
+func (app *journalSeqApp) Query(req abci.RequestQuery) abci.ResponseQuery {
+	if req.Path != types.QueryPathJournalSeq {
+		return abci.ResponseQuery{Code: 1}
+	}
+	return abci.ResponseQuery{Value: types.EncodeJournalSeq(app.seq)}
+}
+
+func (app *journalSeqApp) BeginBlock(req abci.RequestBeginBlock) abci.ResponseBeginBlock {
+	app.inBlock = true
+	return abci.ResponseBeginBlock{}
+}
+
+func (app *journalSeqApp) DeliverTx(req abci.RequestDeliverTx) abci.ResponseDeliverTx {
+	if !app.inBlock {
+		app.seq++
+	}
+	app.value = req.Tx
+	return abci.ResponseDeliverTx{}
+}
+
+func (app *journalSeqApp) EndBlock(req abci.RequestEndBlock) abci.ResponseEndBlock {
+	app.inBlock = false
+	app.height++
+	return abci.ResponseEndBlock{}
+}
+
+func (app *journalSeqApp) Commit() abci.ResponseCommit {
+	return abci.ResponseCommit{Data: app.value[:1]}
+}
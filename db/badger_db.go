@@ -0,0 +1,413 @@
+package db
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger"
+	dbm "github.com/tendermint/tendermint/libs/db"
+)
+
+// BadgerDBBackend selects BadgerDB as the db_backend.
+const BadgerDBBackend dbm.DBBackendType = "badgerdb"
+
+const (
+	// how often the value log is garbage collected
+	badgerGCInterval = 10 * time.Minute
+	// rewrite a value log file once this share of it is stale
+	badgerGCDiscardRatio = 0.5
+)
+
+var _ dbm.DB = (*BadgerDB)(nil)
+
+/*
+BadgerDB is a dbm.DB backed by Badger, an LSM tree which keeps the values
+apart in a value log. It suits the write heavy TxStore, one commit per tx.
+
+All writes are synced, Badger has no per write sync: Set and Write are
+SetSync and WriteSync.
+
+On top of dbm.DB it can expire values (SetWithTTL) and take online backups
+(Backup).
+*/
+type BadgerDB struct {
+	db *badger.DB
+
+	quit      chan struct{}
+	gcDone    chan struct{} // closed once gcRoutine returned
+	closeOnce sync.Once
+}
+
+// NewBadgerDB opens the Badger database name in dir.
+func NewBadgerDB(name string, dir string) (*BadgerDB, error) {
+	dbPath := filepath.Join(dir, name+".db")
+	if err := os.MkdirAll(dbPath, 0755); err != nil {
+		return nil, err
+	}
+	opts := badger.DefaultOptions
+	opts.Dir = dbPath
+	opts.ValueDir = dbPath
+	opts.SyncWrites = true
+	return NewBadgerDBWithOpts(opts)
+}
+
+// NewBadgerDBWithOpts opens a Badger database with the given options.
+func NewBadgerDBWithOpts(opts badger.Options) (*BadgerDB, error) {
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, err
+	}
+	database := &BadgerDB{
+		db:     db,
+		quit:   make(chan struct{}),
+		gcDone: make(chan struct{}),
+	}
+	go database.gcRoutine()
+	return database, nil
+}
+
+// Implements DB.
+func (db *BadgerDB) Get(key []byte) []byte {
+	key = nonNilBytes(key)
+	var value []byte
+	err := db.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err != nil {
+			return err
+		}
+		value, err = item.ValueCopy(nil)
+		// An empty value is still a value, see Has
+		value = nonNilBytes(value)
+		return err
+	})
+	if err == badger.ErrKeyNotFound {
+		return nil
+	}
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+// Implements DB.
+func (db *BadgerDB) Has(key []byte) bool {
+	return db.Get(key) != nil
+}
+
+// Implements DB.
+func (db *BadgerDB) Set(key []byte, value []byte) {
+	db.SetSync(key, value)
+}
+
+// Implements DB.
+func (db *BadgerDB) SetSync(key []byte, value []byte) {
+	db.SetWithTTL(key, value, 0)
+}
+
+// SetWithTTL sets the value, which expires after ttl. A zero ttl never
+// expires.
+func (db *BadgerDB) SetWithTTL(key []byte, value []byte, ttl time.Duration) {
+	batch := db.newBatch()
+	batch.SetWithTTL(key, value, ttl)
+	batch.WriteSync()
+}
+
+// Implements DB.
+func (db *BadgerDB) Delete(key []byte) {
+	db.DeleteSync(key)
+}
+
+// Implements DB.
+func (db *BadgerDB) DeleteSync(key []byte) {
+	batch := db.newBatch()
+	batch.Delete(key)
+	batch.WriteSync()
+}
+
+func (db *BadgerDB) DB() *badger.DB {
+	return db.db
+}
+
+// Implements DB.
+// A value log GC run in progress is waited for.
+func (db *BadgerDB) Close() {
+	db.closeOnce.Do(func() {
+		close(db.quit)
+		<-db.gcDone
+		db.db.Close()
+	})
+}
+
+// Implements DB.
+func (db *BadgerDB) Print() {
+	itr := db.Iterator(nil, nil)
+	defer itr.Close()
+	for ; itr.Valid(); itr.Next() {
+		fmt.Printf("[%X]:\t[%X]\n", itr.Key(), itr.Value())
+	}
+}
+
+// Implements DB.
+func (db *BadgerDB) Stats() map[string]string {
+	lsm, vlog := db.db.Size()
+	return map[string]string{
+		"badger.lsm_size":  fmt.Sprintf("%d", lsm),
+		"badger.vlog_size": fmt.Sprintf("%d", vlog),
+	}
+}
+
+// Backup writes a full backup of the database to the file at path, while
+// the database stays online. badger.DB.Load restores it. An existing file is
+// never overwritten, and a failed backup leaves no file behind.
+func (db *BadgerDB) Backup(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if err := db.backup(f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+func (db *BadgerDB) backup(f *os.File) error {
+	if _, err := db.db.Backup(f, 0); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// Reclaim the space of the deleted, overwritten and expired values.
+func (db *BadgerDB) gcRoutine() {
+	defer close(db.gcDone)
+	ticker := time.NewTicker(badgerGCInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			// Each run rewrites at most one file
+			for db.db.RunValueLogGC(badgerGCDiscardRatio) == nil {
+			}
+		case <-db.quit:
+			return
+		}
+	}
+}
+
+//----------------------------------------
+// Batch
+
+// Implements DB.
+func (db *BadgerDB) NewBatch() dbm.Batch {
+	return db.newBatch()
+}
+
+func (db *BadgerDB) newBatch() *badgerDBBatch {
+	return &badgerDBBatch{db: db}
+}
+
+type badgerOp struct {
+	key    []byte
+	value  []byte
+	ttl    time.Duration
+	delete bool
+}
+
+type badgerDBBatch struct {
+	db  *BadgerDB
+	ops []badgerOp
+}
+
+// Implements Batch.
+func (mBatch *badgerDBBatch) Set(key, value []byte) {
+	mBatch.SetWithTTL(key, value, 0)
+}
+
+// SetWithTTL sets the value, which expires after ttl. A zero ttl never
+// expires.
+func (mBatch *badgerDBBatch) SetWithTTL(key, value []byte, ttl time.Duration) {
+	mBatch.ops = append(mBatch.ops, badgerOp{key: nonNilBytes(key), value: nonNilBytes(value), ttl: ttl})
+}
+
+// Implements Batch.
+func (mBatch *badgerDBBatch) Delete(key []byte) {
+	mBatch.ops = append(mBatch.ops, badgerOp{key: nonNilBytes(key), delete: true})
+}
+
+// Implements Batch.
+func (mBatch *badgerDBBatch) Write() {
+	mBatch.WriteSync()
+}
+
+// Implements Batch.
+// The batch is written in one transaction, all or nothing. It panics with
+// badger.ErrTxnTooBig, writing nothing, if the batch is larger than a Badger
+// transaction can hold (see badger.Options.MaxTableSize).
+func (mBatch *badgerDBBatch) WriteSync() {
+	err := mBatch.db.db.Update(func(txn *badger.Txn) error {
+		for _, op := range mBatch.ops {
+			var err error
+			switch {
+			case op.delete:
+				err = txn.Delete(op.key)
+			case op.ttl > 0:
+				err = txn.SetWithTTL(op.key, op.value, op.ttl)
+			default:
+				err = txn.Set(op.key, op.value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Implements Batch.
+func (mBatch *badgerDBBatch) Close() {
+	mBatch.ops = nil
+}
+
+//----------------------------------------
+// Iterator
+
+// Implements DB.
+func (db *BadgerDB) Iterator(start, end []byte) dbm.Iterator {
+	return newBadgerDBIterator(db.db, start, end, false)
+}
+
+// Implements DB.
+func (db *BadgerDB) ReverseIterator(start, end []byte) dbm.Iterator {
+	return newBadgerDBIterator(db.db, start, end, true)
+}
+
+// badgerDBIterator iterates over a read only transaction, a consistent
+// snapshot of the database.
+type badgerDBIterator struct {
+	txn       *badger.Txn
+	source    *badger.Iterator
+	start     []byte
+	end       []byte
+	isReverse bool
+	isInvalid bool
+}
+
+var _ dbm.Iterator = (*badgerDBIterator)(nil)
+
+func newBadgerDBIterator(db *badger.DB, start, end []byte, isReverse bool) *badgerDBIterator {
+	txn := db.NewTransaction(false)
+	opts := badger.DefaultIteratorOptions
+	opts.Reverse = isReverse
+	source := txn.NewIterator(opts)
+	if isReverse {
+		if end == nil {
+			source.Rewind()
+		} else {
+			// Seeks to the last key at or before end
+			source.Seek(end)
+			if source.Valid() && bytes.Equal(source.Item().Key(), end) {
+				source.Next()
+			}
+		}
+	} else {
+		if start == nil {
+			source.Rewind()
+		} else {
+			source.Seek(start)
+		}
+	}
+	return &badgerDBIterator{
+		txn:       txn,
+		source:    source,
+		start:     start,
+		end:       end,
+		isReverse: isReverse,
+	}
+}
+
+// Implements Iterator.
+func (itr *badgerDBIterator) Domain() ([]byte, []byte) {
+	return itr.start, itr.end
+}
+
+// Implements Iterator.
+func (itr *badgerDBIterator) Valid() bool {
+	// Once invalid, forever invalid.
+	if itr.isInvalid {
+		return false
+	}
+
+	if !itr.source.Valid() {
+		itr.isInvalid = true
+		return false
+	}
+
+	// If key is end or past it, invalid.
+	key := itr.source.Item().Key()
+	if itr.isReverse {
+		if itr.start != nil && bytes.Compare(key, itr.start) < 0 {
+			itr.isInvalid = true
+			return false
+		}
+	} else {
+		if itr.end != nil && bytes.Compare(itr.end, key) <= 0 {
+			itr.isInvalid = true
+			return false
+		}
+	}
+
+	// Valid
+	return true
+}
+
+// Implements Iterator.
+func (itr *badgerDBIterator) Key() []byte {
+	itr.assertIsValid()
+	return itr.source.Item().KeyCopy(nil)
+}
+
+// Implements Iterator.
+func (itr *badgerDBIterator) Value() []byte {
+	itr.assertIsValid()
+	value, err := itr.source.Item().ValueCopy(nil)
+	if err != nil {
+		panic(err)
+	}
+	return nonNilBytes(value)
+}
+
+// Implements Iterator.
+func (itr *badgerDBIterator) Next() {
+	itr.assertIsValid()
+	itr.source.Next()
+}
+
+// Implements Iterator.
+func (itr *badgerDBIterator) Close() {
+	itr.source.Close()
+	itr.txn.Discard()
+}
+
+func (itr *badgerDBIterator) assertIsValid() {
+	if !itr.Valid() {
+		panic("badgerDBIterator is invalid")
+	}
+}
+
+//----------------------------------------
+
+func nonNilBytes(bz []byte) []byte {
+	if bz == nil {
+		return []byte{}
+	}
+	return bz
+}
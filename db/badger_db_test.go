@@ -0,0 +1,152 @@
+package db
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cmn "github.com/tendermint/tendermint/libs/common"
+)
+
+func newTestBadgerDB(t *testing.T) (*BadgerDB, func()) {
+	dir, err := ioutil.TempDir("", "badgerdb")
+	require.NoError(t, err)
+	db, err := NewBadgerDB(fmt.Sprintf("test_%x", cmn.RandStr(12)), dir)
+	require.NoError(t, err)
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestBadgerDBGetSetDelete(t *testing.T) {
+	db, cleanup := newTestBadgerDB(t)
+	defer cleanup()
+
+	assert.Nil(t, db.Get([]byte("a")))
+	assert.False(t, db.Has([]byte("a")))
+
+	db.Set([]byte("a"), []byte("1"))
+	assert.Equal(t, []byte("1"), db.Get([]byte("a")))
+	assert.True(t, db.Has([]byte("a")))
+
+	// An empty value is not a missing one
+	db.SetSync([]byte("b"), nil)
+	assert.Equal(t, []byte{}, db.Get([]byte("b")))
+
+	db.Delete([]byte("a"))
+	assert.Nil(t, db.Get([]byte("a")))
+}
+
+func TestBadgerDBBatch(t *testing.T) {
+	db, cleanup := newTestBadgerDB(t)
+	defer cleanup()
+	db.Set([]byte("c"), []byte("3"))
+
+	batch := db.NewBatch()
+	batch.Set([]byte("a"), []byte("1"))
+	batch.Set([]byte("b"), []byte("2"))
+	batch.Delete([]byte("c"))
+	// Nothing is written before Write
+	assert.Nil(t, db.Get([]byte("a")))
+	batch.WriteSync()
+	batch.Close()
+
+	assert.Equal(t, []byte("1"), db.Get([]byte("a")))
+	assert.Equal(t, []byte("2"), db.Get([]byte("b")))
+	assert.Nil(t, db.Get([]byte("c")))
+}
+
+func TestBadgerDBIterator(t *testing.T) {
+	db, cleanup := newTestBadgerDB(t)
+	defer cleanup()
+	for _, k := range []string{"1", "2", "3", "4"} {
+		db.Set([]byte(k), []byte("v"+k))
+	}
+
+	keys := func(start, end []byte, reverse bool) (keys []string) {
+		itr := db.Iterator(start, end)
+		if reverse {
+			itr = db.ReverseIterator(start, end)
+		}
+		defer itr.Close()
+		for ; itr.Valid(); itr.Next() {
+			assert.Equal(t, "v"+string(itr.Key()), string(itr.Value()))
+			keys = append(keys, string(itr.Key()))
+		}
+		return keys
+	}
+	assert.Equal(t, []string{"1", "2", "3", "4"}, keys(nil, nil, false))
+	assert.Equal(t, []string{"2", "3"}, keys([]byte("2"), []byte("4"), false))
+	assert.Equal(t, []string{"4", "3", "2", "1"}, keys(nil, nil, true))
+	assert.Equal(t, []string{"3", "2"}, keys([]byte("2"), []byte("4"), true))
+	assert.Equal(t, []string{"2", "1"}, keys(nil, []byte("25"), true))
+}
+
+func TestBadgerDBTTL(t *testing.T) {
+	db, cleanup := newTestBadgerDB(t)
+	defer cleanup()
+
+	batch := db.newBatch()
+	batch.SetWithTTL([]byte("a"), []byte("1"), time.Second)
+	batch.Set([]byte("b"), []byte("2"))
+	batch.WriteSync()
+	assert.Equal(t, []byte("1"), db.Get([]byte("a")))
+
+	// Badger expires at a second resolution
+	time.Sleep(2 * time.Second)
+	assert.Nil(t, db.Get([]byte("a")))
+	assert.Equal(t, []byte("2"), db.Get([]byte("b")))
+}
+
+func TestBadgerDBBackup(t *testing.T) {
+	db, cleanup := newTestBadgerDB(t)
+	defer cleanup()
+	db.Set([]byte("a"), []byte("1"))
+
+	dir, err := ioutil.TempDir("", "badgerdb_backup")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "backup")
+	require.NoError(t, db.Backup(path))
+	// Never overwrites a backup
+	assert.Error(t, db.Backup(path))
+
+	restored, err := NewBadgerDB("restored", dir)
+	require.NoError(t, err)
+	defer restored.Close()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	require.NoError(t, restored.DB().Load(f))
+	assert.Equal(t, []byte("1"), restored.Get([]byte("a")))
+}
+
+func TestBadgerDBBatchTooBig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "badgerdb")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	opts := badger.DefaultOptions
+	opts.Dir = dir
+	opts.ValueDir = dir
+	// Holds a few hundred small entries per transaction
+	opts.MaxTableSize = 1 << 16
+	db, err := NewBadgerDBWithOpts(opts)
+	require.NoError(t, err)
+	defer db.Close()
+
+	batch := db.NewBatch()
+	defer batch.Close()
+	for i := 0; i < 10000; i++ {
+		batch.Set([]byte(fmt.Sprintf("%05d", i)), []byte("v"))
+	}
+	assert.PanicsWithValue(t, badger.ErrTxnTooBig, batch.WriteSync)
+	// Nothing of the batch was written
+	assert.Nil(t, db.Get([]byte("00000")))
+}
@@ -0,0 +1,164 @@
+package evidence
+
+import (
+	"fmt"
+
+	dbm "github.com/tendermint/tendermint/libs/db"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+/*
+Requirements:
+	- Valid new evidence must be persisted immediately and never forgotten
+	- Uncommitted evidence must be continuously broadcast
+
+Impl:
+	- First commit atomically in pending, lookup.
+	- Once committed, atomically remove from pending and update lookup.
+
+Unlike the tendermint store, there is no outqueue of evidence sorted by
+priority: the reactor broadcasts from the pool's clist, in arrival order.
+
+Schema for indexing evidence (note you need both height and hash to find a piece of evidence):
+
+"evidence-lookup"/<evidence-height>/<evidence-hash> -> EvidenceInfo
+"evidence-pending"/<evidence-height>/<evidence-hash> -> EvidenceInfo
+*/
+
+type EvidenceInfo struct {
+	Committed bool
+	Evidence  ttypes.Evidence
+}
+
+const (
+	baseKeyLookup  = "evidence-lookup"  // all evidence
+	baseKeyPending = "evidence-pending" // broadcast but not committed
+)
+
+func keyLookup(evidence ttypes.Evidence) []byte {
+	return keyLookupFromHeightAndHash(evidence.Height(), evidence.Hash())
+}
+
+// big endian padded hex
+func bE(h int64) string {
+	return fmt.Sprintf("%0.16X", h)
+}
+
+func keyLookupFromHeightAndHash(height int64, hash []byte) []byte {
+	return _key("%s/%s/%X", baseKeyLookup, bE(height), hash)
+}
+
+func keyPending(evidence ttypes.Evidence) []byte {
+	return _key("%s/%s/%X", baseKeyPending, bE(evidence.Height()), evidence.Hash())
+}
+
+func _key(fmt_ string, o ...interface{}) []byte {
+	return []byte(fmt.Sprintf(fmt_, o...))
+}
+
+// EvidenceStore is a store of all the evidence we've seen, including
+// evidence that has been committed and evidence that has been verified but not yet committed.
+type EvidenceStore struct {
+	db dbm.DB
+}
+
+func NewEvidenceStore(db dbm.DB) *EvidenceStore {
+	return &EvidenceStore{
+		db: db,
+	}
+}
+
+// PendingEvidence returns up to maxNum known, uncommitted evidence.
+// If maxNum is -1, all evidence is returned.
+func (store *EvidenceStore) PendingEvidence(maxNum int64) (evidence []ttypes.Evidence) {
+	return store.listEvidence(baseKeyPending, maxNum)
+}
+
+// listEvidence lists up to maxNum pieces of evidence for the given prefix key.
+// It is wrapped by PendingEvidence for convenience.
+// If maxNum is -1, there's no cap on the size of returned evidence.
+func (store *EvidenceStore) listEvidence(prefixKey string, maxNum int64) (evidence []ttypes.Evidence) {
+	var count int64
+	iter := dbm.IteratePrefix(store.db, []byte(prefixKey))
+	defer iter.Close()
+	for ; iter.Valid(); iter.Next() {
+		val := iter.Value()
+
+		if count == maxNum {
+			return evidence
+		}
+		count++
+
+		var ei EvidenceInfo
+		err := cdc.UnmarshalBinaryBare(val, &ei)
+		if err != nil {
+			panic(err)
+		}
+		evidence = append(evidence, ei.Evidence)
+	}
+	return evidence
+}
+
+// GetEvidenceInfo fetches the EvidenceInfo with the given height and hash.
+// If not found, ei.Evidence is nil.
+func (store *EvidenceStore) GetEvidenceInfo(height int64, hash []byte) EvidenceInfo {
+	key := keyLookupFromHeightAndHash(height, hash)
+	val := store.db.Get(key)
+
+	if len(val) == 0 {
+		return EvidenceInfo{}
+	}
+	var ei EvidenceInfo
+	err := cdc.UnmarshalBinaryBare(val, &ei)
+	if err != nil {
+		panic(err)
+	}
+	return ei
+}
+
+// AddNewEvidence adds the given evidence to the database.
+// It returns false if the evidence is already stored.
+func (store *EvidenceStore) AddNewEvidence(evidence ttypes.Evidence) bool {
+	// check if we already have seen it
+	ei := store.getInfo(evidence)
+	if ei.Evidence != nil {
+		return false
+	}
+
+	ei = EvidenceInfo{
+		Committed: false,
+		Evidence:  evidence,
+	}
+	eiBytes := cdc.MustMarshalBinaryBare(ei)
+
+	// add it to the store
+	key := keyPending(evidence)
+	store.db.Set(key, eiBytes)
+
+	key = keyLookup(evidence)
+	store.db.SetSync(key, eiBytes)
+
+	return true
+}
+
+// MarkEvidenceAsCommitted removes evidence from pending and sets the state to committed.
+func (store *EvidenceStore) MarkEvidenceAsCommitted(evidence ttypes.Evidence) {
+	pendingKey := keyPending(evidence)
+	store.db.Delete(pendingKey)
+
+	ei := EvidenceInfo{
+		Committed: true,
+		Evidence:  evidence,
+	}
+
+	lookupKey := keyLookup(evidence)
+	store.db.SetSync(lookupKey, cdc.MustMarshalBinaryBare(ei))
+}
+
+//---------------------------------------------------
+// utils
+
+// getInfo is convenience for calling GetEvidenceInfo if we have the full evidence.
+func (store *EvidenceStore) getInfo(evidence ttypes.Evidence) EvidenceInfo {
+	return store.GetEvidenceInfo(evidence.Height(), evidence.Hash())
+}
@@ -0,0 +1,68 @@
+package evidence
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-txflow/types"
+	dbm "github.com/tendermint/tendermint/libs/db"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+//-------------------------------------------
+
+func makeDuplicateTxVoteEvidence(t *testing.T, chainID string, height int64) *types.DuplicateTxVoteEvidence {
+	pv := types.NewMockPV()
+	tx := ttypes.Tx("tx")
+	voteA := types.NewTxVote(height, types.TxHash(tx), types.TxKey(tx), pv.GetPubKey().Address())
+	require.NoError(t, pv.SignTxVote(chainID, &voteA))
+	// the same validator rejects the tx at the same height
+	voteB := voteA
+	voteB.Code, voteB.Codespace = 1, "app"
+	require.NoError(t, pv.SignTxVote(chainID, &voteB))
+	return &types.DuplicateTxVoteEvidence{
+		PubKey: pv.GetPubKey(),
+		VoteA:  &voteA,
+		VoteB:  &voteB,
+	}
+}
+
+func TestStoreAddDuplicateTxVote(t *testing.T) {
+	assert := assert.New(t)
+
+	db := dbm.NewMemDB()
+	store := NewEvidenceStore(db)
+
+	ev := makeDuplicateTxVoteEvidence(t, "test-chain", 2)
+
+	added := store.AddNewEvidence(ev)
+	assert.True(added)
+
+	// cant add twice
+	added = store.AddNewEvidence(ev)
+	assert.False(added)
+
+	pendingEv := store.PendingEvidence(-1)
+	require.Equal(t, 1, len(pendingEv))
+	assert.True(ev.Equal(pendingEv[0]))
+}
+
+func TestStoreCommitDuplicateTxVote(t *testing.T) {
+	assert := assert.New(t)
+
+	db := dbm.NewMemDB()
+	store := NewEvidenceStore(db)
+
+	ev := makeDuplicateTxVoteEvidence(t, "test-chain", 2)
+
+	store.AddNewEvidence(ev)
+	store.MarkEvidenceAsCommitted(ev)
+
+	assert.Equal(0, len(store.PendingEvidence(-1)))
+
+	ei := store.GetEvidenceInfo(ev.Height(), ev.Hash())
+	assert.True(ei.Committed)
+	assert.True(ev.Equal(ei.Evidence))
+}
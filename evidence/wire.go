@@ -0,0 +1,29 @@
+/*
+Package evidence is a trimmed copy of the tendermint evidence package.
+
+The tendermint pool can't be reused: its codec is private, so it can't learn
+to decode DuplicateTxVoteEvidence in EvidenceListMessages and EvidenceInfos,
+and it is updated with tendermint blocks and state, not TxFlow ones.
+*/
+package evidence
+
+import (
+	"github.com/Fantom-foundation/go-txflow/types"
+	amino "github.com/tendermint/go-amino"
+	cryptoAmino "github.com/tendermint/tendermint/crypto/encoding/amino"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+var cdc = amino.NewCodec()
+
+func init() {
+	RegisterEvidenceMessages(cdc)
+	cryptoAmino.RegisterAmino(cdc)
+	ttypes.RegisterEvidences(cdc)
+	types.RegisterTxVoteEvidences(cdc)
+}
+
+// For testing purposes only
+func RegisterMockEvidences() {
+	ttypes.RegisterMockEvidences(cdc)
+}
@@ -0,0 +1,208 @@
+/*
+Package lite verifies fast-path tx commits without running a node.
+
+A tx vote at height H is counted against the validators of height H+1, which
+the tick-block header at H commits to in its NextValidatorsHash. TxVerifier
+proves that header with a Tendermint lite DynamicVerifier: starting from a
+trusted FullCommit, it follows the tick-block headers and their Tendermint
+commits fetched from a source, through any validator set change, up to H.
+Only then are the votes of the commit counted against the proven set.
+
+Any Tendermint lite Provider serves as the source, e.g. a lite/client
+HTTPProvider on the RPC of a node, which serves the Tendermint routes.
+*/
+package lite
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	cmn "github.com/tendermint/tendermint/libs/common"
+	"github.com/tendermint/tendermint/libs/log"
+	"github.com/tendermint/tendermint/lite"
+	lerr "github.com/tendermint/tendermint/lite/errors"
+	ttypes "github.com/tendermint/tendermint/types"
+
+	"github.com/Fantom-foundation/go-txflow/types"
+)
+
+// TxCommitResult is what a verified commit proves about its tx.
+type TxCommitResult struct {
+	TxHash string `json:"tx_hash"`
+	// the height of the votes, they are counted against the validators of
+	// Height+1
+	Height         int64        `json:"height"`
+	ValidatorsHash cmn.HexBytes `json:"validators_hash"`
+	// the voting power of the validators with a valid accept vote
+	FinalizedStake int64 `json:"finalized_stake"`
+	TotalStake     int64 `json:"total_stake"`
+}
+
+// IsFinal returns true if more than 2/3 of the stake accepted the tx.
+func (res *TxCommitResult) IsFinal() bool {
+	return res.FinalizedStake > res.TotalStake*2/3
+}
+
+// ErrInsufficientStake is returned, along with the result, for a valid
+// commit which holds 2/3 of the stake or less.
+type ErrInsufficientStake struct {
+	Got    int64
+	Needed int64
+}
+
+func (e ErrInsufficientStake) Error() string {
+	return fmt.Sprintf("insufficient finalized stake: got %d, needed %d", e.Got, e.Needed)
+}
+
+// IsErrInsufficientStake returns true if err is an ErrInsufficientStake.
+func IsErrInsufficientStake(err error) bool {
+	_, ok := errors.Cause(err).(ErrInsufficientStake)
+	return ok
+}
+
+//-----------------------------------------------------------------------------
+
+// TxVerifier verifies fast-path tx commits against the validator sets it
+// proved from a trusted FullCommit. It is safe for concurrent use.
+type TxVerifier struct {
+	chainID string
+	logger  log.Logger
+
+	// Already validated, stored locally
+	trusted lite.PersistentProvider
+	// New tick-block headers and validator sets
+	source lite.Provider
+
+	verifier *lite.DynamicVerifier
+}
+
+// NewTxVerifier returns a new TxVerifier. The trusted provider stores the
+// headers it validated, it must hold a root of trust, see Trust. The source
+// provider is asked for the headers and validator sets it is missing.
+func NewTxVerifier(chainID string, trusted lite.PersistentProvider, source lite.Provider) *TxVerifier {
+	return &TxVerifier{
+		chainID:  chainID,
+		logger:   log.NewNopLogger(),
+		trusted:  trusted,
+		source:   source,
+		verifier: lite.NewDynamicVerifier(chainID, trusted, source),
+	}
+}
+
+// SetLogger - sets the logger of the TxVerifier and its providers.
+func (tv *TxVerifier) SetLogger(logger log.Logger) {
+	tv.logger = logger.With("module", "lite")
+	tv.verifier.SetLogger(logger)
+}
+
+// ChainID returns the chain the TxVerifier verifies commits of.
+func (tv *TxVerifier) ChainID() string {
+	return tv.chainID
+}
+
+// Trust validates fc and saves it as a root of trust. It must be obtained
+// out of band, e.g. from the genesis or a checkpoint the user trusts: the
+// TxVerifier can only verify the commits of its height and above.
+func (tv *TxVerifier) Trust(fc lite.FullCommit) error {
+	if fc.SignedHeader.Header == nil || fc.SignedHeader.Commit == nil {
+		return errors.New("full commit has no signed header")
+	}
+	// Checks the chain too
+	if err := fc.ValidateFull(tv.chainID); err != nil {
+		return errors.Wrap(err, "invalid full commit")
+	}
+	return tv.trusted.SaveFullCommit(fc)
+}
+
+// VerifyCommit verifies that commit finalizes tx. The commit must not mix
+// votes of different heights, as the vote set of a tx does once rebased.
+//
+// An invalid commit or one that can't be checked against a proven validator
+// set returns an error and no result. A valid commit with 2/3 of the stake or
+// less returns its result along with an ErrInsufficientStake.
+func (tv *TxVerifier) VerifyCommit(tx ttypes.Tx, commit *types.Commit) (*TxCommitResult, error) {
+	if commit == nil || len(commit.Commits) == 0 {
+		return nil, errors.New("empty commit")
+	}
+	valSet, err := tv.Validators(commit.Height())
+	if err != nil {
+		return nil, err
+	}
+	cert, err := types.NewCommitCertificate(tv.chainID, commit, valSet)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid commit")
+	}
+	return tv.verify(tx, cert, valSet)
+}
+
+// VerifyCertificate verifies that cert finalizes tx, like VerifyCommit.
+func (tv *TxVerifier) VerifyCertificate(tx ttypes.Tx, cert *types.CommitCertificate) (*TxCommitResult, error) {
+	if cert.ChainID != tv.chainID {
+		return nil, errors.Errorf("certificate is for chain %s, not %s", cert.ChainID, tv.chainID)
+	}
+	valSet, err := tv.Validators(cert.Height)
+	if err != nil {
+		return nil, err
+	}
+	return tv.verify(tx, cert, valSet)
+}
+
+func (tv *TxVerifier) verify(tx ttypes.Tx, cert *types.CommitCertificate, valSet *ttypes.ValidatorSet) (*TxCommitResult, error) {
+	if cert.TxHash != types.TxHash(tx) {
+		return nil, errors.Wrapf(types.ErrVoteInvalidTxHash, "commit is for tx %s, not %s", cert.TxHash, types.TxHash(tx))
+	}
+	stake, err := cert.VotingPower(valSet)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid commit")
+	}
+	res := &TxCommitResult{
+		TxHash:         cert.TxHash,
+		Height:         cert.Height,
+		ValidatorsHash: valSet.Hash(),
+		FinalizedStake: stake,
+		TotalStake:     valSet.TotalVotingPower(),
+	}
+	if !res.IsFinal() {
+		return res, ErrInsufficientStake{Got: stake, Needed: res.TotalStake*2/3 + 1}
+	}
+	return res, nil
+}
+
+// Validators returns the proven validators the votes at height are counted
+// against, those of height+1. The tick-block header at height is verified
+// first if it is not trusted yet.
+func (tv *TxVerifier) Validators(height int64) (*ttypes.ValidatorSet, error) {
+	fc, err := tv.trusted.LatestFullCommit(tv.chainID, height, height)
+	if err == nil {
+		return fc.NextValidators, nil
+	}
+	if !lerr.IsErrCommitNotFound(err) {
+		return nil, err
+	}
+
+	// Not trusted yet, prove the header from the latest trusted one
+	fc, err = tv.source.LatestFullCommit(tv.chainID, height, height)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fetching the tick-block header at height %d", height)
+	}
+	if fc.Height() != height {
+		return nil, errors.Wrapf(lerr.ErrCommitNotFound(), "no tick-block header at height %d", height)
+	}
+	if err := tv.verifier.Verify(fc.SignedHeader); err != nil {
+		return nil, errors.Wrapf(err, "verifying the tick-block header at height %d", height)
+	}
+	tv.logger.Debug("Verified tick-block header", "height", height, "hash", fc.SignedHeader.Hash())
+
+	// The DynamicVerifier only trusts the header along with the validators
+	// it commits to
+	fc, err = tv.trusted.LatestFullCommit(tv.chainID, height, height)
+	if err != nil {
+		return nil, errors.Wrapf(err, "the validators of height %d are unknown", height+1)
+	}
+	if !bytes.Equal(fc.NextValidators.Hash(), fc.SignedHeader.NextValidatorsHash) {
+		return nil, lerr.ErrUnexpectedValidators(fc.SignedHeader.NextValidatorsHash, fc.NextValidators.Hash())
+	}
+	return fc.NextValidators, nil
+}
@@ -0,0 +1,122 @@
+package lite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/crypto"
+	dbm "github.com/tendermint/tendermint/libs/db"
+	"github.com/tendermint/tendermint/lite"
+	ttypes "github.com/tendermint/tendermint/types"
+	tmtime "github.com/tendermint/tendermint/types/time"
+
+	"github.com/Fantom-foundation/go-txflow/types"
+)
+
+const chainID = "test_chain_id"
+
+// signTx returns the accept votes of the keys for tx at height.
+func signTx(t *testing.T, tx ttypes.Tx, height int64, keys []crypto.PrivKey) []*types.CommitSig {
+	commitSigs := make([]*types.CommitSig, len(keys))
+	for i, key := range keys {
+		pv := types.NewMockPVWithParams(key, false, false, false)
+		vote := types.NewTxVote(height, types.TxHash(tx), types.TxKey(tx), pv.GetPubKey().Address())
+		vote.Timestamp = tmtime.Now()
+		require.NoError(t, pv.SignTxVote(chainID, &vote))
+		commitSigs[i] = vote.CommitSig()
+	}
+	return commitSigs
+}
+
+func TestTxVerifier(t *testing.T) {
+	keys := lite.GenSecpPrivKeys(4)
+	// Half of the validators are replaced from height 3
+	newKeys := append(keys[:2:2], lite.GenSecpPrivKeys(2)...)
+	vals, newVals := keys.ToValidators(10, 0), newKeys.ToValidators(10, 0)
+
+	source := lite.NewDBProvider("source", dbm.NewMemDB())
+	fcs := []lite.FullCommit{
+		keys.GenFullCommit(chainID, 1, nil, vals, vals, []byte("app"), nil, nil, 0, len(keys)),
+		keys.GenFullCommit(chainID, 2, nil, vals, newVals, []byte("app"), nil, nil, 0, len(keys)),
+		newKeys.GenFullCommit(chainID, 3, nil, newVals, newVals, []byte("app"), nil, nil, 0, len(newKeys)),
+		newKeys.GenFullCommit(chainID, 4, nil, newVals, newVals, []byte("app"), nil, nil, 0, len(newKeys)),
+	}
+	for _, fc := range fcs {
+		require.NoError(t, source.SaveFullCommit(fc))
+	}
+
+	tv := NewTxVerifier(chainID, lite.NewDBProvider("trusted", dbm.NewMemDB()), source)
+	require.Error(t, tv.Trust(keys.GenFullCommit("other_chain_id", 1, nil, vals, vals, nil, nil, nil, 0, len(keys))))
+	require.NoError(t, tv.Trust(fcs[0]))
+
+	tx := ttypes.Tx("tx")
+
+	// Votes at height 1 are counted against the validators of height 2
+	res, err := tv.VerifyCommit(tx, types.NewCommit(types.TxHash(tx), signTx(t, tx, 1, keys[:3])))
+	require.NoError(t, err)
+	assert.True(t, res.IsFinal())
+	assert.Equal(t, int64(30), res.FinalizedStake)
+	assert.Equal(t, int64(40), res.TotalStake)
+	assert.EqualValues(t, vals.Hash(), res.ValidatorsHash)
+
+	// Votes at height 2 need the validators of height 3, proven through the
+	// tick-block header at height 2
+	commit := types.NewCommit(types.TxHash(tx), signTx(t, tx, 2, newKeys))
+	res, err = tv.VerifyCommit(tx, commit)
+	require.NoError(t, err)
+	assert.Equal(t, int64(40), res.FinalizedStake)
+	assert.EqualValues(t, newVals.Hash(), res.ValidatorsHash)
+
+	cert, err := types.NewCommitCertificate(chainID, commit, newVals)
+	require.NoError(t, err)
+	res, err = tv.VerifyCertificate(tx, cert)
+	require.NoError(t, err)
+	assert.True(t, res.IsFinal())
+
+	// The removed validators don't count anymore
+	_, err = tv.VerifyCommit(tx, types.NewCommit(types.TxHash(tx), signTx(t, tx, 3, keys[1:])))
+	assert.Error(t, err)
+
+	// A valid commit without 2/3 still reports its stake
+	res, err = tv.VerifyCommit(tx, types.NewCommit(types.TxHash(tx), signTx(t, tx, 3, newKeys[:2])))
+	require.Error(t, err)
+	assert.True(t, IsErrInsufficientStake(err))
+	require.NotNil(t, res)
+	assert.False(t, res.IsFinal())
+	assert.Equal(t, int64(20), res.FinalizedStake)
+
+	// Wrong tx, unknown heights
+	_, err = tv.VerifyCommit(ttypes.Tx("other"), commit)
+	assert.Error(t, err)
+	_, err = tv.VerifyCommit(tx, types.NewCommit(types.TxHash(tx), signTx(t, tx, 0, keys)))
+	assert.Error(t, err)
+	_, err = tv.VerifyCommit(tx, types.NewCommit(types.TxHash(tx), signTx(t, tx, 5, newKeys)))
+	assert.Error(t, err)
+	_, err = tv.VerifyCommit(tx, types.NewCommit(types.TxHash(tx), nil))
+	assert.Error(t, err)
+}
+
+func TestTxVerifierForgedHeader(t *testing.T) {
+	keys := lite.GenSecpPrivKeys(4)
+	otherKeys := lite.GenSecpPrivKeys(4)
+	vals, otherVals := keys.ToValidators(10, 0), otherKeys.ToValidators(10, 0)
+
+	// The source serves a header at height 2 signed by validators the
+	// trusted ones never handed over to
+	source := lite.NewDBProvider("source", dbm.NewMemDB())
+	trustedFC := keys.GenFullCommit(chainID, 1, nil, vals, vals, nil, nil, nil, 0, len(keys))
+	require.NoError(t, source.SaveFullCommit(trustedFC))
+	require.NoError(t, source.SaveFullCommit(
+		otherKeys.GenFullCommit(chainID, 2, nil, otherVals, otherVals, nil, nil, nil, 0, len(otherKeys))))
+
+	tv := NewTxVerifier(chainID, lite.NewDBProvider("trusted", dbm.NewMemDB()), source)
+	require.NoError(t, tv.Trust(trustedFC))
+
+	tx := ttypes.Tx("tx")
+	_, err := tv.VerifyCommit(tx, types.NewCommit(types.TxHash(tx), signTx(t, tx, 2, otherKeys)))
+	assert.Error(t, err)
+	_, err = tv.Validators(2)
+	assert.Error(t, err)
+}
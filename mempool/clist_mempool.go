@@ -54,6 +54,9 @@ type CListMempool struct {
 	preCheck     mempool.PreCheckFunc
 	postCheck    mempool.PostCheckFunc
 
+	// while full, new txs are rejected
+	commitPool *CommitPool
+
 	// Track whether we're rechecking txs.
 	// These are not protected by a mutex and are expected to be mutated
 	// in serial (ie. by abci responses which are called in serial).
@@ -80,6 +83,9 @@ type CListMempool struct {
 	// A log of mempool txs
 	wal *auto.AutoFile
 
+	// called with the txs the app rejected in their first CheckTx
+	onRejectedTx func(types.Tx, *abci.ResponseCheckTx)
+
 	logger log.Logger
 
 	metrics *mempool.Metrics
@@ -120,6 +126,14 @@ func NewCListMempool(
 	return mempool
 }
 
+// SetRejectedTxHandler sets the function called with the txs which failed
+// their first CheckTx and the app's response. It is called from the app's
+// response callback and must not block.
+// NOTE: not thread safe - should only be called once, on startup
+func (mem *CListMempool) SetRejectedTxHandler(onRejectedTx func(types.Tx, *abci.ResponseCheckTx)) {
+	mem.onRejectedTx = onRejectedTx
+}
+
 // NOTE: not thread safe - should only be called once, on startup
 func (mem *CListMempool) EnableTxsAvailable() {
 	mem.txsAvailable = make(chan struct{}, 1)
@@ -142,6 +156,12 @@ func WithPostCheck(f mempool.PostCheckFunc) CListMempoolOption {
 	return func(mem *CListMempool) { mem.postCheck = f }
 }
 
+// WithCommitPool makes the mempool reject new txs while the CommitPool is
+// full.
+func WithCommitPool(commitPool *CommitPool) CListMempoolOption {
+	return func(mem *CListMempool) { mem.commitPool = commitPool }
+}
+
 // WithMetrics sets the metrics.
 func WithMetrics(metrics *mempool.Metrics) CListMempoolOption {
 	return func(mem *CListMempool) { mem.metrics = metrics }
@@ -253,6 +273,14 @@ func (mem *CListMempool) CheckTxWithInfo(tx types.Tx, cb func(*abci.Response), t
 			txsBytes, mem.config.MaxTxsBytes}
 	}
 
+	// Committed txs can't be dropped, so hold off new ones until a tick
+	// block drains the CommitPool
+	if mem.commitPool != nil {
+		if err := mem.commitPool.IsFull(); err != nil {
+			return err
+		}
+	}
+
 	// The size of the corresponding amino-encoded TxMessage
 	// can't be larger than the maxMsgSize, otherwise we can't
 	// relay it to peers.
@@ -393,9 +421,10 @@ func (mem *CListMempool) resCbFirstTime(tx []byte, peerID uint16, res *abci.Resp
 		}
 		if (r.CheckTx.Code == abci.CodeTypeOK) && postCheckErr == nil {
 			memTx := &MempoolTx{
-				height:    mem.height,
-				gasWanted: r.CheckTx.GasWanted,
-				Tx:        tx,
+				height:       mem.height,
+				gasWanted:    r.CheckTx.GasWanted,
+				Tx:           tx,
+				ConflictKeys: ConflictKeys(r.CheckTx.Events),
 			}
 			memTx.senders.Store(peerID, true)
 			mem.addTx(memTx)
@@ -412,6 +441,10 @@ func (mem *CListMempool) resCbFirstTime(tx []byte, peerID uint16, res *abci.Resp
 			mem.metrics.FailedTxs.Add(1)
 			// remove from cache (it might be good later)
 			mem.cache.Remove(tx)
+			// Only the app's verdict is voted on, not local post checks
+			if r.CheckTx.Code != abci.CodeTypeOK && mem.onRejectedTx != nil {
+				mem.onRejectedTx(tx, r.CheckTx)
+			}
 		}
 	default:
 		// ignore other messages
@@ -633,6 +666,9 @@ type MempoolTx struct {
 	gasWanted int64    // amount of gas this tx states it will require
 	Tx        types.Tx //
 
+	// app declared keys this tx conflicts on, see ConflictKeys
+	ConflictKeys []string
+
 	// ids of peers who've sent us this tx (as a map for quick lookups).
 	// senders: PeerID -> bool
 	senders sync.Map
@@ -602,3 +602,15 @@ func abciResponses(n int, code uint32) []*abci.ResponseDeliverTx {
 	}
 	return responses
 }
+
+func TestConflictKeys(t *testing.T) {
+	events := []abci.Event{
+		{Type: "transfer", Attributes: []cmn.KVPair{{Key: []byte("sender"), Value: []byte("alice")}}},
+		{Type: ConflictEventType, Attributes: []cmn.KVPair{
+			{Key: []byte("account"), Value: []byte("alice:7")},
+			{Key: []byte("object"), Value: []byte("42")},
+		}},
+	}
+	assert.Equal(t, []string{"account/alice:7", "object/42"}, ConflictKeys(events))
+	assert.Empty(t, ConflictKeys(nil))
+}
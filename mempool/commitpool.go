@@ -0,0 +1,177 @@
+package mempool
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+
+	cfg "github.com/tendermint/tendermint/config"
+	"github.com/tendermint/tendermint/types"
+
+	txtypes "github.com/Fantom-foundation/go-txflow/types"
+)
+
+// CommitPoolTx is a fast-path committed tx waiting for a tick block to
+// record it in its Vtxs.
+type CommitPoolTx struct {
+	Seq    int64 // order in which the tx was committed
+	TxHash string
+	Tx     types.Tx
+	Commit *txtypes.Commit
+
+	size int64 // amino size of the tx and its commit
+}
+
+// CommitPool holds the txs committed through TxFlow until a tick block
+// includes them. Unlike the mempool it never runs CheckTx: its txs were
+// already executed.
+//
+// The pool is capped by the Size and MaxTxsBytes of the mempool config. Its
+// txs were executed and can't be dropped, so Add always admits them; the
+// cap applies backpressure instead: while the pool is full, a mempool
+// created WithCommitPool rejects new txs, so no more txs get committed until
+// a tick block drains the pool.
+type CommitPool struct {
+	config *cfg.MempoolConfig
+
+	mtx sync.Mutex
+
+	txs     *list.List                          // *CommitPoolTx in commit order
+	txsMap  map[string]*list.Element            // txHash -> element
+	keysMap map[[sha256.Size]byte]*list.Element // txKey -> element
+	nextSeq int64
+	bytes   int64
+}
+
+// NewCommitPool returns a new, empty CommitPool, capped by the Size and
+// MaxTxsBytes of config.
+func NewCommitPool(config *cfg.MempoolConfig) *CommitPool {
+	return &CommitPool{
+		config:  config,
+		txs:     list.New(),
+		txsMap:  make(map[string]*list.Element),
+		keysMap: make(map[[sha256.Size]byte]*list.Element),
+		nextSeq: 1,
+	}
+}
+
+// Add appends the committed tx, even if the pool is full. It returns false
+// if the tx is already in the pool.
+func (cp *CommitPool) Add(txHash string, tx types.Tx, commit *txtypes.Commit) bool {
+	cp.mtx.Lock()
+	defer cp.mtx.Unlock()
+
+	if _, ok := cp.txsMap[txHash]; ok {
+		return false
+	}
+	ctx := &CommitPoolTx{
+		Seq:    cp.nextSeq,
+		TxHash: txHash,
+		Tx:     tx,
+		Commit: commit,
+		size:   int64(len(cdc.MustMarshalBinaryLengthPrefixed(tx)) + len(cdc.MustMarshalBinaryLengthPrefixed(commit))),
+	}
+	cp.nextSeq++
+	e := cp.txs.PushBack(ctx)
+	cp.txsMap[txHash] = e
+	cp.keysMap[txtypes.TxKey(tx)] = e
+	cp.bytes += ctx.size
+	return true
+}
+
+// Has returns true if the tx is in the pool.
+func (cp *CommitPool) Has(txHash string) bool {
+	cp.mtx.Lock()
+	defer cp.mtx.Unlock()
+	_, ok := cp.txsMap[txHash]
+	return ok
+}
+
+// Size returns the number of txs in the pool.
+func (cp *CommitPool) Size() int {
+	cp.mtx.Lock()
+	defer cp.mtx.Unlock()
+	return cp.txs.Len()
+}
+
+// TxsBytes returns the total size of the txs and their commits.
+func (cp *CommitPool) TxsBytes() int64 {
+	cp.mtx.Lock()
+	defer cp.mtx.Unlock()
+	return cp.bytes
+}
+
+// IsFull returns ErrCommitPoolIsFull if the pool holds config.Size txs or
+// config.MaxTxsBytes bytes, nil otherwise.
+func (cp *CommitPool) IsFull() error {
+	cp.mtx.Lock()
+	defer cp.mtx.Unlock()
+	if cp.txs.Len() >= cp.config.Size || cp.bytes >= cp.config.MaxTxsBytes {
+		return ErrCommitPoolIsFull{
+			cp.txs.Len(), cp.config.Size,
+			cp.bytes, cp.config.MaxTxsBytes}
+	}
+	return nil
+}
+
+// ReapMaxBytes returns txs and their commits in commit order, as long as
+// their total size does not exceed maxBytes. A negative maxBytes reaps all
+// txs. The txs stay in the pool until Update.
+func (cp *CommitPool) ReapMaxBytes(maxBytes int64) (types.Txs, []*txtypes.Commit) {
+	cp.mtx.Lock()
+	defer cp.mtx.Unlock()
+
+	var (
+		totalBytes int64
+		txs        = make(types.Txs, 0, cp.txs.Len())
+		commits    = make([]*txtypes.Commit, 0, cp.txs.Len())
+	)
+	for e := cp.txs.Front(); e != nil; e = e.Next() {
+		ctx := e.Value.(*CommitPoolTx)
+		if maxBytes > -1 && totalBytes+ctx.size > maxBytes {
+			break
+		}
+		totalBytes += ctx.size
+		txs = append(txs, ctx.Tx)
+		commits = append(commits, ctx.Commit)
+	}
+	return txs, commits
+}
+
+// Get returns the pool entry of the tx, or nil if it's not in the pool.
+func (cp *CommitPool) Get(txHash string) *CommitPoolTx {
+	cp.mtx.Lock()
+	defer cp.mtx.Unlock()
+	if e, ok := cp.txsMap[txHash]; ok {
+		return e.Value.(*CommitPoolTx)
+	}
+	return nil
+}
+
+// GetTx returns the body of the tx with the given key, or nil if it's not in
+// the pool. It implements TxSource, so peers missing the body of a committed
+// tx can fetch it until a tick block carries it.
+func (cp *CommitPool) GetTx(txKey [sha256.Size]byte) types.Tx {
+	cp.mtx.Lock()
+	defer cp.mtx.Unlock()
+	if e, ok := cp.keysMap[txKey]; ok {
+		return e.Value.(*CommitPoolTx).Tx
+	}
+	return nil
+}
+
+// Update drops the txs a finalized tick block recorded in its Vtxs.
+func (cp *CommitPool) Update(vtxs types.Txs) {
+	cp.mtx.Lock()
+	defer cp.mtx.Unlock()
+
+	for _, vtx := range vtxs {
+		txHash := txtypes.TxHash(vtx)
+		if e, ok := cp.txsMap[txHash]; ok {
+			cp.bytes -= e.Value.(*CommitPoolTx).size
+			cp.txs.Remove(e)
+			delete(cp.txsMap, txHash)
+			delete(cp.keysMap, txtypes.TxKey(vtx))
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package mempool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/abci/example/kvstore"
+	cfg "github.com/tendermint/tendermint/config"
+	"github.com/tendermint/tendermint/proxy"
+	"github.com/tendermint/tendermint/types"
+
+	txtypes "github.com/Fantom-foundation/go-txflow/types"
+)
+
+func addCommitPoolTxs(t *testing.T, cp *CommitPool, txs types.Txs) {
+	for _, tx := range txs {
+		txHash := txtypes.TxHash(tx)
+		require.True(t, cp.Add(txHash, tx, txtypes.NewCommit(txHash, nil)))
+	}
+}
+
+func TestCommitPoolReapMaxBytes(t *testing.T) {
+	cp := NewCommitPool(cfg.TestMempoolConfig())
+	txs := types.Txs{types.Tx("a"), types.Tx("b"), types.Tx("c")}
+	addCommitPoolTxs(t, cp, txs)
+
+	// Duplicates are ignored
+	assert.False(t, cp.Add(txtypes.TxHash(txs[0]), txs[0], nil))
+	assert.Equal(t, 3, cp.Size())
+
+	// Txs come out in commit order
+	reaped, commits := cp.ReapMaxBytes(-1)
+	assert.Equal(t, txs, reaped)
+	require.Len(t, commits, 3)
+	for i, commit := range commits {
+		assert.Equal(t, txtypes.TxHash(txs[i]), commit.TxHash)
+	}
+	assert.Equal(t, int64(1), cp.Get(txtypes.TxHash(txs[0])).Seq)
+	assert.Equal(t, int64(3), cp.Get(txtypes.TxHash(txs[2])).Seq)
+
+	// The budget covers the txs and their commits
+	size := cp.TxsBytes() / 3
+	reaped, _ = cp.ReapMaxBytes(2*size + size/2)
+	assert.Equal(t, txs[:2], reaped)
+	reaped, _ = cp.ReapMaxBytes(0)
+	assert.Empty(t, reaped)
+
+	// Reaping does not remove
+	assert.Equal(t, 3, cp.Size())
+}
+
+func TestCommitPoolUpdate(t *testing.T) {
+	cp := NewCommitPool(cfg.TestMempoolConfig())
+	txs := types.Txs{types.Tx("a"), types.Tx("b"), types.Tx("c")}
+	addCommitPoolTxs(t, cp, txs)
+
+	cp.Update(types.Txs{txs[1], types.Tx("unknown")})
+	assert.Equal(t, 2, cp.Size())
+	assert.False(t, cp.Has(txtypes.TxHash(txs[1])))
+
+	reaped, _ := cp.ReapMaxBytes(-1)
+	assert.Equal(t, types.Txs{txs[0], txs[2]}, reaped)
+
+	cp.Update(txs)
+	assert.Equal(t, 0, cp.Size())
+	assert.Equal(t, int64(0), cp.TxsBytes())
+}
+
+func TestCommitPoolGetTx(t *testing.T) {
+	cp := NewCommitPool(cfg.TestMempoolConfig())
+	txs := types.Txs{types.Tx("a"), types.Tx("b")}
+	addCommitPoolTxs(t, cp, txs)
+
+	assert.Equal(t, txs[0], cp.GetTx(txtypes.TxKey(txs[0])))
+	assert.Nil(t, cp.GetTx(txtypes.TxKey(types.Tx("unknown"))))
+
+	// Bodies are served until a tick block carries them
+	cp.Update(txs[:1])
+	assert.Nil(t, cp.GetTx(txtypes.TxKey(txs[0])))
+	assert.Equal(t, txs[1], cp.GetTx(txtypes.TxKey(txs[1])))
+}
+
+func TestCommitPoolIsFull(t *testing.T) {
+	config := cfg.TestMempoolConfig()
+	config.Size = 2
+	cp := NewCommitPool(config)
+	txs := types.Txs{types.Tx("a"), types.Tx("b"), types.Tx("c")}
+
+	addCommitPoolTxs(t, cp, txs[:1])
+	assert.NoError(t, cp.IsFull())
+
+	// Committed txs are admitted past the cap
+	addCommitPoolTxs(t, cp, txs[1:])
+	assert.Equal(t, 3, cp.Size())
+	assert.IsType(t, ErrCommitPoolIsFull{}, cp.IsFull())
+
+	// The mempool holds off new txs meanwhile
+	app := kvstore.NewKVStoreApplication()
+	mempool, cleanup := newMempoolWithApp(proxy.NewLocalClientCreator(app))
+	defer cleanup()
+	WithCommitPool(cp)(mempool)
+	err := mempool.CheckTx(types.Tx("d"), nil)
+	assert.IsType(t, ErrCommitPoolIsFull{}, err)
+	assert.Equal(t, 0, mempool.Size())
+
+	// Until a tick block drains the pool
+	cp.Update(txs[:2])
+	assert.NoError(t, cp.IsFull())
+	assert.NoError(t, mempool.CheckTx(types.Tx("d"), nil))
+	assert.Equal(t, 1, mempool.Size())
+
+	// The byte cap applies as well
+	config.MaxTxsBytes = cp.TxsBytes()
+	assert.IsType(t, ErrCommitPoolIsFull{}, cp.IsFull())
+}
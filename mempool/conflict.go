@@ -0,0 +1,29 @@
+package mempool
+
+import (
+	abci "github.com/tendermint/tendermint/abci/types"
+)
+
+const (
+	// ConflictEventType is the type of the CheckTx event an application uses
+	// to declare the conflict keys of a tx, e.g. an account and nonce or an
+	// object ID. Two txs sharing a conflict key must not both be fast-path
+	// committed; validators sign only the first one they see.
+	ConflictEventType = "conflict"
+)
+
+// ConflictKeys returns the conflict keys declared in the CheckTx events.
+// Every attribute of a ConflictEventType event is one key, made of the
+// attribute key and value joined by a slash.
+func ConflictKeys(events []abci.Event) []string {
+	var keys []string
+	for _, ev := range events {
+		if ev.Type != ConflictEventType {
+			continue
+		}
+		for _, attr := range ev.Attributes {
+			keys = append(keys, string(attr.Key)+"/"+string(attr.Value))
+		}
+	}
+	return keys
+}
@@ -30,6 +30,23 @@ func (e ErrMempoolIsFull) Error() string {
 		e.txsBytes, e.maxTxsBytes)
 }
 
+// ErrCommitPoolIsFull means too many committed txs wait for a tick block,
+// new txs are refused until one drains the CommitPool
+type ErrCommitPoolIsFull struct {
+	numTxs int
+	maxTxs int
+
+	txsBytes    int64
+	maxTxsBytes int64
+}
+
+func (e ErrCommitPoolIsFull) Error() string {
+	return fmt.Sprintf(
+		"commit pool is full: number of txs %d (max: %d), total txs bytes %d (max: %d)",
+		e.numTxs, e.maxTxs,
+		e.txsBytes, e.maxTxsBytes)
+}
+
 // ErrPreCheck is returned when tx is too big
 type ErrPreCheck struct {
 	Reason error
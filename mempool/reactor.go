@@ -1,6 +1,7 @@
 package mempool
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"reflect"
 	"sync"
@@ -24,6 +25,18 @@ type Reactor struct {
 	config  *cfg.MempoolConfig
 	mempool *CListMempool
 	ids     *mempoolIDs
+
+	// other pools peers may fetch tx bodies from, e.g. the commit pool
+	txSources []TxSource
+	// tx bodies we asked peers for: txKey -> struct{}
+	requested sync.Map
+	// called with every requested tx body we receive
+	onTxBody func(types.Tx)
+}
+
+// TxSource looks up a tx body by its key.
+type TxSource interface {
+	GetTx(txKey [sha256.Size]byte) types.Tx
 }
 
 type mempoolIDs struct {
@@ -106,6 +119,44 @@ func (memR *Reactor) SetLogger(l log.Logger) {
 	memR.mempool.SetLogger(l)
 }
 
+// AddTxSource lets peers fetch tx bodies from src as well as from the mempool.
+func (memR *Reactor) AddTxSource(src TxSource) {
+	memR.txSources = append(memR.txSources, src)
+}
+
+// SetTxBodyHandler sets the function called with the tx bodies received in
+// answer to RequestTx. The body is verified to hash to the requested key.
+func (memR *Reactor) SetTxBodyHandler(onTxBody func(types.Tx)) {
+	memR.onTxBody = onTxBody
+}
+
+// RequestTx asks all peers for the body of the tx with the given key.
+// Votes only carry validator addresses, so we can't tell which peers voted;
+// any peer holding the body answers.
+func (memR *Reactor) RequestTx(txKey [sha256.Size]byte) {
+	memR.requested.Store(txKey, struct{}{})
+	memR.Switch.Broadcast(MempoolChannel, cdc.MustMarshalBinaryBare(&TxRequestMessage{TxKey: txKey}))
+}
+
+// CancelTxRequest forgets the request for the body of the tx with the given
+// key, which arrived another way. A late answer to it is then ignored.
+func (memR *Reactor) CancelTxRequest(txKey [sha256.Size]byte) {
+	memR.requested.Delete(txKey)
+}
+
+// getTx looks up a tx body in the mempool and the other tx sources.
+func (memR *Reactor) getTx(txKey [sha256.Size]byte) types.Tx {
+	if tx := memR.mempool.GetTx(txKey); tx != nil {
+		return tx
+	}
+	for _, src := range memR.txSources {
+		if tx := src.GetTx(txKey); tx != nil {
+			return tx
+		}
+	}
+	return nil
+}
+
 // OnStart implements p2p.BaseReactor.
 func (memR *Reactor) OnStart() error {
 	if !memR.config.Broadcast {
@@ -157,6 +208,22 @@ func (memR *Reactor) Receive(chID byte, src p2p.Peer, msgBytes []byte) {
 			memR.Logger.Info("Could not check tx", "tx", txID(msg.Tx), "err", err)
 		}
 		// broadcasting happens from go routines per peer
+	case *TxRequestMessage:
+		if tx := memR.getTx(msg.TxKey); tx != nil {
+			src.TrySend(MempoolChannel, cdc.MustMarshalBinaryBare(&TxResponseMessage{Tx: tx}))
+		}
+	case *TxResponseMessage:
+		// Keyed by the hash of the body we got, so only a body matching the
+		// requested key is accepted
+		key := txKey(msg.Tx)
+		if _, ok := memR.requested.Load(key); !ok {
+			memR.Logger.Debug("Ignoring unrequested tx", "tx", txID(msg.Tx), "src", src)
+			return
+		}
+		memR.requested.Delete(key)
+		if memR.onTxBody != nil {
+			memR.onTxBody(msg.Tx)
+		}
 	default:
 		memR.Logger.Error(fmt.Sprintf("Unknown message type %v", reflect.TypeOf(msg)))
 	}
@@ -246,6 +313,8 @@ type MempoolMessage interface{}
 func RegisterMempoolMessages(cdc *amino.Codec) {
 	cdc.RegisterInterface((*MempoolMessage)(nil), nil)
 	cdc.RegisterConcrete(&TxMessage{}, "tendermint/mempool/TxMessage", nil)
+	cdc.RegisterConcrete(&TxRequestMessage{}, "txflow/mempool/TxRequestMessage", nil)
+	cdc.RegisterConcrete(&TxResponseMessage{}, "txflow/mempool/TxResponseMessage", nil)
 }
 
 func decodeMsg(bz []byte) (msg MempoolMessage, err error) {
@@ -267,3 +336,27 @@ type TxMessage struct {
 func (m *TxMessage) String() string {
 	return fmt.Sprintf("[TxMessage %v]", m.Tx)
 }
+
+//-------------------------------------
+
+// TxRequestMessage is a MempoolMessage asking for the body of a tx.
+type TxRequestMessage struct {
+	TxKey [sha256.Size]byte
+}
+
+// String returns a string representation of the TxRequestMessage.
+func (m *TxRequestMessage) String() string {
+	return fmt.Sprintf("[TxRequestMessage %X]", m.TxKey)
+}
+
+//-------------------------------------
+
+// TxResponseMessage is a MempoolMessage answering a TxRequestMessage.
+type TxResponseMessage struct {
+	Tx types.Tx
+}
+
+// String returns a string representation of the TxResponseMessage.
+func (m *TxResponseMessage) String() string {
+	return fmt.Sprintf("[TxResponseMessage %v]", m.Tx)
+}
@@ -149,6 +149,32 @@ func TestReactorNoBroadcastToSender(t *testing.T) {
 	ensureNoTxs(t, reactors[1], 100*time.Millisecond)
 }
 
+func TestReactorRequestTx(t *testing.T) {
+	config := cfg.TestConfig()
+	const N = 2
+	reactors := makeAndConnectReactors(config, N)
+	defer func() {
+		for _, r := range reactors {
+			r.Stop()
+		}
+	}()
+
+	received := make(chan types.Tx, 1)
+	reactors[1].SetTxBodyHandler(func(tx types.Tx) { received <- tx })
+
+	// the tx claims to come from the other peer, so it is not gossiped to it
+	txs := checkTxs(t, reactors[0].mempool, 1, 1)
+	ensureNoTxs(t, reactors[1], 100*time.Millisecond)
+
+	reactors[1].RequestTx(txKey(txs[0]))
+	select {
+	case tx := <-received:
+		assert.Equal(t, txs[0], tx)
+	case <-time.After(TIMEOUT):
+		t.Fatal("Timed out waiting for the requested tx")
+	}
+}
+
 func TestBroadcastTxForPeerStopsWhenPeerStops(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping test in short mode.")
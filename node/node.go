@@ -17,8 +17,11 @@ import (
 	"github.com/rs/cors"
 
 	cs "github.com/Fantom-foundation/go-txflow/consensus"
+	txdb "github.com/Fantom-foundation/go-txflow/db"
+	"github.com/Fantom-foundation/go-txflow/evidence"
 	mempl "github.com/Fantom-foundation/go-txflow/mempool"
 	"github.com/Fantom-foundation/go-txflow/privval"
+	txflowcore "github.com/Fantom-foundation/go-txflow/rpc/core"
 	sm "github.com/Fantom-foundation/go-txflow/state"
 	"github.com/Fantom-foundation/go-txflow/tx"
 	"github.com/Fantom-foundation/go-txflow/txflow"
@@ -31,8 +34,8 @@ import (
 	bc "github.com/tendermint/tendermint/blockchain"
 	cfg "github.com/tendermint/tendermint/config"
 	"github.com/tendermint/tendermint/crypto/ed25519"
-	"github.com/tendermint/tendermint/evidence"
 	cmn "github.com/tendermint/tendermint/libs/common"
+	dbm "github.com/tendermint/tendermint/libs/db"
 	"github.com/tendermint/tendermint/libs/log"
 	tmpubsub "github.com/tendermint/tendermint/libs/pubsub"
 	tmempl "github.com/tendermint/tendermint/mempool"
@@ -51,16 +54,42 @@ import (
 	ttypes "github.com/tendermint/tendermint/types"
 	tmtime "github.com/tendermint/tendermint/types/time"
 	"github.com/tendermint/tendermint/version"
-	dbm "github.com/tendermint/tm-cmn/db"
 )
 
 // CustomReactorNamePrefix is a prefix for all custom reactors to prevent
 // clashes with built-in reactors.
 const CustomReactorNamePrefix = "CUSTOM_"
 
-// Option sets a parameter for the node.
+// Option sets a parameter for the node. Options only record their setting,
+// NewNode reads it while it builds the node: some, like the TxSequencer, are
+// needed before the handshake.
 type Option func(*Node)
 
+// PruneTxStore drops the txs out of the retention window of config from
+// the TxStore while the node runs.
+func PruneTxStore(config tx.PruneConfig) Option {
+	return func(n *Node) {
+		n.txPruneConfig = &config
+	}
+}
+
+// CommutativeTxs lets the application declare which txs commute, see
+// txflow.CommutativeFunc. All validators must declare the same txs.
+func CommutativeTxs(isCommutative txflow.CommutativeFunc) Option {
+	return func(n *Node) {
+		n.isCommutative = isCommutative
+	}
+}
+
+// TxBatchSize sets the number of fast-path txs committed to the app at once,
+// see txflow.TxSequencerWithMaxBatchSize. All validators must use the same
+// size.
+func TxBatchSize(maxBatchSize int) Option {
+	return func(n *Node) {
+		n.txBatchSize = maxBatchSize
+	}
+}
+
 // NodeProvider takes a config and a logger and returns a ready to go Node.
 type NodeProvider func(*cfg.Config, log.Logger) (*Node, error)
 
@@ -96,12 +125,21 @@ func DefaultNewNode(config *cfg.Config, logger log.Logger) (*Node, error) {
 		nodeKey,
 		proxy.DefaultClientCreator(config.ProxyApp, config.ABCI, config.DBDir()),
 		node.DefaultGenesisDocProviderFunc(config),
-		node.DefaultDBProvider,
+		DefaultDBProvider,
 		node.DefaultMetricsProvider(config.Instrumentation),
 		logger,
 	)
 }
 
+// DefaultDBProvider returns a BadgerDB when db_backend is "badgerdb", and
+// one of the stock backends otherwise.
+func DefaultDBProvider(ctx *node.DBContext) (dbm.DB, error) {
+	if dbm.DBBackendType(ctx.Config.DBBackend) == txdb.BadgerDBBackend {
+		return txdb.NewBadgerDB(ctx.ID, ctx.Config.DBDir())
+	}
+	return node.DefaultDBProvider(ctx)
+}
+
 //------------------------------------------------------------------------------
 
 // Node is the highest level interface to a full Tendermint node.
@@ -129,14 +167,20 @@ type Node struct {
 	bcReactor      *bc.BlockchainReactor // for fast-syncing
 	mempoolReactor *mempl.Reactor        // for gossipping transactions
 	mempool        tmempl.Mempool
-	commitpool     tmempl.Mempool
+	commitpool     *mempl.CommitPool
 
 	//TxVotePool system for aBFT voting on mempool Tx's
 	txvotepoolReactor *txvotepool.Reactor
 	txvotepool        *txvotepool.TxVotePool
 	txflow            *txflow.TxFlow
 
-	txStore *tx.TxStore
+	txStore       *tx.TxStore
+	txPruner      *tx.Pruner // nil unless pruning is on
+	txPruneConfig *tx.PruneConfig
+
+	// order and batches of the fast-path txs
+	isCommutative txflow.CommutativeFunc
+	txBatchSize   int
 
 	consensusState   *cs.ConsensusState     // latest consensus state
 	consensusReactor *cs.ConsensusReactor   // for participating in the consensus
@@ -157,17 +201,17 @@ func initDBs(config *cfg.Config, dbProvider node.DBProvider) (blockStore *bc.Blo
 	}
 	blockStore = bc.NewBlockStore(blockStoreDB)
 
-	var txStoreDB dbm.DB
-	txStoreDB, err = dbProvider(&node.DBContext{"txstore", config})
+	stateDB, err = dbProvider(&node.DBContext{"state", config})
 	if err != nil {
 		return
 	}
-	txStore = tx.NewTxStore(txStoreDB)
 
-	stateDB, err = dbProvider(&node.DBContext{"state", config})
+	var txStoreDB dbm.DB
+	txStoreDB, err = dbProvider(&node.DBContext{"txstore", config})
 	if err != nil {
 		return
 	}
+	txStore = tx.NewTxStore(txStoreDB, tx.TxStoreWithValidatorsLoader(txflow.NewValidatorsLoader(stateDB)))
 
 	return
 }
@@ -220,11 +264,14 @@ func createAndStartIndexerService(config *cfg.Config, dbProvider node.DBProvider
 }
 
 func doHandshake(stateDB dbm.DB, state sm.State, blockStore sm.BlockStore,
+	txJournal sm.TxJournal, txOrderer sm.TxOrderer,
 	genDoc *ttypes.GenesisDoc, eventBus *ttypes.EventBus, proxyApp proxy.AppConns, consensusLogger log.Logger) error {
 
 	handshaker := cs.NewHandshaker(stateDB, state, blockStore, genDoc)
 	handshaker.SetLogger(consensusLogger)
 	handshaker.SetEventBus(eventBus)
+	handshaker.SetTxJournal(txJournal)
+	handshaker.SetTxOrderer(txOrderer)
 	if err := handshaker.Handshake(proxyApp); err != nil {
 		return fmt.Errorf("error during handshake: %v", err)
 	}
@@ -268,7 +315,7 @@ func onlyValidatorIsUs(state sm.State, privVal types.PrivValidator) bool {
 }
 
 func createMempoolAndMempoolReactor(config *cfg.Config, proxyApp proxy.AppConns,
-	state sm.State, memplMetrics *tmempl.Metrics, logger log.Logger) (*mempl.Reactor, *mempl.CListMempool) {
+	state sm.State, commitpool *mempl.CommitPool, memplMetrics *tmempl.Metrics, logger log.Logger) (*mempl.Reactor, *mempl.CListMempool) {
 
 	mempool := mempl.NewCListMempool(
 		config.Mempool,
@@ -277,6 +324,7 @@ func createMempoolAndMempoolReactor(config *cfg.Config, proxyApp proxy.AppConns,
 		mempl.WithMetrics(memplMetrics),
 		mempl.WithPreCheck(sm.TxPreCheck(state)),
 		mempl.WithPostCheck(sm.TxPostCheck(state)),
+		mempl.WithCommitPool(commitpool),
 	)
 	mempoolLogger := logger.With("module", "mempool")
 	mempoolReactor := mempl.NewReactor(config.Mempool, mempool)
@@ -288,13 +336,20 @@ func createMempoolAndMempoolReactor(config *cfg.Config, proxyApp proxy.AppConns,
 	return mempoolReactor, mempool
 }
 
-func createTxVotePoolAndTxVotePoolReactor(config *cfg.Config,
-	state *sm.State, privVal types.PrivValidator, mempool *mempl.CListMempool, memplMetrics *tmempl.Metrics, logger log.Logger) (*txvotepool.Reactor, *txvotepool.TxVotePool) {
+func createTxVotePoolAndTxVotePoolReactor(config *cfg.Config, dbProvider node.DBProvider,
+	state *sm.State, privVal types.PrivValidator, mempool *mempl.CListMempool, memplMetrics *tmempl.Metrics, logger log.Logger) (*txvotepool.Reactor, *txvotepool.TxVotePool, error) {
 
+	// The conflict keys of signed txs are held until the txs are final,
+	// across restarts
+	conflictDB, err := dbProvider(&node.DBContext{"txconflicts", config})
+	if err != nil {
+		return nil, nil, err
+	}
 	txVPool := txvotepool.NewTxVotePool(
 		config.Mempool,
 		state.LastBlockHeight,
 		txvotepool.WithMetrics(memplMetrics),
+		txvotepool.WithConflictDB(conflictDB),
 	)
 	txVotePoolLogger := logger.With("module", "txvotepool")
 	txVotePoolReactor := txvotepool.NewReactor(
@@ -309,7 +364,7 @@ func createTxVotePoolAndTxVotePoolReactor(config *cfg.Config,
 	if config.Consensus.WaitForTxs() {
 		txVPool.EnableTxsAvailable()
 	}
-	return txVotePoolReactor, txVPool
+	return txVotePoolReactor, txVPool, nil
 }
 
 func createEvidenceReactor(config *cfg.Config, dbProvider node.DBProvider,
@@ -505,11 +560,26 @@ func NewNode(config *cfg.Config,
 	logger log.Logger,
 	options ...Option) (*Node, error) {
 
+	// Collect the settings of the options
+	opts := &Node{txBatchSize: txflowstate.DefaultMaxBatchSize}
+	for _, option := range options {
+		option(opts)
+	}
+
 	blockStore, txStore, stateDB, err := initDBs(config, dbProvider)
 	if err != nil {
 		return nil, err
 	}
 
+	var txPruner *tx.Pruner
+	if opts.txPruneConfig != nil {
+		txStore.SetTxTTL(opts.txPruneConfig.TxTTL)
+		if opts.txPruneConfig.Mode != tx.PruneNothing {
+			txPruner = tx.NewPruner(txStore, *opts.txPruneConfig)
+			txPruner.SetLogger(logger.With("module", "txstore"))
+		}
+	}
+
 	state, genDoc, err := LoadStateFromDBOrGenesisDocProvider(stateDB, genesisDocProvider)
 	if err != nil {
 		return nil, err
@@ -536,10 +606,17 @@ func NewNode(config *cfg.Config,
 		return nil, err
 	}
 
+	// Make the TxSequencer, ordering the fast-path txs of tick blocks for
+	// the TxFlow and the handshaker alike
+	txSequencer := txflow.NewTxSequencer(
+		txflow.TxSequencerWithCommutativeFunc(opts.isCommutative),
+		txflow.TxSequencerWithMaxBatchSize(opts.txBatchSize),
+	)
+
 	// Create the handshaker, which calls RequestInfo, sets the AppVersion on the state,
 	// and replays any blocks as necessary to sync tendermint with the app.
 	consensusLogger := logger.With("module", "consensus")
-	if err := doHandshake(stateDB, state, blockStore, genDoc, eventBus, proxyApp, consensusLogger); err != nil {
+	if err := doHandshake(stateDB, state, blockStore, txStore, txSequencer, genDoc, eventBus, proxyApp, consensusLogger); err != nil {
 		return nil, err
 	}
 
@@ -566,11 +643,18 @@ func NewNode(config *cfg.Config,
 
 	csMetrics, p2pMetrics, memplMetrics, smMetrics := metricsProvider(genDoc.ChainID)
 
+	// Make CommitPool, holding fast-path committed txs for the next tick block.
+	// The mempool holds off new txs while it's full.
+	commitpool := mempl.NewCommitPool(config.Mempool)
+
 	// Make MempoolReactor
-	mempoolReactor, mempool := createMempoolAndMempoolReactor(config, proxyApp, state, memplMetrics, logger)
+	mempoolReactor, mempool := createMempoolAndMempoolReactor(config, proxyApp, state, commitpool, memplMetrics, logger)
 
 	// Make TxVotePoolReactor
-	txvotepoolReactor, txvotepool := createTxVotePoolAndTxVotePoolReactor(config, &state, privValidator, mempool, memplMetrics, logger)
+	txvotepoolReactor, txvotepool, err := createTxVotePoolAndTxVotePoolReactor(config, dbProvider, &state, privValidator, mempool, memplMetrics, logger)
+	if err != nil {
+		return nil, err
+	}
 
 	// Make Evidence Reactor
 	evidenceReactor, evidencePool, err := createEvidenceReactor(config, dbProvider, stateDB, logger)
@@ -584,6 +668,7 @@ func NewNode(config *cfg.Config,
 		logger.With("module", "state"),
 		proxyApp.Consensus(),
 		mempool,
+		commitpool,
 		evidencePool,
 		sm.BlockExecutorWithMetrics(smMetrics),
 	)
@@ -598,14 +683,39 @@ func NewNode(config *cfg.Config,
 	txfLogger := logger.With("module", "txflow")
 	txf := txflow.NewTxFlow(
 		&state,
+		stateDB,
 		txvotepool,
 		mempool,
+		commitpool,
 		txExec,
 		txStore,
-		nil,
+		evidencePool,
+		txflow.WithTxSequencer(txSequencer),
 	)
 	txf.SetLogger(txfLogger)
 
+	migrated, err := txStore.MigrateCommits(state.ChainID)
+	if err != nil {
+		return nil, errors.Wrap(err, "error migrating txstore commits to certificates")
+	}
+	if migrated > 0 {
+		txfLogger.Info("Migrated txstore commits to certificates", "count", migrated)
+	}
+
+	// Tick blocks fix the execution order of fast-path txs
+	blockExec.SetTxSequencer(txf)
+	blockExec.SetTxStore(txStore)
+	blockExec.SetStalledTxs(txf)
+
+	// Fetch the bodies of committed txs we never received, and serve those
+	// of the committed txs waiting for a tick block
+	txf.SetTxFetcher(mempoolReactor)
+	mempoolReactor.SetTxBodyHandler(txf.ReceiveTx)
+	mempoolReactor.AddTxSource(commitpool)
+
+	// Sign tx votes for the latest tick block
+	txf.SetVoteSigner(txvotepoolReactor)
+
 	// Make BlockchainReactor
 	bcReactor := bc.NewBlockchainReactor(state.Copy(), blockExec, blockStore, fastSync)
 	bcReactor.SetLogger(logger.With("module", "blockchain"))
@@ -677,9 +787,12 @@ func NewNode(config *cfg.Config,
 
 		stateDB:           stateDB,
 		blockStore:        blockStore,
+		txStore:           txStore,
+		txPruner:          txPruner,
 		bcReactor:         bcReactor,
 		mempoolReactor:    mempoolReactor,
 		mempool:           mempool,
+		commitpool:        commitpool,
 		txvotepoolReactor: txvotepoolReactor,
 		txvotepool:        txvotepool,
 		txflow:            txf,
@@ -694,10 +807,6 @@ func NewNode(config *cfg.Config,
 	}
 	node.BaseService = *cmn.NewBaseService(logger, "Node", node)
 
-	for _, option := range options {
-		option(node)
-	}
-
 	return node, nil
 }
 
@@ -742,6 +851,14 @@ func (n *Node) OnStart() error {
 	if n.config.Mempool.WalEnabled() {
 		n.mempool.InitWAL() // no need to have the mempool wal during tests
 		n.txvotepool.InitWAL()
+
+		// Recover the votes collected before a restart, TxFlow picks them up
+		// from the pool once it starts
+		replayed, err := n.txvotepool.ReplayWAL(n.txStore.HasTx)
+		if err != nil {
+			n.Logger.Error("Error replaying txvotepool WAL", "err", err)
+		}
+		n.Logger.Info("Replayed txvotepool WAL", "votes", replayed)
 	}
 
 	// Start the switch (the P2P server).
@@ -759,6 +876,12 @@ func (n *Node) OnStart() error {
 	n.txvotepoolReactor.Start()
 	n.txflow.Start()
 
+	if n.txPruner != nil {
+		if err := n.txPruner.Start(); err != nil {
+			return errors.Wrap(err, "could not start txstore pruner")
+		}
+	}
+
 	return nil
 }
 
@@ -771,6 +894,9 @@ func (n *Node) OnStop() {
 	// first stop the non-reactor services
 	n.eventBus.Stop()
 	n.indexerService.Stop()
+	if n.txPruner != nil {
+		n.txPruner.Stop()
+	}
 
 	// now stop the reactors
 	n.sw.Stop()
@@ -830,6 +956,14 @@ func (n *Node) ConfigureRPC() {
 	rpccore.SetEventBus(n.eventBus)
 	rpccore.SetLogger(n.Logger.With("module", "rpc"))
 	rpccore.SetConfig(*n.config.RPC)
+
+	txflowcore.SetTxFlow(n.txflow)
+	txflowcore.SetTxStore(n.txStore)
+	txflowcore.SetTxVotePool(n.txvotepool)
+	txflowcore.SetMempool(n.mempool)
+	txflowcore.SetEventBus(n.eventBus)
+	txflowcore.SetLogger(n.Logger.With("module", "rpc"))
+	txflowcore.SetConfig(*n.config.RPC)
 }
 
 func (n *Node) startRPC() ([]net.Listener, error) {
@@ -837,10 +971,12 @@ func (n *Node) startRPC() ([]net.Listener, error) {
 	listenAddrs := splitAndTrimEmpty(n.config.RPC.ListenAddress, ",", " ")
 	coreCodec := amino.NewCodec()
 	ctypes.RegisterAmino(coreCodec)
+	types.RegisterEventDatas(coreCodec)
 
 	if n.config.RPC.Unsafe {
 		rpccore.AddUnsafeRoutes()
 	}
+	routes := txflowcore.AllRoutes()
 
 	// we may expose the rpc over both a unix and tcp socket
 	listeners := make([]net.Listener, len(listenAddrs))
@@ -848,7 +984,7 @@ func (n *Node) startRPC() ([]net.Listener, error) {
 		mux := http.NewServeMux()
 		rpcLogger := n.Logger.With("module", "rpc-server")
 		wmLogger := rpcLogger.With("protocol", "websocket")
-		wm := rpcserver.NewWebsocketManager(rpccore.Routes, coreCodec,
+		wm := rpcserver.NewWebsocketManager(routes, coreCodec,
 			rpcserver.OnDisconnect(func(remoteAddr string) {
 				err := n.eventBus.UnsubscribeAll(context.Background(), remoteAddr)
 				if err != nil && err != tmpubsub.ErrSubscriptionNotFound {
@@ -857,7 +993,7 @@ func (n *Node) startRPC() ([]net.Listener, error) {
 			}))
 		wm.SetLogger(wmLogger)
 		mux.HandleFunc("/websocket", wm.WebsocketHandler)
-		rpcserver.RegisterRPCFuncs(mux, rpccore.Routes, coreCodec, rpcLogger)
+		rpcserver.RegisterRPCFuncs(mux, routes, coreCodec, rpcLogger)
 
 		config := rpcserver.DefaultConfig()
 		config.MaxOpenConnections = n.config.RPC.MaxOpenConnections
@@ -978,6 +1114,11 @@ func (n *Node) Mempool() tmempl.Mempool {
 	return n.mempool
 }
 
+// CommitPool returns the Node's pool of fast-path committed txs.
+func (n *Node) CommitPool() *mempl.CommitPool {
+	return n.commitpool
+}
+
 // TxVotePool returns the Node's mempool.
 func (n *Node) TxVotePool() *txvotepool.TxVotePool {
 	return n.txvotepool
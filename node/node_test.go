@@ -366,7 +366,7 @@ func TestTxVotes(t *testing.T) {
 	assert.Equal(t, true, txVotePoolReactor.IsRunning())
 	assert.Equal(t, 16, mempool.Size())
 
-	txStore := tx.NewTxStore(stateDB)
+	txStore := tx.NewTxStore(stateDB, tx.TxStoreWithValidatorsLoader(txflow.NewValidatorsLoader(stateDB)))
 
 	txExec := txflowstate.NewTxExecutor(
 		logger.With("module", "state"),
@@ -375,11 +375,16 @@ func TestTxVotes(t *testing.T) {
 		txVotePool,
 	)
 
+	// Make CommitPool, holding fast-path committed txs for the next tick block
+	commitpool := mempl.NewCommitPool(config.Mempool)
+
 	txfLogger := logger.With("module", "txflow")
 	txf := txflow.NewTxFlow(
 		&state,
+		stateDB,
 		txVotePool,
 		mempool,
+		commitpool,
 		txExec,
 		txStore,
 		nil,
@@ -1,7 +1,13 @@
 package privval
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/Fantom-foundation/go-txflow/types"
 	"github.com/tendermint/tendermint/crypto"
@@ -13,13 +19,151 @@ import (
 
 //-------------------------------------------------------------------------------
 
+/*
+FilePVLastTxVotes records the tx votes signed at the last height a tx vote was
+signed at. Tx votes are signed concurrently and for many txs at once, so
+unlike FilePVLastSignState it keeps every vote of the height.
+
+The votes are kept in an append-only log, one amino JSON vote per line. Every
+vote is appended and synced before its signature is handed out. The first
+vote of a new height replaces the log, so it only ever holds the votes of one
+height.
+*/
+type FilePVLastTxVotes struct {
+	Height int64
+	Votes  map[string]*types.TxVote // by tx hash
+
+	filePath string
+	file     *os.File // open for appending, nil until the first vote
+}
+
+// CheckTxVote returns the vote signed earlier for the same tx at the same
+// height, or nil if there is none. It returns an error if the vote is for
+// an earlier height, or if it conflicts with the earlier vote.
+func (lts *FilePVLastTxVotes) CheckTxVote(vote *types.TxVote) (*types.TxVote, error) {
+	if lts.Height > vote.Height {
+		return nil, fmt.Errorf("height regression. Got %v, last height %v", vote.Height, lts.Height)
+	}
+	if lts.Height < vote.Height {
+		return nil, nil
+	}
+	last, ok := lts.Votes[vote.TxHash]
+	if !ok {
+		return nil, nil
+	}
+	if last.ConflictsWith(vote) {
+		return nil, fmt.Errorf("conflicting vote for tx %v, already signed %v", vote.TxHash, last)
+	}
+	return last, nil
+}
+
+// saveTxVote records a signed vote. A vote for a later height starts the log
+// over, forgetting the votes of the earlier height.
+func (lts *FilePVLastTxVotes) saveTxVote(vote *types.TxVote) error {
+	line, err := cdc.MarshalJSON(vote)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if lts.Height < vote.Height {
+		if err := lts.reset(line); err != nil {
+			return err
+		}
+		lts.Height = vote.Height
+		lts.Votes = make(map[string]*types.TxVote)
+	} else {
+		if err := lts.append(line); err != nil {
+			return err
+		}
+	}
+	lts.Votes[vote.TxHash] = vote.Copy()
+	return nil
+}
+
+// append writes line to the end of the log and syncs it.
+func (lts *FilePVLastTxVotes) append(line []byte) error {
+	if lts.file == nil {
+		file, err := os.OpenFile(lts.filePath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return err
+		}
+		lts.file = file
+	}
+	if _, err := lts.file.Write(line); err != nil {
+		return err
+	}
+	return lts.file.Sync()
+}
+
+// reset atomically replaces the log with the given content and opens it for
+// appending.
+func (lts *FilePVLastTxVotes) reset(content []byte) error {
+	if lts.file != nil {
+		lts.file.Close()
+		lts.file = nil
+	}
+	if err := cmn.WriteFileAtomic(lts.filePath, content, 0600); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(lts.filePath, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	lts.file = file
+	return nil
+}
+
+// txVotesFilePath returns the path of the FilePVLastTxVotes, next to the
+// last sign state.
+func txVotesFilePath(stateFilePath string) string {
+	ext := filepath.Ext(stateFilePath)
+	return strings.TrimSuffix(stateFilePath, ext) + "_txvotes" + ext
+}
+
+// loadLastTxVotes loads the FilePVLastTxVotes from filePath, or returns an
+// empty one if the file doesn't exist yet. A vote cut short by a crash was
+// never handed out, it is skipped.
+func loadLastTxVotes(filePath string) *FilePVLastTxVotes {
+	lts := &FilePVLastTxVotes{
+		Votes:    make(map[string]*types.TxVote),
+		filePath: filePath,
+	}
+	if !cmn.FileExists(filePath) {
+		return lts
+	}
+	bz, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		cmn.Exit(err.Error())
+	}
+	lines := bytes.Split(bz, []byte{'\n'})
+	// the last line is either empty or incomplete
+	for _, line := range lines[:len(lines)-1] {
+		vote := new(types.TxVote)
+		if err := cdc.UnmarshalJSON(line, vote); err != nil {
+			cmn.Exit(fmt.Sprintf("Error reading PrivValidator tx votes from %v: %v\n", filePath, err))
+		}
+		if vote.Height > lts.Height {
+			lts.Height = vote.Height
+			lts.Votes = make(map[string]*types.TxVote)
+		}
+		lts.Votes[vote.TxHash] = vote
+	}
+	return lts
+}
+
 // FilePV implements PrivValidator using data persisted to disk
 // to prevent double signing.
 // NOTE: the directories containing pv.Key.filePath and pv.LastSignState.filePath must already exist.
 // It includes the LastSignature and LastSignBytes so we don't lose the signature
 // if the process crashes after signing but before the resulting consensus message is processed.
+// The tx votes signed at the last height are kept in LastTxVotes, next to the
+// last sign state.
 type FilePV struct {
 	tpv *privval.FilePV
+
+	mtx         sync.Mutex // tx votes are signed concurrently
+	LastTxVotes *FilePVLastTxVotes
 }
 
 // LoadOrGenFilePV loads a FilePV from the given filePaths
@@ -32,7 +176,10 @@ func LoadOrGenFilePV(keyFilePath, stateFilePath string) *FilePV {
 		pv = privval.GenFilePV(keyFilePath, stateFilePath)
 		pv.Save()
 	}
-	return &FilePV{tpv: pv}
+	return &FilePV{
+		tpv:         pv,
+		LastTxVotes: loadLastTxVotes(txVotesFilePath(stateFilePath)),
+	}
 }
 
 // GetAddress returns the address of the validator.
@@ -77,6 +224,14 @@ func (pv *FilePV) Save() {
 // NOTE: Unsafe!
 func (pv *FilePV) Reset() {
 	pv.tpv.Reset()
+
+	pv.mtx.Lock()
+	defer pv.mtx.Unlock()
+	if err := pv.LastTxVotes.reset(nil); err != nil {
+		panic(err)
+	}
+	pv.LastTxVotes.Height = 0
+	pv.LastTxVotes.Votes = make(map[string]*types.TxVote)
 }
 
 // String returns a string representation of the FilePV.
@@ -86,17 +241,36 @@ func (pv *FilePV) String() string {
 
 //------------------------------------------------------------------------------------
 
-// signVote checks if the vote is good to sign and sets the vote signature.
+// signTxVote checks if the vote is good to sign and sets the vote signature.
 // It may need to set the timestamp as well if the vote is otherwise the same as
-// a previously signed vote (ie. we crashed after signing but before the vote hit the WAL).
+// a previously signed vote (ie. we crashed after signing but before the vote was gossiped).
 func (pv *FilePV) signTxVote(chainID string, vote *types.TxVote) error {
+	pv.mtx.Lock()
+	defer pv.mtx.Unlock()
+
+	last, err := pv.LastTxVotes.CheckTxVote(vote)
+	if err != nil {
+		return err
+	}
+
+	// We might crash before the vote is gossiped,
+	// so re-use the timestamp and signature of the vote we signed before.
+	if last != nil {
+		vote.Timestamp = last.Timestamp
+		vote.Signature = last.Signature
+		return nil
+	}
 
-	signBytes := vote.SignBytes(chainID)
 	// It passed the checks. Sign the vote
-	sig, err := pv.tpv.Key.PrivKey.Sign(signBytes)
+	sig, err := pv.tpv.Key.PrivKey.Sign(vote.SignBytes(chainID))
 	if err != nil {
 		return err
 	}
 	vote.Signature = sig
+	// Don't hand out a signature we can't remember
+	if err := pv.LastTxVotes.saveTxVote(vote); err != nil {
+		vote.Signature = nil
+		return err
+	}
 	return nil
 }
@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	txtypes "github.com/Fantom-foundation/go-txflow/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tendermint/tendermint/crypto/ed25519"
@@ -300,6 +301,65 @@ func TestDifferByTimestamp(t *testing.T) {
 	}
 }
 
+func TestSignTxVote(t *testing.T) {
+	tempKeyFile, err := ioutil.TempFile("", "priv_validator_key_")
+	require.Nil(t, err)
+	tempStateFile, err := ioutil.TempFile("", "priv_validator_state_")
+	require.Nil(t, err)
+	os.Remove(tempKeyFile.Name())
+	defer os.Remove(txVotesFilePath(tempStateFile.Name()))
+
+	privVal := LoadOrGenFilePV(tempKeyFile.Name(), tempStateFile.Name())
+	addr := privVal.GetAddress()
+	height := int64(10)
+	chainID := "mychainid"
+
+	// sign a vote for first time
+	tx := types.Tx("tx")
+	vote := txtypes.NewTxVote(height, txtypes.TxHash(tx), txtypes.TxKey(tx), addr)
+	require.NoError(t, privVal.SignTxVote(chainID, &vote))
+	sig, timeStamp := vote.Signature, vote.Timestamp
+
+	// re-signing with a different timestamp gives back the same vote,
+	// also after a restart
+	privVal = LoadOrGenFilePV(tempKeyFile.Name(), tempStateFile.Name())
+	vote.Timestamp = vote.Timestamp.Add(time.Millisecond)
+	vote.Signature = nil
+	require.NoError(t, privVal.SignTxVote(chainID, &vote))
+	assert.True(t, timeStamp.Equal(vote.Timestamp))
+	assert.Equal(t, sig, vote.Signature)
+
+	// rejecting the tx at the same height is double signing
+	reject := txtypes.NewTxVote(height, txtypes.TxHash(tx), txtypes.TxKey(tx), addr)
+	reject.Code, reject.Codespace = 1, "app"
+	assert.Error(t, privVal.SignTxVote(chainID, &reject))
+
+	// so is a vote for an earlier height
+	other := types.Tx("other")
+	old := txtypes.NewTxVote(height-1, txtypes.TxHash(other), txtypes.TxKey(other), addr)
+	assert.Error(t, privVal.SignTxVote(chainID, &old))
+
+	// a later height starts the log afresh
+	reject.Height = height + 1
+	assert.NoError(t, privVal.SignTxVote(chainID, &reject))
+	assert.Len(t, privVal.LastTxVotes.Votes, 1)
+	vote = txtypes.NewTxVote(height+1, txtypes.TxHash(other), txtypes.TxKey(other), addr)
+	require.NoError(t, privVal.SignTxVote(chainID, &vote))
+
+	// a vote cut short by a crash is skipped on load
+	txVotesFile := txVotesFilePath(tempStateFile.Name())
+	f, err := os.OpenFile(txVotesFile, os.O_WRONLY|os.O_APPEND, 0600)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"height":"11","tx_hash":`)
+	require.NoError(t, err)
+	f.Close()
+
+	privVal = LoadOrGenFilePV(tempKeyFile.Name(), tempStateFile.Name())
+	assert.Equal(t, height+1, privVal.LastTxVotes.Height)
+	assert.Len(t, privVal.LastTxVotes.Votes, 2)
+	assert.Error(t, privVal.SignTxVote(chainID, &old))
+}
+
 func newVote(addr types.Address, idx int, height int64, round int, typ byte, blockID types.BlockID) *types.Vote {
 	return &types.Vote{
 		ValidatorAddress: addr,
@@ -0,0 +1,145 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	rpctypes "github.com/tendermint/tendermint/rpc/lib/types"
+
+	ctypes "github.com/Fantom-foundation/go-txflow/rpc/core/types"
+	"github.com/Fantom-foundation/go-txflow/tx"
+)
+
+// ErrTxCommitsTooSlow ends the commit stream of a client which does not read
+// the commits as fast as they are sent.
+var ErrTxCommitsTooSlow = errors.New("Tx commits subscriber too slow, resubscribe from the last seq")
+
+const (
+	defaultTxCommitsLimit = 30
+	maxTxCommitsLimit     = 100
+
+	// widest height range searched for commits missing a validator, each
+	// commit in the range is loaded
+	maxMissingSearchHeights = 100
+)
+
+// TxCommits returns the txs committed on the fast path after the cursor, in
+// commit order. A cursor of 0 starts at the first commit.
+func TxCommits(ctx *rpctypes.Context, cursor int64, limit int) (*ctypes.ResultTxCommits, error) {
+	if cursor < 0 {
+		return nil, fmt.Errorf("Cursor must be non negative, got %d", cursor)
+	}
+	commits, err := txStore.LoadCommittedTxs(cursor, validateTxCommitsLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	lastSeq := cursor
+	if len(commits) > 0 {
+		lastSeq = commits[len(commits)-1].Seq
+	}
+	return &ctypes.ResultTxCommits{
+		Commits:   commits,
+		LastSeq:   lastSeq,
+		CommitSeq: txStore.CommitSeq(),
+	}, nil
+}
+
+// TxCommitsSearch returns the commits with votes from minHeight to maxHeight,
+// in height order. A maxHeight of 0 searches up to the last commit.
+//
+// Given a validator address, it returns only the commits the validator signed,
+// or with missing set only those it did not sign. The range of a missing
+// search is capped at maxMissingSearchHeights heights.
+func TxCommitsSearch(
+	ctx *rpctypes.Context,
+	minHeight, maxHeight int64,
+	validator []byte,
+	missing bool,
+	cursor []byte,
+	limit int,
+) (*ctypes.ResultTxCommitsSearch, error) {
+	if maxHeight <= 0 {
+		maxHeight = txStore.Height()
+	}
+	if minHeight < 0 || minHeight > maxHeight {
+		return nil, fmt.Errorf("Invalid height range [%d, %d]", minHeight, maxHeight)
+	}
+	if len(cursor) == 0 {
+		cursor = nil
+	}
+
+	var it *tx.CommitIterator
+	switch {
+	case len(validator) == 0 && missing:
+		return nil, errors.New("Searching for missing signatures needs a validator")
+	case len(validator) == 0:
+		it = txStore.CommitsByHeight(minHeight, maxHeight, cursor)
+	case missing:
+		if maxHeight-minHeight >= maxMissingSearchHeights {
+			maxHeight = minHeight + maxMissingSearchHeights - 1
+		}
+		it = txStore.CommitsMissingValidator(validator, minHeight, maxHeight, cursor)
+	default:
+		it = txStore.CommitsByValidator(validator, minHeight, maxHeight, cursor)
+	}
+	defer it.Close()
+
+	commits, next, err := it.ReadPage(validateTxCommitsLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	return &ctypes.ResultTxCommitsSearch{
+		Commits: commits,
+		Next:    next,
+	}, nil
+}
+
+// SubscribeTxCommits streams the txs committed on the fast path after the
+// cursor over the websocket: first the stored ones, then the live ones. Each
+// commit is sent as a tx.CommittedTx; the client keeps the Seq of the last one
+// it processed to resume from there. The stream ends with the connection.
+//
+// A client which does not keep up, so that the write buffer of its connection
+// fills up, is dropped: the stream ends with ErrTxCommitsTooSlow, sent on a
+// best effort basis, and the client resubscribes from its last Seq. Skipping
+// commits would break the stream, and waiting for the client would hold the
+// server's resources.
+func SubscribeTxCommits(ctx *rpctypes.Context, cursor int64) (*ctypes.ResultSubscribeTxCommits, error) {
+	if cursor < 0 {
+		return nil, fmt.Errorf("Cursor must be non negative, got %d", cursor)
+	}
+	logger.Info("Subscribe to tx commits", "remote", ctx.RemoteAddr(), "cursor", cursor)
+
+	stream := txStore.NewCommitStream(cursor)
+	id := rpctypes.JSONRPCStringID(fmt.Sprintf("%v#commit", ctx.JSONReq.ID))
+	go func() {
+		for {
+			committed, err := stream.Next(ctx.WSConn.Context())
+			if tx.IsErrTxPruned(err) {
+				ctx.WSConn.TryWriteRPCResponse(rpctypes.RPCServerError(id, err))
+				return
+			} else if err != nil {
+				return
+			}
+			ok := ctx.WSConn.TryWriteRPCResponse(
+				rpctypes.NewRPCSuccessResponse(ctx.WSConn.Codec(), id, committed))
+			if !ok {
+				logger.Info("Dropping slow tx commits subscriber", "remote", ctx.RemoteAddr(), "seq", committed.Seq)
+				ctx.WSConn.TryWriteRPCResponse(rpctypes.RPCServerError(id, ErrTxCommitsTooSlow))
+				return
+			}
+		}
+	}()
+
+	return &ctypes.ResultSubscribeTxCommits{Cursor: cursor}, nil
+}
+
+func validateTxCommitsLimit(limit int) int {
+	if limit <= 0 {
+		return defaultTxCommitsLimit
+	} else if limit > maxTxCommitsLimit {
+		return maxTxCommitsLimit
+	}
+	return limit
+}
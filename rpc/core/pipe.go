@@ -0,0 +1,65 @@
+package core
+
+import (
+	"time"
+
+	cfg "github.com/tendermint/tendermint/config"
+	"github.com/tendermint/tendermint/libs/log"
+	mempl "github.com/tendermint/tendermint/mempool"
+	ttypes "github.com/tendermint/tendermint/types"
+
+	"github.com/Fantom-foundation/go-txflow/tx"
+	"github.com/Fantom-foundation/go-txflow/txflow"
+	"github.com/Fantom-foundation/go-txflow/txvotepool"
+)
+
+const (
+	// SubscribeTimeout is the maximum time we wait to subscribe for an event.
+	// must be less than the server's write timeout (see rpcserver.DefaultConfig)
+	SubscribeTimeout = 5 * time.Second
+)
+
+//----------------------------------------------
+// These package level globals come with setters
+// that are expected to be called only once, on startup
+
+var (
+	txFlow     *txflow.TxFlow
+	txStore    *tx.TxStore
+	txVotePool *txvotepool.TxVotePool
+	mempool    mempl.Mempool
+	eventBus   *ttypes.EventBus
+
+	logger log.Logger
+
+	config cfg.RPCConfig
+)
+
+func SetTxFlow(txf *txflow.TxFlow) {
+	txFlow = txf
+}
+
+func SetTxStore(ts *tx.TxStore) {
+	txStore = ts
+}
+
+func SetTxVotePool(txvp *txvotepool.TxVotePool) {
+	txVotePool = txvp
+}
+
+func SetMempool(mem mempl.Mempool) {
+	mempool = mem
+}
+
+func SetEventBus(b *ttypes.EventBus) {
+	eventBus = b
+}
+
+func SetLogger(l log.Logger) {
+	logger = l
+}
+
+// SetConfig sets an RPCConfig.
+func SetConfig(c cfg.RPCConfig) {
+	config = c
+}
@@ -0,0 +1,36 @@
+package core
+
+import (
+	rpccore "github.com/tendermint/tendermint/rpc/core"
+	rpc "github.com/tendermint/tendermint/rpc/lib/server"
+)
+
+// Routes are the fast-path routes, served next to the Tendermint ones.
+var Routes = map[string]*rpc.RPCFunc{
+	// info API
+	"tx_commit":               rpc.NewRPCFunc(TxCommit, "hash"),
+	"tx_votes":                rpc.NewRPCFunc(TxVotes, "hash"),
+	"unconfirmed_txvotes":     rpc.NewRPCFunc(UnconfirmedTxVotes, "limit"),
+	"num_unconfirmed_txvotes": rpc.NewRPCFunc(NumUnconfirmedTxVotes, ""),
+	"tx_commits":              rpc.NewRPCFunc(TxCommits, "cursor,limit"),
+	"tx_commits_search":       rpc.NewRPCFunc(TxCommitsSearch, "min_height,max_height,validator,missing,cursor,limit"),
+
+	// subscribe API
+	"subscribe_tx_commits": rpc.NewWSRPCFunc(SubscribeTxCommits, "cursor"),
+
+	// broadcast API
+	"broadcast_tx_flow": rpc.NewRPCFunc(BroadcastTxFlow, "tx"),
+}
+
+// AllRoutes returns the Tendermint routes along with the fast-path routes.
+// Call it after rpccore.AddUnsafeRoutes, if at all.
+func AllRoutes() map[string]*rpc.RPCFunc {
+	routes := make(map[string]*rpc.RPCFunc, len(rpccore.Routes)+len(Routes))
+	for name, fn := range rpccore.Routes {
+		routes[name] = fn
+	}
+	for name, fn := range Routes {
+		routes[name] = fn
+	}
+	return routes
+}
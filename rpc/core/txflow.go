@@ -0,0 +1,164 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	rpctypes "github.com/tendermint/tendermint/rpc/lib/types"
+	ttypes "github.com/tendermint/tendermint/types"
+
+	ctypes "github.com/Fantom-foundation/go-txflow/rpc/core/types"
+	"github.com/Fantom-foundation/go-txflow/txflow"
+	"github.com/Fantom-foundation/go-txflow/types"
+)
+
+const (
+	defaultUnconfirmedTxVotesLimit = 30
+	maxUnconfirmedTxVotesLimit     = 100
+)
+
+// BroadcastTxFlow submits a tx and waits until the app committed it on the
+// fast path or the validators rejected it, or config.TimeoutBroadcastTxCommit
+// passed.
+//
+// If CheckTx fails, the CheckTx result is returned right away.
+func BroadcastTxFlow(ctx *rpctypes.Context, tx ttypes.Tx) (*ctypes.ResultBroadcastTxFlow, error) {
+	subscriber := ctx.RemoteAddr()
+
+	if eventBus.NumClients() >= config.MaxSubscriptionClients {
+		return nil, fmt.Errorf("max_subscription_clients %d reached", config.MaxSubscriptionClients)
+	} else if eventBus.NumClientSubscriptions(subscriber) >= config.MaxSubscriptionsPerClient {
+		return nil, fmt.Errorf("max_subscriptions_per_client %d reached", config.MaxSubscriptionsPerClient)
+	}
+
+	// Subscribe to the fast-path events of the tx
+	subCtx, cancel := context.WithTimeout(ctx.Context(), SubscribeTimeout)
+	defer cancel()
+	q := types.EventQueryTxFlowFor(types.TxHash(tx))
+	txFlowSub, err := eventBus.Subscribe(subCtx, subscriber, q)
+	if err != nil {
+		err = errors.Wrap(err, "failed to subscribe to tx")
+		logger.Error("Error on broadcastTxFlow", "err", err)
+		return nil, err
+	}
+	defer eventBus.Unsubscribe(context.Background(), subscriber, q)
+
+	checkTxResCh := make(chan *abci.Response, 1)
+	err = mempool.CheckTx(tx, func(res *abci.Response) {
+		checkTxResCh <- res
+	})
+	if err != nil {
+		logger.Error("Error on broadcastTxFlow", "err", err)
+		return nil, fmt.Errorf("Error on broadcastTxFlow: %v", err)
+	}
+	checkTxRes := (<-checkTxResCh).GetCheckTx()
+	result := &ctypes.ResultBroadcastTxFlow{
+		CheckTx: *checkTxRes,
+		Hash:    tx.Hash(),
+	}
+	if checkTxRes.Code != abci.CodeTypeOK {
+		return result, nil
+	}
+
+	// Wait for the tx to be committed or rejected, the votes are skipped
+	timeout := time.After(config.TimeoutBroadcastTxCommit)
+	for {
+		select {
+		case msg := <-txFlowSub.Out():
+			switch data := msg.Data().(type) {
+			case types.EventDataTxFastCommitted:
+				result.DeliverTx = data.Result
+				result.Commit = data.Commit
+				return result, nil
+			case types.EventDataTxRejected:
+				result.Reject = data.Certificate
+				return result, nil
+			}
+		case <-txFlowSub.Cancelled():
+			var reason string
+			if txFlowSub.Err() == nil {
+				reason = "Tendermint exited"
+			} else {
+				reason = txFlowSub.Err().Error()
+			}
+			err = fmt.Errorf("txFlowSub was cancelled (reason: %s)", reason)
+			logger.Error("Error on broadcastTxFlow", "err", err)
+			return result, err
+		case <-timeout:
+			err = errors.New("Timed out waiting for tx to be committed")
+			logger.Error("Error on broadcastTxFlow", "err", err)
+			return result, err
+		}
+	}
+}
+
+// TxCommit returns the 2/3 Commit of the tx with the given hash and its vote
+// set, unless they were pruned. The reject votes the tx got are counted in the
+// vote set.
+func TxCommit(ctx *rpctypes.Context, hash []byte) (*ctypes.ResultTxCommit, error) {
+	txHash := fmt.Sprintf("%X", hash)
+	voteSet, err := txFlow.LoadTxVoteSet(txHash)
+	if err != nil {
+		return nil, err
+	}
+	if voteSet == nil {
+		return nil, fmt.Errorf("Tx (%X) is not committed", hash)
+	}
+	return &ctypes.ResultTxCommit{
+		Hash:    hash,
+		Commit:  voteSet.MakeCommit(),
+		VoteSet: txflow.NewTxVoteSetInfo(voteSet),
+		Votes:   voteSet.GetVotes(),
+	}, nil
+}
+
+// TxVotes returns the votes and the stake collected for a tx which has not
+// reached 2/3 yet.
+func TxVotes(ctx *rpctypes.Context, hash []byte) (*ctypes.ResultTxVotes, error) {
+	txHash := fmt.Sprintf("%X", hash)
+	voteSet := txFlow.TxVoteSets.Get(txHash)
+	info := txFlow.TxVoteSets.Info(txHash)
+	if voteSet == nil || info == nil {
+		return nil, fmt.Errorf("No open vote set for tx (%X)", hash)
+	}
+	return &ctypes.ResultTxVotes{
+		VoteSet: *info,
+		Votes:   voteSet.GetVotes(),
+	}, nil
+}
+
+// UnconfirmedTxVotes returns up to limit votes waiting in the TxVotePool,
+// along with their number. Count is the number of votes returned, Total the
+// number in the TxVotePool.
+func UnconfirmedTxVotes(ctx *rpctypes.Context, limit int) (*ctypes.ResultUnconfirmedTxVotes, error) {
+	txVotes := txVotePool.ReapMaxTxs(validateUnconfirmedTxVotesLimit(limit))
+	return &ctypes.ResultUnconfirmedTxVotes{
+		Count:      len(txVotes),
+		Total:      txVotePool.Size(),
+		TotalBytes: txVotePool.TxsBytes(),
+		TxVotes:    txVotes,
+	}, nil
+}
+
+// NumUnconfirmedTxVotes returns the number of votes in the TxVotePool, in
+// Total. It returns no votes, so Count is 0.
+func NumUnconfirmedTxVotes(ctx *rpctypes.Context) (*ctypes.ResultUnconfirmedTxVotes, error) {
+	return &ctypes.ResultUnconfirmedTxVotes{
+		Count:      0,
+		Total:      txVotePool.Size(),
+		TotalBytes: txVotePool.TxsBytes(),
+	}, nil
+}
+
+func validateUnconfirmedTxVotesLimit(limit int) int {
+	if limit <= 0 {
+		return defaultUnconfirmedTxVotesLimit
+	} else if limit > maxUnconfirmedTxVotesLimit {
+		return maxUnconfirmedTxVotesLimit
+	}
+	return limit
+}
@@ -0,0 +1,67 @@
+package core_types
+
+import (
+	abci "github.com/tendermint/tendermint/abci/types"
+	cmn "github.com/tendermint/tendermint/libs/common"
+
+	"github.com/Fantom-foundation/go-txflow/tx"
+	"github.com/Fantom-foundation/go-txflow/txflow"
+	"github.com/Fantom-foundation/go-txflow/types"
+)
+
+// ResultBroadcastTxFlow is the result of broadcast_tx_flow. Exactly one of
+// Commit and Reject is set, unless CheckTx failed or the wait timed out.
+// DeliverTx is the result of the committed tx.
+type ResultBroadcastTxFlow struct {
+	CheckTx   abci.ResponseCheckTx   `json:"check_tx"`
+	DeliverTx abci.ResponseDeliverTx `json:"deliver_tx"`
+	Hash      cmn.HexBytes           `json:"hash"`
+	Commit    *types.Commit          `json:"commit"`
+	Reject    *types.Commit          `json:"reject"`
+}
+
+// ResultTxCommit is the stored outcome of a tx on the fast path: its 2/3
+// Commit and its vote set, with the reject votes the commit leaves out.
+type ResultTxCommit struct {
+	Hash    cmn.HexBytes         `json:"hash"`
+	Commit  *types.Commit        `json:"commit"`
+	VoteSet txflow.TxVoteSetInfo `json:"vote_set"`
+	Votes   []types.TxVote       `json:"votes"`
+}
+
+// ResultTxVotes shows the votes collected so far for a tx which has not
+// reached 2/3 yet.
+type ResultTxVotes struct {
+	VoteSet txflow.TxVoteSetInfo `json:"vote_set"`
+	Votes   []types.TxVote       `json:"votes"`
+}
+
+// ResultUnconfirmedTxVotes lists the votes waiting in the TxVotePool. Count
+// is the number of TxVotes listed, Total the number in the TxVotePool.
+type ResultUnconfirmedTxVotes struct {
+	Count      int            `json:"n_txvotes"`
+	Total      int            `json:"total"`
+	TotalBytes int64          `json:"total_bytes"`
+	TxVotes    []types.TxVote `json:"txvotes"`
+}
+
+// ResultTxCommits is a page of the commit log. Pass LastSeq back as the cursor
+// to get the next page.
+type ResultTxCommits struct {
+	Commits   []*tx.CommittedTx `json:"commits"`
+	LastSeq   int64             `json:"last_seq"`
+	CommitSeq int64             `json:"commit_seq"`
+}
+
+// ResultSubscribeTxCommits acknowledges subscribe_tx_commits. The commits
+// follow as events, starting right after Cursor.
+type ResultSubscribeTxCommits struct {
+	Cursor int64 `json:"cursor"`
+}
+
+// ResultTxCommitsSearch is a page of the commits matching a search. Pass Next
+// back as the cursor to get the next page, it is empty after the last one.
+type ResultTxCommitsSearch struct {
+	Commits []*tx.CommittedTx `json:"commits"`
+	Next    cmn.HexBytes      `json:"next"`
+}
@@ -6,13 +6,22 @@ import (
 
 	"github.com/Fantom-foundation/go-txflow/types"
 	abci "github.com/tendermint/tendermint/abci/types"
+	dbm "github.com/tendermint/tendermint/libs/db"
 	"github.com/tendermint/tendermint/libs/fail"
 	"github.com/tendermint/tendermint/libs/log"
 	mempl "github.com/tendermint/tendermint/mempool"
 	"github.com/tendermint/tendermint/proxy"
 	sm "github.com/tendermint/tendermint/state"
 	ttypes "github.com/tendermint/tendermint/types"
-	dbm "github.com/tendermint/tm-cmn/db"
+)
+
+const (
+	// CodespaceTxFlow is the codespace of the DeliverTx responses made up by
+	// the BlockExecutor instead of the app.
+	CodespaceTxFlow = "txflow"
+	// CodeTypeSkipped marks a block tx which was not delivered to the app,
+	// because it was already committed on the fast path.
+	CodeTypeSkipped uint32 = 1
 )
 
 //-----------------------------------------------------------------------------
@@ -34,9 +43,16 @@ type BlockExecutor struct {
 	// manage the mempool lock during commit
 	// and update both with block results after commit.
 	mempool    mempl.Mempool
-	commitpool mempl.Mempool
+	commitpool CommitPool
 	evpool     EvidencePool
 
+	// execute the fast-path txs of each tick block
+	txSeq TxSequencer
+	// keep fast-path committed txs out of proposed blocks
+	txStore TxStore
+	// txs which failed to reach 2/3, proposed ahead of the mempool txs
+	stalledTxs StalledTxs
+
 	logger log.Logger
 
 	metrics *Metrics
@@ -52,7 +68,7 @@ func BlockExecutorWithMetrics(metrics *Metrics) BlockExecutorOption {
 
 // NewBlockExecutor returns a new BlockExecutor with a NopEventBus.
 // Call SetEventBus to provide one.
-func NewBlockExecutor(db dbm.DB, logger log.Logger, proxyApp proxy.AppConnConsensus, mempool mempl.Mempool, commitpool mempl.Mempool, evpool EvidencePool, options ...BlockExecutorOption) *BlockExecutor {
+func NewBlockExecutor(db dbm.DB, logger log.Logger, proxyApp proxy.AppConnConsensus, mempool mempl.Mempool, commitpool CommitPool, evpool EvidencePool, options ...BlockExecutorOption) *BlockExecutor {
 	res := &BlockExecutor{
 		db:         db,
 		proxyApp:   proxyApp,
@@ -81,6 +97,24 @@ func (blockExec *BlockExecutor) SetEventBus(eventBus ttypes.BlockEventPublisher)
 	blockExec.eventBus = eventBus
 }
 
+// SetTxSequencer - sets the sequencer which executes the Vtxs of every
+// applied block. If not called, Vtxs are only recorded, not executed.
+func (blockExec *BlockExecutor) SetTxSequencer(txSeq TxSequencer) {
+	blockExec.txSeq = txSeq
+}
+
+// SetTxStore - sets the store of the fast-path committed txs, which are then
+// left out of the txs of proposed blocks.
+func (blockExec *BlockExecutor) SetTxStore(txStore TxStore) {
+	blockExec.txStore = txStore
+}
+
+// SetStalledTxs - sets the source of the txs which failed to reach 2/3 on the
+// fast path. Proposed blocks order them ahead of the mempool txs.
+func (blockExec *BlockExecutor) SetStalledTxs(stalledTxs StalledTxs) {
+	blockExec.stalledTxs = stalledTxs
+}
+
 // CreateProposalBlock calls state.MakeBlock with evidence from the evpool
 // and txs from the mempool. The max bytes must be big enough to fit the commit.
 // Up to 1/10th of the block space is allcoated for maximum sized evidence.
@@ -98,14 +132,92 @@ func (blockExec *BlockExecutor) CreateProposalBlock(
 	maxNumEvidence, _ := ttypes.MaxEvidencePerBlock(maxBytes)
 	evidence := blockExec.evpool.PendingEvidence(maxNumEvidence)
 
-	// Fetch a limited amount of valid txs
+	// Up to half of the data space is given to the validated txs and their
+	// commits, the rest to the mempool txs
 	maxDataBytes := ttypes.MaxDataBytes(maxBytes, state.Validators.Size(), len(evidence))
-	txs := blockExec.mempool.ReapMaxBytesMaxGas(maxDataBytes, maxGas)
+	vtxs, vtxCommits, vtxBytes := blockExec.reapVtxs(maxDataBytes / 2)
+
+	// Stalled txs go first, the mempool fills the remaining space
+	stalled, stalledBytes := blockExec.reapStalledTxs(maxDataBytes - vtxBytes)
+
+	// Fetch a limited amount of valid txs
+	txs := blockExec.mempool.ReapMaxBytesMaxGas(maxDataBytes-vtxBytes-stalledBytes, maxGas)
+	txs = append(stalled, blockExec.dropCommittedTxs(dropTxs(txs, stalled))...)
+
+	block, _ := state.MakeBlock(height, txs, vtxs, commit, evidence, proposerAddr)
+	block.VtxCommits = vtxCommits
+	return block, block.MakePartSet(ttypes.BlockPartSizeBytes)
+}
+
+// reapVtxs returns the committed fast-path txs which no block included yet,
+// their commits and their size.
+func (blockExec *BlockExecutor) reapVtxs(maxBytes int64) (ttypes.Txs, []*types.Commit, int64) {
+	var (
+		vtxs    ttypes.Txs
+		commits []*types.Commit
+		size    int64
+	)
+	reaped, reapedCommits := blockExec.commitpool.ReapMaxBytes(maxBytes)
+	for i, vtx := range reaped {
+		// Committed locally after a block already recorded or executed it
+		txHash := types.TxHash(vtx)
+		if LoadVtxHeight(blockExec.db, txHash) > 0 || LoadBlockTxHeight(blockExec.db, txHash) > 0 {
+			continue
+		}
+		vtxs = append(vtxs, vtx)
+		commits = append(commits, reapedCommits[i])
+		size += int64(len(cdc.MustMarshalBinaryLengthPrefixed(vtx)) +
+			len(cdc.MustMarshalBinaryLengthPrefixed(reapedCommits[i])))
+	}
+	return vtxs, commits, size
+}
+
+// reapStalledTxs returns the txs which failed to reach 2/3 on the fast path and
+// their size. Their gas is not counted against the block's max gas: they
+// already passed CheckTx to get into the mempool.
+func (blockExec *BlockExecutor) reapStalledTxs(maxBytes int64) (ttypes.Txs, int64) {
+	if blockExec.stalledTxs == nil {
+		return nil, 0
+	}
+	txs := blockExec.dropCommittedTxs(blockExec.stalledTxs.ReapStalled(maxBytes))
+	var size int64
+	for _, tx := range txs {
+		size += int64(len(tx)) + ttypes.ComputeAminoOverhead(tx, 1)
+	}
+	return txs, size
+}
 
-	// Attach all validated txs
-	vtxs := blockExec.commitpool.ReapMaxTxs(-1)
+// dropTxs removes the given txs from txs.
+func dropTxs(txs, drop ttypes.Txs) ttypes.Txs {
+	if len(drop) == 0 {
+		return txs
+	}
+	dropped := make(map[string]bool, len(drop))
+	for _, tx := range drop {
+		dropped[types.TxHash(tx)] = true
+	}
+	filtered := txs[:0]
+	for _, tx := range txs {
+		if !dropped[types.TxHash(tx)] {
+			filtered = append(filtered, tx)
+		}
+	}
+	return filtered
+}
 
-	return state.MakeBlock(height, txs, vtxs, commit, evidence, proposerAddr)
+// dropCommittedTxs removes the txs already committed on the fast path. They
+// would be skipped anyway once recorded in Vtxs, see skippedTxs.
+func (blockExec *BlockExecutor) dropCommittedTxs(txs ttypes.Txs) ttypes.Txs {
+	if blockExec.txStore == nil {
+		return txs
+	}
+	filtered := txs[:0]
+	for _, tx := range txs {
+		if !blockExec.txStore.HasTx(types.TxHash(tx)) {
+			filtered = append(filtered, tx)
+		}
+	}
+	return filtered
 }
 
 // ValidateBlock validates the given block against the given state.
@@ -127,6 +239,14 @@ func (blockExec *BlockExecutor) ApplyBlock(state State, blockID ttypes.BlockID,
 		return state, sm.ErrInvalidBlock(err)
 	}
 
+	// Keep fast-path txs from interleaving with the block.
+	if blockExec.txSeq != nil {
+		if err := blockExec.txSeq.Lock(); err != nil {
+			return state, sm.ErrProxyAppConn(err)
+		}
+		defer blockExec.txSeq.Unlock()
+	}
+
 	startTime := time.Now().UnixNano()
 	abciResponses, err := execBlockOnProxyApp(blockExec.logger, blockExec.proxyApp, block, blockExec.db)
 	endTime := time.Now().UnixNano()
@@ -168,11 +288,31 @@ func (blockExec *BlockExecutor) ApplyBlock(state State, blockID ttypes.BlockID,
 		return state, fmt.Errorf("Commit failed for application: %v", err)
 	}
 
+	fail.Fail() // XXX
+
+	// Execute the fast-path txs in the order fixed by this block.
+	if blockExec.txSeq != nil {
+		seqAppHash, err := blockExec.txSeq.SequenceBlock(block, ExecutableVtxs(block, blockExec.db))
+		if err != nil {
+			return state, fmt.Errorf("Sequencing Vtxs failed for application: %v", err)
+		}
+		if seqAppHash != nil {
+			appHash = seqAppHash
+		}
+	}
+
 	// Update evpool with the block and state.
 	blockExec.evpool.Update(block, state)
 
 	fail.Fail() // XXX
 
+	// Record the Vtxs and the delivered txs, so they are rejected as Vtxs in
+	// later blocks.
+	saveVtxs(blockExec.db, block)
+	saveBlockTxs(blockExec.db, block, abciResponses)
+	blockExec.commitpool.Update(block.Vtxs)
+	blockExec.commitpool.Update(block.Txs)
+
 	// Update the app hash and save the state.
 	state.AppHash = appHash
 	SaveState(blockExec.db, state)
@@ -249,7 +389,16 @@ func execBlockOnProxyApp(
 	block *types.Block,
 	stateDB dbm.DB,
 ) (*sm.ABCIResponses, error) {
-	var validTxs, invalidTxs = 0, 0
+	var validTxs, invalidTxs, skippedCount = 0, 0, 0
+
+	// Responses come back for the delivered txs only
+	skipped := skippedTxs(block, stateDB)
+	delivered := make([]int, 0, len(block.Txs))
+	for i := range block.Txs {
+		if !skipped[i] {
+			delivered = append(delivered, i)
+		}
+	}
 
 	txIndex := 0
 	abciResponses := NewABCIResponses(block)
@@ -268,7 +417,7 @@ func execBlockOnProxyApp(
 				logger.Debug("Invalid tx", "code", txRes.Code, "log", txRes.Log)
 				invalidTxs++
 			}
-			abciResponses.DeliverTx[txIndex] = txRes
+			abciResponses.DeliverTx[delivered[txIndex]] = txRes
 			txIndex++
 		}
 	}
@@ -290,7 +439,16 @@ func execBlockOnProxyApp(
 	}
 
 	// Run txs of block.
-	for _, tx := range block.Txs {
+	for i, tx := range block.Txs {
+		if skipped[i] {
+			abciResponses.DeliverTx[i] = &abci.ResponseDeliverTx{
+				Code:      CodeTypeSkipped,
+				Codespace: CodespaceTxFlow,
+				Log:       "tx was already committed on the fast path",
+			}
+			skippedCount++
+			continue
+		}
 		proxyAppConn.DeliverTxAsync(abci.RequestDeliverTx{Tx: tx})
 		if err := proxyAppConn.Error(); err != nil {
 			return nil, err
@@ -304,11 +462,59 @@ func execBlockOnProxyApp(
 		return nil, err
 	}
 
-	logger.Info("Executed block", "height", block.Height, "validTxs", validTxs, "invalidTxs", invalidTxs)
+	logger.Info("Executed block", "height", block.Height, "validTxs", validTxs, "invalidTxs", invalidTxs, "skippedTxs", skippedCount)
 
 	return abciResponses, nil
 }
 
+// skippedTxs returns which block txs must not be delivered, because they
+// were already committed on the fast path. Only commits recorded on chain
+// count, in the Vtxs of the block or of an earlier one, so all nodes decide
+// the same, also when replaying the block later on.
+func skippedTxs(block *types.Block, stateDB dbm.DB) []bool {
+	skipped := make([]bool, len(block.Txs))
+	if len(block.Txs) == 0 {
+		return skipped
+	}
+	vtxs := make(map[string]bool, len(block.Vtxs))
+	for _, vtx := range block.Vtxs {
+		vtxs[types.TxHash(vtx)] = true
+	}
+	for i, tx := range block.Txs {
+		txHash := types.TxHash(tx)
+		if vtxs[txHash] {
+			skipped[i] = true
+			continue
+		}
+		if height := LoadVtxHeight(stateDB, txHash); height > 0 && height <= block.Height {
+			skipped[i] = true
+		}
+	}
+	return skipped
+}
+
+// ExecutableVtxs returns the Vtxs of the block which no earlier block
+// delivered to the app in its Txs. validateBlock rejects blocks with such
+// Vtxs, this covers the blocks executed again without validation, e.g. when
+// the Handshaker replays them.
+func ExecutableVtxs(block *types.Block, stateDB dbm.DB) ttypes.Txs {
+	vtxs := make(ttypes.Txs, 0, len(block.Vtxs))
+	for _, vtx := range block.Vtxs {
+		if height := LoadBlockTxHeight(stateDB, types.TxHash(vtx)); height > 0 && height < block.Height {
+			continue
+		}
+		vtxs = append(vtxs, vtx)
+	}
+	return vtxs
+}
+
+// IsSkippedTx returns true if the DeliverTx response marks a block tx which
+// was not delivered to the app, because it was already committed on the fast
+// path.
+func IsSkippedTx(res *abci.ResponseDeliverTx) bool {
+	return res != nil && res.Codespace == CodespaceTxFlow && res.Code == CodeTypeSkipped
+}
+
 func getBeginBlockValidatorInfo(block *types.Block, stateDB dbm.DB) (abci.LastCommitInfo, []abci.Evidence) {
 	voteInfos := make([]abci.VoteInfo, block.LastCommit.Size())
 	byzVals := make([]abci.Evidence, len(block.Evidence.Evidence))
@@ -354,7 +560,7 @@ func getBeginBlockValidatorInfo(block *types.Block, stateDB dbm.DB) (abci.LastCo
 		if err != nil {
 			panic(err) // shouldn't happen
 		}
-		byzVals[i] = ttypes.TM2PB.Evidence(ev, valset, block.Time)
+		byzVals[i] = types.TM2PBEvidence(ev, valset, block.Time)
 	}
 
 	commitInfo := abci.LastCommitInfo{
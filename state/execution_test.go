@@ -6,6 +6,7 @@ import (
 	"time"
 
 	sm "github.com/Fantom-foundation/go-txflow/state"
+	txtypes "github.com/Fantom-foundation/go-txflow/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tendermint/tendermint/abci/example/kvstore"
@@ -35,7 +36,7 @@ func TestApplyBlock(t *testing.T) {
 	state, stateDB, _ := makeState(1, 1)
 
 	blockExec := sm.NewBlockExecutor(stateDB, log.TestingLogger(), proxyApp.Consensus(),
-		mock.Mempool{}, mock.Mempool{}, sm.MockEvidencePool{})
+		mock.Mempool{}, sm.MockCommitPool{}, sm.MockEvidencePool{})
 
 	block := makeBlock(state, 1)
 	blockID := ttypes.BlockID{Hash: block.Hash(), PartsHeader: block.MakePartSet(testPartSize).Header()}
@@ -47,6 +48,66 @@ func TestApplyBlock(t *testing.T) {
 	// TODO check state and mempool
 }
 
+// TestSkippedTxs ensures block txs already recorded as Vtxs, by the block
+// itself or an earlier one, are skipped.
+func TestSkippedTxs(t *testing.T) {
+	state, stateDB, _ := makeState(1, 1)
+	txs := makeTxs(2)
+
+	prev := makeBlock(state, 2)
+	prev.Vtxs = ttypes.Txs{txs[0]}
+	sm.SaveVtxs(stateDB, prev)
+
+	block := makeBlock(state, 3)
+	block.Txs = txs
+	block.Vtxs = ttypes.Txs{txs[1]}
+	skipped := sm.SkippedTxs(block, stateDB)
+	require.Len(t, skipped, len(txs))
+	assert.True(t, skipped[0], "tx recorded by an earlier block")
+	assert.True(t, skipped[1], "tx recorded by the block itself")
+	for i := 2; i < len(txs); i++ {
+		assert.False(t, skipped[i], "tx %d was never committed on the fast path", i)
+	}
+
+	// Replaying an older block must not see the later Vtxs
+	old := makeBlock(state, 1)
+	old.Txs = txs
+	assert.False(t, sm.SkippedTxs(old, stateDB)[0])
+
+	assert.True(t, sm.IsSkippedTx(&abci.ResponseDeliverTx{Code: sm.CodeTypeSkipped, Codespace: sm.CodespaceTxFlow}))
+	assert.False(t, sm.IsSkippedTx(&abci.ResponseDeliverTx{Code: sm.CodeTypeSkipped}))
+}
+
+// TestBlockTxsNotExecutedAsVtxs ensures txs an earlier block delivered in its
+// Txs are neither accepted nor executed as Vtxs later on.
+func TestBlockTxsNotExecutedAsVtxs(t *testing.T) {
+	state, stateDB, _ := makeState(1, 1)
+	txs := makeTxs(3)
+
+	prev := makeBlock(state, 2)
+	prev.Txs = txs
+	abciResponses := sm.NewABCIResponses(prev)
+	for i := range abciResponses.DeliverTx {
+		abciResponses.DeliverTx[i] = &abci.ResponseDeliverTx{}
+	}
+	// Skipped txs were not delivered, they are not recorded
+	abciResponses.DeliverTx[2] = &abci.ResponseDeliverTx{Code: sm.CodeTypeSkipped, Codespace: sm.CodespaceTxFlow}
+	sm.SaveBlockTxs(stateDB, prev, abciResponses)
+	assert.Equal(t, int64(2), sm.LoadBlockTxHeight(stateDB, txtypes.TxHash(txs[0])))
+	assert.Equal(t, int64(0), sm.LoadBlockTxHeight(stateDB, txtypes.TxHash(txs[2])))
+
+	block := makeBlock(state, 3)
+	block.Vtxs = ttypes.Txs{txs[1], txs[2]}
+	assert.Equal(t, ttypes.Txs{txs[2]}, sm.ExecutableVtxs(block, stateDB))
+	err := sm.ValidateVtxs(stateDB, state, block)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already executed in the txs of block 2")
+
+	// Replaying the block itself must not see its own txs
+	prev.Vtxs = ttypes.Txs{txs[0]}
+	assert.Equal(t, prev.Vtxs, sm.ExecutableVtxs(prev, stateDB))
+}
+
 // TestBeginBlockValidators ensures we send absent validators list.
 func TestBeginBlockValidators(t *testing.T) {
 	app := &testApp{}
@@ -307,7 +368,7 @@ func TestEndBlockValidatorUpdates(t *testing.T) {
 
 	state, stateDB, _ := makeState(1, 1)
 
-	blockExec := sm.NewBlockExecutor(stateDB, log.TestingLogger(), proxyApp.Consensus(), mock.Mempool{}, mock.Mempool{}, sm.MockEvidencePool{})
+	blockExec := sm.NewBlockExecutor(stateDB, log.TestingLogger(), proxyApp.Consensus(), mock.Mempool{}, sm.MockCommitPool{}, sm.MockEvidencePool{})
 
 	eventBus := ttypes.NewEventBus()
 	err = eventBus.Start()
@@ -364,7 +425,7 @@ func TestEndBlockValidatorUpdatesResultingInEmptySet(t *testing.T) {
 	defer proxyApp.Stop()
 
 	state, stateDB, _ := makeState(1, 1)
-	blockExec := sm.NewBlockExecutor(stateDB, log.TestingLogger(), proxyApp.Consensus(), mock.Mempool{}, mock.Mempool{}, sm.MockEvidencePool{})
+	blockExec := sm.NewBlockExecutor(stateDB, log.TestingLogger(), proxyApp.Consensus(), mock.Mempool{}, sm.MockCommitPool{}, sm.MockEvidencePool{})
 
 	block := makeBlock(state, 1)
 	blockID := ttypes.BlockID{Hash: block.Hash(), PartsHeader: block.MakePartSet(testPartSize).Header()}
@@ -2,9 +2,11 @@ package state
 
 import (
 	abci "github.com/tendermint/tendermint/abci/types"
+	dbm "github.com/tendermint/tendermint/libs/db"
 	sm "github.com/tendermint/tendermint/state"
 	"github.com/tendermint/tendermint/types"
-	dbm "github.com/tendermint/tm-cmn/db"
+
+	txtypes "github.com/Fantom-foundation/go-txflow/types"
 )
 
 //
@@ -61,3 +63,27 @@ func SaveConsensusParamsInfo(db dbm.DB, nextHeight, changeHeight int64, params t
 func SaveValidatorsInfo(db dbm.DB, height, lastHeightChanged int64, valSet *types.ValidatorSet) {
 	saveValidatorsInfo(db, height, lastHeightChanged, valSet)
 }
+
+// SaveVtxs is an alias for the private saveVtxs method in store.go, exported
+// exclusively and explicitly for testing.
+func SaveVtxs(db dbm.DB, block *txtypes.Block) {
+	saveVtxs(db, block)
+}
+
+// SkippedTxs is an alias for the private skippedTxs method in execution.go,
+// exported exclusively and explicitly for testing.
+func SkippedTxs(block *txtypes.Block, stateDB dbm.DB) []bool {
+	return skippedTxs(block, stateDB)
+}
+
+// SaveBlockTxs is an alias for the private saveBlockTxs method in store.go,
+// exported exclusively and explicitly for testing.
+func SaveBlockTxs(db dbm.DB, block *txtypes.Block, abciResponses *sm.ABCIResponses) {
+	saveBlockTxs(db, block, abciResponses)
+}
+
+// ValidateVtxs is an alias for the private validateVtxs method in
+// validation.go, exported exclusively and explicitly for testing.
+func ValidateVtxs(stateDB dbm.DB, state State, block *txtypes.Block) error {
+	return validateVtxs(stateDB, state, block)
+}
@@ -51,3 +51,62 @@ func (m MockEvidencePool) PendingEvidence(int64) []ttypes.Evidence { return nil
 func (m MockEvidencePool) AddEvidence(ttypes.Evidence) error       { return nil }
 func (m MockEvidencePool) Update(*types.Block, State)              {}
 func (m MockEvidencePool) IsCommitted(ttypes.Evidence) bool        { return false }
+
+//-----------------------------------------------------------------------------------------------------
+// fast path
+
+// TxSequencer defines the interface used by the BlockExecutor to execute the
+// fast-path txs (Vtxs) in the order fixed by a tick block.
+type TxSequencer interface {
+	// Lock holds back fast-path execution, so the app sees the block on its
+	// own.
+	Lock() error
+	Unlock()
+
+	// SequenceBlock applies vtxs, the Vtxs of the block no earlier block
+	// executed in its Txs, and returns the resulting app hash, or nil if
+	// nothing was applied. Must be called between Lock and Unlock.
+	SequenceBlock(block *types.Block, vtxs ttypes.Txs) ([]byte, error)
+}
+
+// TxOrderer fixes the order of the Vtxs of a tick block and the batches
+// they are committed to the app in. The TxSequencer executes them in this
+// order, the Handshaker replays them in it.
+type TxOrderer interface {
+	Sequence(vtxs ttypes.Txs) []ttypes.Txs
+}
+
+// StalledTxs hands the txs which failed to reach 2/3 on the fast path to the
+// block proposer, to be ordered in the Txs of a block instead.
+type StalledTxs interface {
+	ReapStalled(maxBytes int64) ttypes.Txs
+}
+
+// TxStore tells which txs were committed on the fast path.
+type TxStore interface {
+	HasTx(txHash string) bool
+}
+
+// CommitPool holds the fast-path committed txs until a tick block records
+// them in its Vtxs.
+type CommitPool interface {
+	// ReapMaxBytes returns txs and their commits in commit order, up to
+	// maxBytes in total.
+	ReapMaxBytes(maxBytes int64) (ttypes.Txs, []*types.Commit)
+	// Update drops the Vtxs of a finalized block.
+	Update(vtxs ttypes.Txs)
+}
+
+// MockCommitPool is an empty implementation of CommitPool, useful for testing.
+type MockCommitPool struct{}
+
+func (m MockCommitPool) ReapMaxBytes(int64) (ttypes.Txs, []*types.Commit) { return nil, nil }
+func (m MockCommitPool) Update(ttypes.Txs)                                {}
+
+// TxJournal records the execution of fast-path txs, so the Handshaker can
+// bring the app back in line with them after a crash.
+type TxJournal interface {
+	LastJournalSeq() int64
+	LoadJournalEntry(seq int64) *types.TxJournalEntry
+	SaveJournalEntry(entry *types.TxJournalEntry)
+}
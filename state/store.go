@@ -6,9 +6,9 @@ import (
 	"github.com/Fantom-foundation/go-txflow/types"
 	abci "github.com/tendermint/tendermint/abci/types"
 	cmn "github.com/tendermint/tendermint/libs/common"
+	dbm "github.com/tendermint/tendermint/libs/db"
 	sm "github.com/tendermint/tendermint/state"
 	ttypes "github.com/tendermint/tendermint/types"
-	dbm "github.com/tendermint/tm-cmn/db"
 )
 
 const (
@@ -33,6 +33,14 @@ func calcABCIResponsesKey(height int64) []byte {
 	return []byte(fmt.Sprintf("abciResponsesKey:%v", height))
 }
 
+func calcVtxKey(txHash string) []byte {
+	return []byte(fmt.Sprintf("vtxKey:%v", txHash))
+}
+
+func calcBlockTxKey(txHash string) []byte {
+	return []byte(fmt.Sprintf("blockTxKey:%v", txHash))
+}
+
 // LoadStateFromDBOrGenesisFile loads the most recent state from the database,
 // or creates a new one from the given genesisFilePath and persists the result
 // to the database.
@@ -130,6 +138,76 @@ func saveABCIResponses(db dbm.DB, height int64, abciResponses *sm.ABCIResponses)
 	db.SetSync(calcABCIResponsesKey(height), abciResponses.Bytes())
 }
 
+// LoadVtxHeight returns the height of the block which included the given
+// fast-path tx in its Vtxs, or 0 if no block did.
+func LoadVtxHeight(db dbm.DB, txHash string) int64 {
+	buf := db.Get(calcVtxKey(txHash))
+	if len(buf) == 0 {
+		return 0
+	}
+	var height int64
+	err := cdc.UnmarshalBinaryBare(buf, &height)
+	if err != nil {
+		// DATA HAS BEEN CORRUPTED OR THE SPEC HAS CHANGED
+		cmn.Exit(fmt.Sprintf(`LoadVtxHeight: Data has been corrupted or its spec has
+                changed: %v\n`, err))
+	}
+	return height
+}
+
+// LoadBlockTxHeight returns the height of the first block which delivered the
+// given tx to the app in its Txs, or 0 if no block did.
+func LoadBlockTxHeight(db dbm.DB, txHash string) int64 {
+	buf := db.Get(calcBlockTxKey(txHash))
+	if len(buf) == 0 {
+		return 0
+	}
+	var height int64
+	err := cdc.UnmarshalBinaryBare(buf, &height)
+	if err != nil {
+		// DATA HAS BEEN CORRUPTED OR THE SPEC HAS CHANGED
+		cmn.Exit(fmt.Sprintf(`LoadBlockTxHeight: Data has been corrupted or its spec has
+                changed: %v\n`, err))
+	}
+	return height
+}
+
+// saveBlockTxs records the txs the block delivered to the app, so later
+// blocks can't execute them again as Vtxs. Txs skipped as already committed
+// on the fast path are left out.
+func saveBlockTxs(db dbm.DB, block *types.Block, abciResponses *sm.ABCIResponses) {
+	batch := db.NewBatch()
+	defer batch.Close()
+	height := cdc.MustMarshalBinaryBare(block.Height)
+	n := 0
+	for i, tx := range block.Txs {
+		key := calcBlockTxKey(types.TxHash(tx))
+		if IsSkippedTx(abciResponses.DeliverTx[i]) || db.Has(key) {
+			continue
+		}
+		batch.Set(key, height)
+		n++
+	}
+	if n > 0 {
+		batch.WriteSync()
+	}
+}
+
+// saveVtxs records the Vtxs of the block, so later blocks can't include them
+// again.
+func saveVtxs(db dbm.DB, block *types.Block) {
+	if len(block.Vtxs) == 0 {
+		return
+	}
+	batch := db.NewBatch()
+	defer batch.Close()
+	height := cdc.MustMarshalBinaryBare(block.Height)
+	for _, vtx := range block.Vtxs {
+		batch.Set(calcVtxKey(types.TxHash(vtx)), height)
+	}
+	batch.WriteSync()
+}
+
 // saveValidatorsInfo persists the validator set.
 //
 // `height` is the effective height for which the validator is responsible for
@@ -10,9 +10,9 @@ import (
 
 	sm "github.com/Fantom-foundation/go-txflow/state"
 	cfg "github.com/tendermint/tendermint/config"
+	dbm "github.com/tendermint/tendermint/libs/db"
 	tsm "github.com/tendermint/tendermint/state"
 	"github.com/tendermint/tendermint/types"
-	dbm "github.com/tendermint/tm-cmn/db"
 )
 
 func TestStoreLoadValidators(t *testing.T) {
@@ -7,9 +7,9 @@ import (
 
 	"github.com/Fantom-foundation/go-txflow/types"
 	"github.com/tendermint/tendermint/crypto"
+	dbm "github.com/tendermint/tendermint/libs/db"
 	sm "github.com/tendermint/tendermint/state"
 	ttypes "github.com/tendermint/tendermint/types"
-	dbm "github.com/tendermint/tm-cmn/db"
 )
 
 //-----------------------------------------------------
@@ -149,6 +149,11 @@ func validateBlock(evidencePool EvidencePool, stateDB dbm.DB, state State, block
 		}
 	}
 
+	// Validate the commits of all Vtxs.
+	if err := validateVtxs(stateDB, state, block); err != nil {
+		return err
+	}
+
 	// NOTE: We can't actually verify it's the right proposer because we dont
 	// know what round the block was first proposed. So just check that it's
 	// a legit address and a known validator.
@@ -162,6 +167,40 @@ func validateBlock(evidencePool EvidencePool, stateDB dbm.DB, state State, block
 	return nil
 }
 
+// validateVtxs checks that every Vtx is new and reached 2/3 before the block.
+// No earlier block may have included it, in its Vtxs or in its Txs, and its
+// commit must hold votes of more than 2/3 of the validator set the votes
+// were signed for, the set of the block after the commit height.
+func validateVtxs(stateDB dbm.DB, state State, block *types.Block) error {
+	seen := make(map[string]bool, len(block.Vtxs))
+	for i, vtx := range block.Vtxs {
+		txHash := types.TxHash(vtx)
+		if seen[txHash] {
+			return fmt.Errorf("Duplicate Vtx #%d %s", i, txHash)
+		}
+		seen[txHash] = true
+		if height := LoadVtxHeight(stateDB, txHash); height > 0 && height < block.Height {
+			return fmt.Errorf("Vtx #%d %s was already included at height %d", i, txHash, height)
+		}
+		if height := LoadBlockTxHeight(stateDB, txHash); height > 0 && height < block.Height {
+			return fmt.Errorf("Vtx #%d %s was already executed in the txs of block %d", i, txHash, height)
+		}
+
+		commit := block.VtxCommits[i]
+		if commit.Height() >= block.Height {
+			return fmt.Errorf("Commit of Vtx #%d is from height %d, not before block %d", i, commit.Height(), block.Height)
+		}
+		valSet, err := sm.LoadValidators(stateDB, commit.Height()+1)
+		if err != nil {
+			return fmt.Errorf("Can't load validators of Vtx #%d commit: %v", i, err)
+		}
+		if err := commit.VerifyTx(state.ChainID, vtx, valSet); err != nil {
+			return fmt.Errorf("Invalid commit of Vtx #%d: %v", i, err)
+		}
+	}
+	return nil
+}
+
 // VerifyEvidence verifies the evidence fully by checking:
 // - it is sufficiently recent (MaxAge)
 // - it is from a key who was a validator at the given height
@@ -12,8 +12,8 @@ import (
 	"github.com/Fantom-foundation/go-txflow/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"github.com/tendermint/tm-cmn/db"
-	dbm "github.com/tendermint/tm-cmn/db"
+	"github.com/tendermint/tendermint/libs/db"
+	dbm "github.com/tendermint/tendermint/libs/db"
 
 	sm "github.com/Fantom-foundation/go-txflow/state"
 	cfg "github.com/tendermint/tendermint/config"
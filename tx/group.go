@@ -0,0 +1,122 @@
+package tx
+
+import (
+	"time"
+
+	dbm "github.com/tendermint/tendermint/libs/db"
+
+	"github.com/Fantom-foundation/go-txflow/types"
+)
+
+const (
+	// DefaultGroupMaxDelay is how long the first SaveTx of a commit group
+	// waits for others. The group is also written only once the previous one
+	// is synced, concurrent SaveTx calls join it in the meantime.
+	DefaultGroupMaxDelay = 0
+	// DefaultGroupMaxSize is the most txs in a commit group
+	DefaultGroupMaxSize = 1000
+)
+
+/*
+commitGroup is a set of concurrent SaveTx calls written to the db in a single
+batch and synced once.
+
+The first SaveTx of a group is its leader. Once the group is full, or
+groupMaxDelay passed, the leader waits for the previous group to be synced,
+closes the group to new txs and writes it. The other callers wait for the
+group to be done. Groups are written in order, so the commit log only grows
+at its end and CommitSeq only counts synced txs.
+*/
+type commitGroup struct {
+	batch dbm.Batch
+	// commit seq of the txs in the group
+	seqs map[string]int64
+	// commits of the txs in the group, indexed in the batch
+	commits map[string]*types.Commit
+	// height and commit seq after the group is written
+	height    int64
+	commitSeq int64
+
+	full   chan struct{} // closed once the group is full
+	closed chan struct{} // closed once no more txs can join
+	done   chan struct{} // closed once the group is synced
+}
+
+// joinGroup returns the open commit group, and true if the caller opened it
+// and leads it.
+// Assumes ts.mtx is held.
+func (ts *TxStore) joinGroup() (*commitGroup, bool) {
+	if ts.group != nil {
+		return ts.group, false
+	}
+	ts.group = &commitGroup{
+		batch:     ts.db.NewBatch(),
+		seqs:      make(map[string]int64),
+		commits:   make(map[string]*types.Commit),
+		height:    ts.height,
+		commitSeq: ts.commitSeq,
+		full:      make(chan struct{}),
+		closed:    make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	return ts.group, true
+}
+
+// add records that the tx was staged in the group.
+// Assumes ts.mtx is held.
+func (g *commitGroup) add(txHash string, height, seq int64, commit *types.Commit, maxSize int) {
+	g.seqs[txHash] = seq
+	g.commits[txHash] = commit
+	g.height = height
+	if seq > g.commitSeq {
+		g.commitSeq = seq
+	}
+	if len(g.seqs) == maxSize {
+		close(g.full)
+	}
+}
+
+// flushGroup writes and syncs the group, then makes its txs visible.
+func (ts *TxStore) flushGroup(g *commitGroup) {
+	ts.mtx.RLock()
+	maxDelay := ts.groupMaxDelay
+	ts.mtx.RUnlock()
+	if maxDelay > 0 {
+		timer := time.NewTimer(maxDelay)
+		select {
+		case <-timer.C:
+		case <-g.full:
+			timer.Stop()
+		}
+	}
+
+	// Txs keep joining while the previous group is written
+	ts.flushMtx.Lock()
+	defer ts.flushMtx.Unlock()
+
+	ts.mtx.Lock()
+	ts.group = nil
+	ts.flushing = g
+	ts.mtx.Unlock()
+	close(g.closed)
+
+	// Save new TxStoreStateJSON descriptor
+	tsj := TxStoreStateJSON{Height: g.height, CommitSeq: g.commitSeq}
+	g.batch.Set(txStoreKey, tsj.mustMarshal())
+
+	// Flush
+	g.batch.WriteSync()
+	g.batch.Close()
+
+	// Done!
+	ts.mtx.Lock()
+	ts.height = g.height
+	ts.flushing = nil
+	if g.commitSeq > ts.commitSeq {
+		ts.commitSeq = g.commitSeq
+		close(ts.commitsCh)
+		ts.commitsCh = make(chan struct{})
+	}
+	ts.mtx.Unlock()
+	close(g.done)
+}
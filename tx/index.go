@@ -0,0 +1,221 @@
+package tx
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/tendermint/tendermint/crypto"
+	cmn "github.com/tendermint/tendermint/libs/common"
+	dbm "github.com/tendermint/tendermint/libs/db"
+
+	"github.com/Fantom-foundation/go-txflow/types"
+)
+
+/*
+The commit log is indexed by height and by signing validator. Index keys end
+with the zero padded height and commit sequence of the tx, so an index
+iterates in (height, seq) order and a range of heights is a range of keys.
+Index values are the commit sequence of the tx in the log.
+
+ - "IH:<height>:<seq>"         all commits by height
+ - "IV:<addr>:<height>:<seq>"  the commits each validator signed, by height
+
+The log itself, "S:<seq>", is the commit sequence index.
+*/
+
+func calcHeightIndexKey(height, seq int64) []byte {
+	return []byte(fmt.Sprintf("IH:%020d:%020d", height, seq))
+}
+
+func calcValidatorIndexKey(addr crypto.Address, height, seq int64) []byte {
+	return []byte(fmt.Sprintf("IV:%X:%020d:%020d", addr, height, seq))
+}
+
+// indexCommit adds the commit at seq to the indexes, at the height of its
+// votes.
+func indexCommit(batch dbm.Batch, seq int64, commit *types.Commit) {
+	height := commit.Height()
+	seqBytes := cdc.MustMarshalBinaryBare(seq)
+	batch.Set(calcHeightIndexKey(height, seq), seqBytes)
+	for _, sig := range commit.Commits {
+		if sig == nil {
+			continue
+		}
+		batch.Set(calcValidatorIndexKey(sig.ValidatorAddress, height, seq), seqBytes)
+	}
+}
+
+// unindexCommit removes the commit at seq from the indexes. A tx saved again
+// is unindexed first, in the same batch, so the keys of its old height and
+// signers do not linger.
+func unindexCommit(batch dbm.Batch, seq int64, commit *types.Commit) {
+	height := commit.Height()
+	batch.Delete(calcHeightIndexKey(height, seq))
+	for _, sig := range commit.Commits {
+		if sig == nil {
+			continue
+		}
+		batch.Delete(calcValidatorIndexKey(sig.ValidatorAddress, height, seq))
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+/*
+CommitIterator iterates over the commits of an index, in index order.
+
+Scans are paginated with cursors: Cursor returns the index key of the current
+commit, and an iterator opened at that cursor starts there. A page of commits
+is read with ReadPage. An iterator stops at the first commit it fails to
+load, see Err.
+
+	it := ts.CommitsByHeight(100, 200, nil)
+	defer it.Close()
+	for ; it.Valid(); it.Next() {
+		committed := it.Value()
+	}
+	if err := it.Err(); err != nil {
+		...
+	}
+*/
+type CommitIterator struct {
+	ts     *TxStore
+	it     dbm.Iterator
+	filter func(*CommittedTx) bool
+
+	value *CommittedTx
+	err   error
+}
+
+func (ts *TxStore) newCommitIterator(start, end, cursor []byte, filter func(*CommittedTx) bool) *CommitIterator {
+	if cursor != nil {
+		if bytes.Compare(cursor, end) >= 0 {
+			cursor = end
+		}
+		if bytes.Compare(cursor, start) > 0 {
+			start = cursor
+		}
+	}
+	ci := &CommitIterator{
+		ts:     ts,
+		it:     ts.db.Iterator(start, end),
+		filter: filter,
+	}
+	ci.seek()
+	return ci
+}
+
+// CommitsBySeq iterates over the commit log from minSeq to maxSeq, both
+// included.
+func (ts *TxStore) CommitsBySeq(minSeq, maxSeq int64, cursor []byte) *CommitIterator {
+	return ts.newCommitIterator(calcCommitLogKey(minSeq), calcCommitLogKey(maxSeq+1), cursor, nil)
+}
+
+// CommitsByHeight iterates over the commits with votes from minHeight to
+// maxHeight, both included.
+func (ts *TxStore) CommitsByHeight(minHeight, maxHeight int64, cursor []byte) *CommitIterator {
+	return ts.newCommitIterator(calcHeightIndexKey(minHeight, 0), calcHeightIndexKey(maxHeight+1, 0), cursor, nil)
+}
+
+// CommitsByValidator iterates over the commits from minHeight to maxHeight
+// which the validator signed.
+func (ts *TxStore) CommitsByValidator(addr crypto.Address, minHeight, maxHeight int64, cursor []byte) *CommitIterator {
+	return ts.newCommitIterator(
+		calcValidatorIndexKey(addr, minHeight, 0),
+		calcValidatorIndexKey(addr, maxHeight+1, 0),
+		cursor,
+		nil,
+	)
+}
+
+// CommitsMissingValidator iterates over the commits from minHeight to
+// maxHeight which the validator did not sign. It scans the height index, so
+// every commit in the range is loaded.
+func (ts *TxStore) CommitsMissingValidator(addr crypto.Address, minHeight, maxHeight int64, cursor []byte) *CommitIterator {
+	return ts.newCommitIterator(
+		calcHeightIndexKey(minHeight, 0),
+		calcHeightIndexKey(maxHeight+1, 0),
+		cursor,
+		func(committed *CommittedTx) bool { return !committed.SignedBy(addr) },
+	)
+}
+
+// seek moves to the first entry at or after the current one with a commit
+// passing the filter.
+func (ci *CommitIterator) seek() {
+	for ; ci.it.Valid(); ci.it.Next() {
+		ci.value, ci.err = ci.load()
+		if ci.err != nil {
+			break
+		}
+		if ci.value != nil && (ci.filter == nil || ci.filter(ci.value)) {
+			return
+		}
+	}
+	ci.value = nil
+}
+
+func (ci *CommitIterator) load() (*CommittedTx, error) {
+	// The log maps seq to the tx hash, the indexes to the seq
+	if bytes.HasPrefix(ci.it.Key(), []byte("S:")) {
+		return ci.ts.LoadCommittedTx(ci.ts.LoadCommitSeq(string(ci.it.Value())))
+	}
+	var seq int64
+	if err := cdc.UnmarshalBinaryBare(ci.it.Value(), &seq); err != nil {
+		panic(cmn.ErrorWrap(err, "Error reading commit index"))
+	}
+	return ci.ts.LoadCommittedTx(seq)
+}
+
+// Valid returns false once the iterator is past its last commit.
+func (ci *CommitIterator) Valid() bool {
+	return ci.value != nil
+}
+
+// Next moves to the next commit.
+func (ci *CommitIterator) Next() {
+	if !ci.Valid() {
+		panic("CommitIterator is past its last commit")
+	}
+	ci.it.Next()
+	ci.seek()
+}
+
+// Value returns the current commit.
+func (ci *CommitIterator) Value() *CommittedTx {
+	return ci.value
+}
+
+// Err returns the error the iterator stopped at, or nil if it did not fail.
+// The Cursor of a failed iterator is nil.
+func (ci *CommitIterator) Err() error {
+	return ci.err
+}
+
+// Cursor returns the position of the current commit, for a later iterator to
+// start from, or nil if the iterator is past its last commit.
+func (ci *CommitIterator) Cursor() []byte {
+	if !ci.Valid() {
+		return nil
+	}
+	return ci.it.Key()
+}
+
+// Close releases the iterator.
+func (ci *CommitIterator) Close() {
+	ci.it.Close()
+}
+
+// ReadPage reads at most limit commits from the iterator. The returned cursor
+// points at the commit following the page, or is nil if there is none.
+// It fails if the iterator does, see Err.
+func (ci *CommitIterator) ReadPage(limit int) ([]*CommittedTx, []byte, error) {
+	var commits []*CommittedTx
+	for ; ci.Valid() && len(commits) < limit; ci.Next() {
+		commits = append(commits, ci.Value())
+	}
+	if ci.err != nil {
+		return nil, nil, ci.err
+	}
+	return commits, ci.Cursor(), nil
+}
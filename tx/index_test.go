@@ -0,0 +1,153 @@
+package tx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/libs/db"
+	ttypes "github.com/tendermint/tendermint/types"
+
+	"github.com/Fantom-foundation/go-txflow/types"
+)
+
+// makeSignedTx returns a vote set at height holding the votes of the signers.
+func makeSignedTx(t *testing.T, txHash string, height int64, valSet *ttypes.ValidatorSet, signers []*types.MockPV) *types.TxVoteSet {
+	voteSet := types.NewTxVoteSet("test_chain_id", height, txHash, types.TxKey([]byte(txHash)), valSet)
+	for _, pv := range signers {
+		vote := types.NewTxVote(height, txHash, types.TxKey([]byte(txHash)), pv.GetPubKey().Address())
+		require.NoError(t, pv.SignTxVote("test_chain_id", &vote))
+		added, err := voteSet.AddVote(&vote)
+		require.NoError(t, err)
+		require.True(t, added)
+	}
+	return voteSet
+}
+
+func txHashes(commits []*CommittedTx) []string {
+	hashes := make([]string, len(commits))
+	for i, committed := range commits {
+		hashes[i] = committed.TxHash
+	}
+	return hashes
+}
+
+func TestTxStoreIndexes(t *testing.T) {
+	pvs := []*types.MockPV{types.NewMockPV(), types.NewMockPV(), types.NewMockPV()}
+	vals := make([]*ttypes.Validator, len(pvs))
+	// Two of the three signers still hold more than 2/3 of the power
+	powers := []int64{2, 2, 1}
+	for i, pv := range pvs {
+		vals[i] = ttypes.NewValidator(pv.GetPubKey(), powers[i])
+	}
+	valSet := ttypes.NewValidatorSet(vals)
+	all, missing2 := pvs, pvs[:2]
+	addr2 := pvs[2].GetPubKey().Address()
+
+	ts := NewTxStore(db.NewMemDB(), TxStoreWithValidatorsLoader(func(int64) (*ttypes.ValidatorSet, error) {
+		return valSet, nil
+	}))
+	// Saved out of height order
+	ts.SaveTx(makeSignedTx(t, "0x1", 100, valSet, all))
+	ts.SaveTx(makeSignedTx(t, "0x2", 150, valSet, missing2))
+	ts.SaveTx(makeSignedTx(t, "0x3", 120, valSet, all))
+	ts.SaveTx(makeSignedTx(t, "0x4", 250, valSet, missing2))
+
+	it := ts.CommitsByHeight(100, 200, nil)
+	commits, next, err := it.ReadPage(10)
+	require.NoError(t, err)
+	it.Close()
+	assert.Equal(t, []string{"0x1", "0x3", "0x2"}, txHashes(commits))
+	assert.Nil(t, next)
+	assert.Equal(t, int64(120), commits[1].Height)
+	assert.Equal(t, int64(3), commits[1].Seq)
+
+	// Paginate
+	it = ts.CommitsByHeight(0, 300, nil)
+	commits, next, err = it.ReadPage(2)
+	require.NoError(t, err)
+	it.Close()
+	assert.Equal(t, []string{"0x1", "0x3"}, txHashes(commits))
+	require.NotNil(t, next)
+	it = ts.CommitsByHeight(0, 300, next)
+	commits, next, err = it.ReadPage(2)
+	require.NoError(t, err)
+	it.Close()
+	assert.Equal(t, []string{"0x2", "0x4"}, txHashes(commits))
+	assert.Nil(t, next)
+
+	it = ts.CommitsByValidator(addr2, 0, 300, nil)
+	commits, _, err = it.ReadPage(10)
+	require.NoError(t, err)
+	it.Close()
+	assert.Equal(t, []string{"0x1", "0x3"}, txHashes(commits))
+
+	it = ts.CommitsMissingValidator(addr2, 0, 200, nil)
+	commits, _, err = it.ReadPage(10)
+	require.NoError(t, err)
+	it.Close()
+	assert.Equal(t, []string{"0x2"}, txHashes(commits))
+
+	it = ts.CommitsBySeq(2, 3, nil)
+	commits, _, err = it.ReadPage(10)
+	require.NoError(t, err)
+	it.Close()
+	assert.Equal(t, []string{"0x2", "0x3"}, txHashes(commits))
+}
+
+func TestTxStoreIndexesSavedAgain(t *testing.T) {
+	pvs := []*types.MockPV{types.NewMockPV(), types.NewMockPV(), types.NewMockPV()}
+	vals := make([]*ttypes.Validator, len(pvs))
+	powers := []int64{2, 2, 1}
+	for i, pv := range pvs {
+		vals[i] = ttypes.NewValidator(pv.GetPubKey(), powers[i])
+	}
+	valSet := ttypes.NewValidatorSet(vals)
+	addr2 := pvs[2].GetPubKey().Address()
+
+	for _, sameGroup := range []bool{false, true} {
+		db := db.NewMemDB()
+		ts := NewTxStore(db, TxStoreWithValidatorsLoader(func(int64) (*ttypes.ValidatorSet, error) {
+			return valSet, nil
+		}))
+		if sameGroup {
+			// Both saves are staged in the same batch
+			ts.SetGroupCommit(100*time.Millisecond, 10)
+			first, second := makeSignedTx(t, "0x1", 100, valSet, pvs), makeSignedTx(t, "0x1", 150, valSet, pvs[:2])
+			done := make(chan struct{})
+			go func() {
+				ts.SaveTx(first)
+				close(done)
+			}()
+			for staged := false; !staged; {
+				time.Sleep(time.Millisecond)
+				ts.mtx.RLock()
+				staged = ts.group != nil
+				ts.mtx.RUnlock()
+			}
+			ts.SaveTx(second)
+			<-done
+		} else {
+			ts.SaveTx(makeSignedTx(t, "0x1", 100, valSet, pvs))
+			ts.SaveTx(makeSignedTx(t, "0x1", 150, valSet, pvs[:2]))
+		}
+		assert.Equal(t, int64(1), ts.LoadCommitSeq("0x1"))
+
+		// Only the keys of the last save are left
+		it := ts.CommitsByHeight(0, 200, nil)
+		commits, _, err := it.ReadPage(10)
+		require.NoError(t, err)
+		it.Close()
+		require.Len(t, commits, 1)
+		assert.Equal(t, int64(150), commits[0].Height)
+
+		it = ts.CommitsByValidator(addr2, 0, 200, nil)
+		commits, _, err = it.ReadPage(10)
+		require.NoError(t, err)
+		it.Close()
+		assert.Empty(t, commits)
+		assert.False(t, db.Has(calcHeightIndexKey(100, 1)))
+		assert.False(t, db.Has(calcValidatorIndexKey(addr2, 100, 1)))
+	}
+}
@@ -0,0 +1,129 @@
+package tx
+
+import (
+	"encoding/hex"
+
+	"github.com/pkg/errors"
+
+	"github.com/Fantom-foundation/go-txflow/types"
+)
+
+const (
+	// commits converted per batch write
+	migrateBatchSize = 1000
+)
+
+var (
+	txCommitPrefix    = []byte("C:")
+	txCommitPrefixEnd = []byte("C;")
+	// vote sets saved before certificates, they only kept the tx hash and key
+	txVoteSetPrefix    = []byte("H:")
+	txVoteSetPrefixEnd = []byte("H;")
+
+	commitsMigratedKey = []byte("txCommitsMigrated")
+)
+
+/*
+MigrateCommits converts the full Commits saved before certificates into
+CommitCertificates, with the validators of the ValidatorsLoader, drops the
+vote sets saved alongside them, and returns how many commits it converted.
+
+Commits whose votes have different heights don't tell which validators they
+were counted against, they stay as they are. Each batch of conversions is written at once, so MigrateCommits
+can be stopped halfway and run again. Once it went through all the commits it
+records so, and later runs return right away.
+*/
+func (ts *TxStore) MigrateCommits(chainID string) (int, error) {
+	if ts.db.Has(commitsMigratedKey) {
+		return 0, nil
+	}
+	if ts.loadValidators == nil {
+		return 0, errors.New("TxStore needs a validators loader to make certificates")
+	}
+	var (
+		migrated int
+		start    = txCommitPrefix
+	)
+	for {
+		// The db is written once the iterator is closed
+		keys := ts.scanKeys(start, txCommitPrefixEnd)
+		if len(keys) == 0 {
+			break
+		}
+		// Resume after the last key, the unconverted commits stay
+		start = append(keys[len(keys)-1], 0)
+
+		batch := ts.db.NewBatch()
+		n := 0
+		for _, key := range keys {
+			cert, err := ts.commitCertificate(chainID, key)
+			if err != nil {
+				batch.Close()
+				return migrated, err
+			}
+			if cert == nil {
+				continue
+			}
+			batch.Set(calcTxCertificateKey(cert.TxHash), cert.Bytes())
+			batch.Delete(key)
+			n++
+		}
+		batch.WriteSync()
+		batch.Close()
+		migrated += n
+	}
+
+	for {
+		keys := ts.scanKeys(txVoteSetPrefix, txVoteSetPrefixEnd)
+		if len(keys) == 0 {
+			break
+		}
+		batch := ts.db.NewBatch()
+		for _, key := range keys {
+			batch.Delete(key)
+		}
+		batch.WriteSync()
+		batch.Close()
+	}
+
+	ts.db.SetSync(commitsMigratedKey, []byte{1})
+	return migrated, nil
+}
+
+// scanKeys returns at most migrateBatchSize keys from start to end.
+func (ts *TxStore) scanKeys(start, end []byte) [][]byte {
+	var keys [][]byte
+	it := ts.db.Iterator(start, end)
+	defer it.Close()
+	for ; it.Valid() && len(keys) < migrateBatchSize; it.Next() {
+		keys = append(keys, it.Key())
+	}
+	return keys
+}
+
+// commitCertificate returns the certificate of the commit stored at key, or
+// nil if its votes have different heights and it can't be one.
+func (ts *TxStore) commitCertificate(chainID string, key []byte) (*types.CommitCertificate, error) {
+	hashBytes, err := hex.DecodeString(string(key[len(txCommitPrefix):]))
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid commit key %q", key)
+	}
+	commit, err := ts.LoadTxCommit(string(hashBytes))
+	if err != nil || commit == nil {
+		return nil, err
+	}
+	for _, cs := range commit.Commits {
+		if cs != nil && cs.Height != commit.Height() {
+			return nil, nil
+		}
+	}
+	valSet, err := ts.loadValidators(commit.Height())
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading the validators of height %d", commit.Height())
+	}
+	cert, err := types.NewCommitCertificate(chainID, commit, valSet)
+	if err != nil {
+		return nil, errors.Wrapf(err, "making the certificate of tx %s", commit.TxHash)
+	}
+	return cert, nil
+}
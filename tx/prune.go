@@ -0,0 +1,236 @@
+package tx
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	cmn "github.com/tendermint/tendermint/libs/common"
+	dbm "github.com/tendermint/tendermint/libs/db"
+)
+
+// PruneMode selects what TxStore keeps of the txs out of the retention
+// window.
+type PruneMode int
+
+const (
+	// PruneNothing keeps every tx forever
+	PruneNothing PruneMode = iota
+	// PruneVoteSets drops the reject votes of old txs and keeps their
+	// commits, compact certificates for most
+	PruneVoteSets
+	// PruneAll drops old txs altogether. Only the record that they committed
+	// is kept, so late votes and blocks still treat them as final.
+	PruneAll
+)
+
+const (
+	// txs deleted per batch write
+	pruneBatchSize = 1000
+)
+
+// PruneConfig configures the Pruner. A tx is pruned once it is out of both
+// retention windows, a zero window is not checked.
+type PruneConfig struct {
+	Mode PruneMode `mapstructure:"mode"`
+	// keep the txs among the last RetainSeqs commits
+	RetainSeqs int64 `mapstructure:"retain_seqs"`
+	// keep the txs with votes among the last RetainHeights tick heights
+	RetainHeights int64 `mapstructure:"retain_heights"`
+	// how often the Pruner runs
+	Interval time.Duration `mapstructure:"interval"`
+	// reject votes expire this long after they are saved, on a db which
+	// supports it like db.BadgerDB. Zero keeps them.
+	TxTTL time.Duration `mapstructure:"tx_ttl"`
+}
+
+// DefaultPruneConfig returns a default configuration for the Pruner, which
+// prunes nothing.
+func DefaultPruneConfig() PruneConfig {
+	return PruneConfig{
+		Mode:       PruneNothing,
+		RetainSeqs: 100000,
+		Interval:   time.Minute,
+	}
+}
+
+// ValidateBasic performs basic validation (checking param bounds, etc.) and
+// returns an error if any check fails.
+func (cfg PruneConfig) ValidateBasic() error {
+	if cfg.Mode < PruneNothing || cfg.Mode > PruneAll {
+		return fmt.Errorf("unknown prune mode %d", cfg.Mode)
+	}
+	if cfg.RetainSeqs < 0 {
+		return errors.New("retain_seqs can't be negative")
+	}
+	if cfg.RetainHeights < 0 {
+		return errors.New("retain_heights can't be negative")
+	}
+	if cfg.Mode != PruneNothing && cfg.RetainSeqs == 0 && cfg.RetainHeights == 0 {
+		return errors.New("pruning needs a retention window")
+	}
+	if cfg.TxTTL < 0 {
+		return errors.New("tx_ttl can't be negative")
+	}
+	if cfg.Interval <= 0 {
+		return errors.New("interval must be positive")
+	}
+	return nil
+}
+
+// ErrTxPruned is returned for the txs the Pruner dropped, below the pruned
+// base of the commit log.
+type ErrTxPruned struct {
+	Seq  int64
+	Base int64
+}
+
+func (e ErrTxPruned) Error() string {
+	return fmt.Sprintf("tx at commit seq %d was pruned, the pruned base is %d", e.Seq, e.Base)
+}
+
+// IsErrTxPruned returns true if err is an ErrTxPruned.
+func IsErrTxPruned(err error) bool {
+	_, ok := err.(ErrTxPruned)
+	return ok
+}
+
+//-----------------------------------------------------------------------------
+
+// Pruner drops the txs out of the retention window from a TxStore in the
+// background, oldest commit first. It holds no TxStore lock while deleting,
+// SaveTx is never blocked.
+type Pruner struct {
+	cmn.BaseService
+
+	ts     *TxStore
+	config PruneConfig
+}
+
+// NewPruner returns a Pruner for the TxStore.
+func NewPruner(ts *TxStore, config PruneConfig) *Pruner {
+	p := &Pruner{
+		ts:     ts,
+		config: config,
+	}
+	p.BaseService = *cmn.NewBaseService(nil, "TxStorePruner", p)
+	return p
+}
+
+// OnStart implements cmn.Service.
+func (p *Pruner) OnStart() error {
+	if err := p.config.ValidateBasic(); err != nil {
+		return err
+	}
+	if p.config.Mode == PruneNothing {
+		return nil
+	}
+	go p.pruneRoutine()
+	return nil
+}
+
+func (p *Pruner) pruneRoutine() {
+	ticker := time.NewTicker(p.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			base := p.ts.PruneBase()
+			if pruned := p.Prune(); pruned > 0 {
+				p.Logger.Info("Pruned txs", "count", pruned, "from", base+1, "to", base+pruned)
+			}
+		case <-p.Quit():
+			return
+		}
+	}
+}
+
+// Prune drops the txs out of the retention window and returns their count.
+// It stops at the first tx to keep, so the pruned txs are always a prefix of
+// the commit log.
+func (p *Pruner) Prune() int64 {
+	var pruned int64
+	for {
+		n := p.pruneBatch()
+		pruned += n
+		if n < pruneBatchSize {
+			return pruned
+		}
+		select {
+		case <-p.Quit():
+			return pruned
+		default:
+		}
+	}
+}
+
+// pruneBatch drops at most pruneBatchSize txs and moves the pruned base past
+// them in the same write.
+func (p *Pruner) pruneBatch() int64 {
+	base := p.ts.PruneBase()
+	lastSeq := p.ts.CommitSeq()
+	height := p.ts.Height()
+
+	batch := p.ts.db.NewBatch()
+	defer batch.Close()
+
+	seq := base + 1
+	for ; seq <= lastSeq && seq <= base+pruneBatchSize; seq++ {
+		if p.config.RetainSeqs > 0 && seq > lastSeq-p.config.RetainSeqs {
+			break
+		}
+		committed, err := p.ts.LoadCommittedTx(seq)
+		if err != nil {
+			// Keep it and retry on the next run
+			p.Logger.Error("Error loading tx to prune", "seq", seq, "err", err)
+			break
+		}
+		if committed == nil {
+			// Pruned by an earlier run which crashed before moving the base
+			continue
+		}
+		if p.config.RetainHeights > 0 && committed.Height > height-p.config.RetainHeights {
+			break
+		}
+		p.pruneTx(batch, committed)
+	}
+	if seq == base+1 {
+		return 0
+	}
+
+	newBase := seq - 1
+	batch.Set(pruneBaseKey, cdc.MustMarshalBinaryBare(newBase))
+	batch.WriteSync()
+	p.ts.setPruneBase(newBase)
+	return newBase - base
+}
+
+func (p *Pruner) pruneTx(batch dbm.Batch, committed *CommittedTx) {
+	batch.Delete(calcTxRejectVotesKey(committed.TxHash))
+	if p.config.Mode != PruneAll {
+		return
+	}
+	// The commit seq of the tx stays, for HasTx
+	batch.Delete(calcTxCommitKey(committed.TxHash))
+	batch.Delete(calcTxCertificateKey(committed.TxHash))
+	batch.Delete(calcCommitLogKey(committed.Seq))
+	if committed.Commit != nil {
+		unindexCommit(batch, committed.Seq, committed.Commit)
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+var pruneBaseKey = []byte("txPruneBase")
+
+func loadPruneBase(db dbm.DB) int64 {
+	bz := db.Get(pruneBaseKey)
+	if len(bz) == 0 {
+		return 0
+	}
+	var base int64
+	if err := cdc.UnmarshalBinaryBare(bz, &base); err != nil {
+		panic(cmn.ErrorWrap(err, "Error reading prune base"))
+	}
+	return base
+}
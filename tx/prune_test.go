@@ -0,0 +1,88 @@
+package tx
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func saveTestTxs(ts *TxStore, n int) {
+	for i := 1; i <= n; i++ {
+		ts.SaveTx(makeCommittedTxWithReject(fmt.Sprintf("0x%d", i)))
+	}
+}
+
+func TestPruneConfigValidateBasic(t *testing.T) {
+	assert.NoError(t, DefaultPruneConfig().ValidateBasic())
+
+	cfg := DefaultPruneConfig()
+	cfg.Mode = PruneAll
+	cfg.RetainSeqs = 0
+	assert.Error(t, cfg.ValidateBasic())
+	cfg.RetainHeights = 10
+	assert.NoError(t, cfg.ValidateBasic())
+	cfg.Interval = 0
+	assert.Error(t, cfg.ValidateBasic())
+}
+
+func TestPrunerVoteSets(t *testing.T) {
+	ts, db := freshBlockStore()
+	saveTestTxs(ts, 5)
+
+	p := NewPruner(ts, PruneConfig{Mode: PruneVoteSets, RetainSeqs: 2})
+	assert.Equal(t, int64(3), p.Prune())
+	assert.Equal(t, int64(3), ts.PruneBase())
+	// Nothing more to prune
+	assert.Equal(t, int64(0), p.Prune())
+
+	// The reject votes are gone, the commit is kept
+	assert.Nil(t, ts.LoadTxRejectVotes("0x1"))
+	commit, err := ts.LookupTxCommit("0x1")
+	require.NoError(t, err)
+	assert.NotNil(t, commit)
+	assert.True(t, ts.HasTx("0x1"))
+	assert.NotNil(t, ts.LoadTxRejectVotes("0x4"))
+
+	// Unknown txs are not pruned ones
+	commit, err = ts.LookupTxCommit("0x9")
+	assert.NoError(t, err)
+	assert.Nil(t, commit)
+
+	// The base survives a restart
+	assert.Equal(t, int64(3), NewTxStore(db).PruneBase())
+}
+
+func TestPrunerAll(t *testing.T) {
+	ts, _ := freshBlockStore()
+	saveTestTxs(ts, 5)
+
+	p := NewPruner(ts, PruneConfig{Mode: PruneAll, RetainSeqs: 2})
+	assert.Equal(t, int64(3), p.Prune())
+
+	_, err := ts.LookupTxCommit("0x2")
+	assert.Equal(t, ErrTxPruned{Seq: 2, Base: 3}, err)
+	// Still final, so late votes are ignored
+	assert.True(t, ts.HasTx("0x2"))
+	assert.True(t, ts.IsFinal("0x2"))
+
+	_, err = ts.LoadCommittedTxs(0, 10)
+	assert.True(t, IsErrTxPruned(err))
+	commits, err := ts.LoadCommittedTxs(3, 10)
+	require.NoError(t, err)
+	assert.Len(t, commits, 2)
+
+	_, err = ts.NewCommitStream(1).Next(context.Background())
+	assert.True(t, IsErrTxPruned(err))
+	committed, err := ts.NewCommitStream(3).Next(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "0x4", committed.TxHash)
+
+	it := ts.CommitsByHeight(0, 1, nil)
+	defer it.Close()
+	commits, _, err = it.ReadPage(10)
+	require.NoError(t, err)
+	assert.Len(t, commits, 2)
+}
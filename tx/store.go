@@ -1,11 +1,17 @@
 package tx
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"sync"
+	"time"
+
+	"github.com/pkg/errors"
 
 	cmn "github.com/tendermint/tendermint/libs/common"
 	dbm "github.com/tendermint/tendermint/libs/db"
+	ttypes "github.com/tendermint/tendermint/types"
 
 	"github.com/Fantom-foundation/go-txflow/types"
 )
@@ -13,14 +19,21 @@ import (
 /*
 TxStore is a simple low level store for approved transactions.
 
-There are three types of information stored:
- - TxMeta:   Meta information about each tx
- - Tx:       Parts of each tx
- - Commit:   The commit part of each tx, for gossiping votes
+There are seven types of information stored:
+ - TxMeta:     Meta information about each tx
+ - Votes:      The reject votes a tx got before it committed, which its
+               commit leaves out
+ - Commit:     The commit part of each tx, for gossiping votes, as a
+               compact CommitCertificate; some saved before certificates
+               stay full Commits, see MigrateCommits
+ - CommitLog:  The commit sequence of each tx and the tx at each sequence
+ - Index:      The commits by height and by signing validator, see index.go
+ - Resolution: The tick block which ordered a tx that never reached 2/3
+ - Reject:     The reject certificate of a tx more than 1/3 rejected
 
-Currently the commit signatures are duplicated in the Tx as
-well as the Commit.  In the future this may change, perhaps by moving
-the Commit data outside the Block. (TODO)
+Certificates only hold the signers, their timestamps and signatures; the
+validators they were counted against are loaded with the ValidatorsLoader
+given to NewTxStore.
 
 // NOTE: TxStore methods will panic if they encounter errors
 // deserializing loaded data, indicating probable corruption on disk.
@@ -28,18 +41,95 @@ the Commit data outside the Block. (TODO)
 type TxStore struct {
 	db dbm.DB
 
-	mtx    sync.RWMutex
-	height int64
+	mtx        sync.RWMutex
+	height     int64
+	journalSeq int64
+	commitSeq  int64
+	pruneBase  int64
+	// closed and replaced on every new commit, see CommitsUpdated
+	commitsCh chan struct{}
+
+	// the commit group SaveTx calls join, nil until the first one
+	group *commitGroup
+	// the closed group being written, nil once it's synced
+	flushing *commitGroup
+	// the last commit seq handed out, synced or not
+	stagedSeq int64
+	// held by the leader of a group while it writes the group
+	flushMtx      sync.Mutex
+	groupMaxDelay time.Duration
+	groupMaxSize  int
+
+	// reject votes expire after txTTL, if the db supports it
+	txTTL time.Duration
+
+	// the validators which vote at a height, to expand certificates
+	loadValidators ValidatorsLoader
+}
+
+// ValidatorsLoader returns the validators which vote at a height, those of
+// height+1.
+type ValidatorsLoader func(height int64) (*ttypes.ValidatorSet, error)
+
+// TxStoreOption sets an optional parameter on the TxStore.
+type TxStoreOption func(*TxStore)
+
+// TxStoreWithValidatorsLoader sets the validators certificates are made and
+// expanded with. Without it, commits saved as certificates can't be loaded.
+func TxStoreWithValidatorsLoader(loadValidators ValidatorsLoader) TxStoreOption {
+	return func(ts *TxStore) { ts.loadValidators = loadValidators }
 }
 
 // NewTxStore returns a new TxStore with the given DB,
 // initialized to the last height that was committed to the DB.
-func NewTxStore(db dbm.DB) *TxStore {
+func NewTxStore(db dbm.DB, options ...TxStoreOption) *TxStore {
 	bsjson := LoadTxStoreStateJSON(db)
-	return &TxStore{
-		height: bsjson.Height,
-		db:     db,
+	ts := &TxStore{
+		height:     bsjson.Height,
+		journalSeq: loadJournalSeq(db),
+		commitSeq:  bsjson.CommitSeq,
+		stagedSeq:  bsjson.CommitSeq,
+		pruneBase:  loadPruneBase(db),
+		commitsCh:  make(chan struct{}),
+		db:         db,
+
+		groupMaxDelay: DefaultGroupMaxDelay,
+		groupMaxSize:  DefaultGroupMaxSize,
 	}
+	for _, option := range options {
+		option(ts)
+	}
+	return ts
+}
+
+// SetTxTTL - sets how long the reject votes of a tx are kept after it is
+// saved. Only the reject votes expire, LoadTxRejectVotes then returns nil;
+// the commit and commit seq of the tx stay. It takes effect on a db whose
+// batches implement TTLSetter, and is ignored otherwise. A zero ttl keeps
+// reject votes.
+func (ts *TxStore) SetTxTTL(ttl time.Duration) {
+	ts.mtx.Lock()
+	defer ts.mtx.Unlock()
+	ts.txTTL = ttl
+}
+
+// TTLSetter is implemented by the batches of the dbs which can expire
+// values, like db.BadgerDB.
+type TTLSetter interface {
+	SetWithTTL(key, value []byte, ttl time.Duration)
+}
+
+// SetGroupCommit - sets how long the first SaveTx of a commit group waits for
+// others to join before it writes the group, and the most txs in a group.
+// A maxSize of 1 syncs every tx on its own.
+func (ts *TxStore) SetGroupCommit(maxDelay time.Duration, maxSize int) {
+	if maxSize < 1 {
+		panic(fmt.Sprintf("TxStore commit groups need at least one tx, got %d", maxSize))
+	}
+	ts.mtx.Lock()
+	defer ts.mtx.Unlock()
+	ts.groupMaxDelay = maxDelay
+	ts.groupMaxSize = maxSize
 }
 
 // Height returns the last known contiguous block height.
@@ -49,88 +139,514 @@ func (ts *TxStore) Height() int64 {
 	return ts.height
 }
 
-// LoadTx returns the tx for the given hash.
-// If no tx is found for the given hash, it returns nil.
-func (ts *TxStore) LoadTx(txHash string) *types.TxVoteSet {
-	var tx = new(types.TxVoteSet)
-	bz := ts.db.Get(calcTxKey(txHash))
+// LoadTxRejectVotes returns the reject votes the tx got before it committed,
+// which its commit leaves out. It returns nil if there were none, or they
+// expired or were pruned.
+func (ts *TxStore) LoadTxRejectVotes(txHash string) *types.Commit {
+	var votes = new(types.Commit)
+	bz := ts.db.Get(calcTxRejectVotesKey(txHash))
 	if len(bz) == 0 {
 		return nil
 	}
-	err := cdc.UnmarshalBinaryBare(bz, tx)
+	err := cdc.UnmarshalBinaryBare(bz, votes)
 	if err != nil {
-		panic(cmn.ErrorWrap(err, "Error reading tx meta"))
+		panic(cmn.ErrorWrap(err, "Error reading tx reject votes"))
+	}
+	return votes
+}
+
+// HasTx returns true if a tx with the given hash was committed, pruned or
+// not.
+func (ts *TxStore) HasTx(txHash string) bool {
+	return ts.db.Has(calcCommitSeqKey(txHash))
+}
+
+// LookupTxCommit is LoadTxCommit, but returns ErrTxPruned if the Pruner
+// dropped the commit.
+func (ts *TxStore) LookupTxCommit(txHash string) (*types.Commit, error) {
+	commit, err := ts.LoadTxCommit(txHash)
+	if err != nil || commit != nil {
+		return commit, err
+	}
+	return nil, ts.checkPruned(txHash)
+}
+
+func (ts *TxStore) checkPruned(txHash string) error {
+	seq := ts.LoadCommitSeq(txHash)
+	if base := ts.PruneBase(); seq > 0 && seq <= base {
+		return ErrTxPruned{Seq: seq, Base: base}
 	}
-	return tx
+	return nil
+}
+
+// PruneBase returns the commit sequence of the last tx the Pruner dropped,
+// or 0 if it dropped none.
+func (ts *TxStore) PruneBase() int64 {
+	ts.mtx.RLock()
+	defer ts.mtx.RUnlock()
+	return ts.pruneBase
+}
+
+func (ts *TxStore) setPruneBase(base int64) {
+	ts.mtx.Lock()
+	defer ts.mtx.Unlock()
+	ts.pruneBase = base
 }
 
-// LoadTxCommit returns the Commit for the given txHash.
+// LoadTxCommit returns the Commit for the given txHash, or nil if the tx
+// has none.
 // This commit consists of the +2/3 and other votes for tx,
-func (ts *TxStore) LoadTxCommit(txHash string) *types.Commit {
+//
+// A commit stored as a certificate is expanded with the validators of the
+// ValidatorsLoader, its votes have an empty TxKey. It fails if the TxStore
+// has no ValidatorsLoader or the validators can't be loaded.
+func (ts *TxStore) LoadTxCommit(txHash string) (*types.Commit, error) {
 	var commit = new(types.Commit)
 	bz := ts.db.Get(calcTxCommitKey(txHash))
 	if len(bz) == 0 {
-		return nil
+		return ts.loadCertificateCommit(txHash)
 	}
 	err := cdc.UnmarshalBinaryBare(bz, commit)
 	if err != nil {
 		panic(cmn.ErrorWrap(err, "Error reading block commit"))
 	}
-	return commit
+	return commit, nil
 }
 
-// SaveTx persists the given tx to the underlying db.
+func (ts *TxStore) loadCertificateCommit(txHash string) (*types.Commit, error) {
+	cert := ts.LoadTxCertificate(txHash)
+	if cert == nil {
+		return nil, nil
+	}
+	if ts.loadValidators == nil {
+		return nil, errors.New("TxStore needs a validators loader to expand certificates")
+	}
+	valSet, err := ts.loadValidators(cert.Height)
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading the validators of certificate height %d", cert.Height)
+	}
+	commit, err := cert.ToCommit(valSet)
+	if err != nil {
+		return nil, errors.Wrapf(err, "expanding the certificate of tx %s", txHash)
+	}
+	return commit, nil
+}
+
+// LoadTxVoteSet rebuilds the vote set of a committed tx: the votes of its
+// commit and the reject votes saved beside it, counted against the
+// validators of the ValidatorsLoader. The votes were verified before they
+// were saved and are not verified again. It returns nil if the tx has no
+// commit, and ErrTxPruned if the Pruner dropped it.
+func (ts *TxStore) LoadTxVoteSet(chainID string, txHash string) (*types.TxVoteSet, error) {
+	commit, err := ts.LookupTxCommit(txHash)
+	if err != nil || commit == nil {
+		return nil, err
+	}
+	if ts.loadValidators == nil {
+		return nil, errors.New("TxStore needs a validators loader to rebuild vote sets")
+	}
+	height := commit.Height()
+	if cert := ts.LoadTxCertificate(txHash); cert != nil {
+		height = cert.Height
+	}
+	valSet, err := ts.loadValidators(height)
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading the validators of height %d", height)
+	}
+	var txKey [sha256.Size]byte
+	hashBytes, err := hex.DecodeString(txHash)
+	if err != nil || len(hashBytes) != len(txKey) {
+		return nil, errors.Errorf("invalid tx hash %q", txHash)
+	}
+	copy(txKey[:], hashBytes)
+
+	sigs := commit.Commits
+	if rejects := ts.LoadTxRejectVotes(txHash); rejects != nil {
+		sigs = append(sigs[:len(sigs):len(sigs)], rejects.Commits...)
+	}
+	voteSet := types.NewTxVoteSet(chainID, height, txHash, txKey, valSet)
+	for _, cs := range sigs {
+		if cs == nil {
+			continue
+		}
+		vote := types.TxVote(*cs)
+		vote.TxKey = txKey
+		if _, err := voteSet.AddPreverifiedVote(&vote); err != nil {
+			return nil, errors.Wrapf(err, "rebuilding the vote set of tx %s", txHash)
+		}
+	}
+	return voteSet, nil
+}
+
+// LoadTxCertificate returns the certificate of the tx's commit, or nil if
+// the commit is not stored as a certificate.
+func (ts *TxStore) LoadTxCertificate(txHash string) *types.CommitCertificate {
+	var cert = new(types.CommitCertificate)
+	bz := ts.db.Get(calcTxCertificateKey(txHash))
+	if len(bz) == 0 {
+		return nil
+	}
+	err := cdc.UnmarshalBinaryBare(bz, cert)
+	if err != nil {
+		panic(cmn.ErrorWrap(err, "Error reading tx certificate"))
+	}
+	return cert
+}
+
+// SaveTx persists the given tx to the underlying db and appends it to the
+// commit log. Saving a tx again keeps its commit sequence.
+//
+// Concurrent calls are written and synced together, see commitGroup. SaveTx
+// returns once the tx is synced.
 func (ts *TxStore) SaveTx(tx *types.TxVoteSet) {
 	if tx == nil {
 		panic("TxStore can only save a non-nil tx")
 	}
-	height := tx.Height()
+	// Encode outside of the lock
+	var rejectBytes []byte
+	if rejects := rejectVotes(tx); len(rejects.Commits) > 0 {
+		rejectBytes = cdc.MustMarshalBinaryBare(rejects)
+	}
+	commit := tx.MakeCommit()
+	cert, err := tx.MakeCertificate()
+	if err != nil {
+		panic(cmn.ErrorWrap(err, "Error making tx certificate"))
+	}
+	certBytes := cert.Bytes()
 
-	// Save tx
-	txBytes := cdc.MustMarshalBinaryBare(tx)
-	ts.db.Set(calcTxKey(tx.TxHash), txBytes)
+	ts.mtx.Lock()
+	group, leader := ts.joinGroup()
+	for len(group.seqs) >= ts.groupMaxSize {
+		// Full, wait for the next one
+		ts.mtx.Unlock()
+		<-group.closed
+		ts.mtx.Lock()
+		group, leader = ts.joinGroup()
+	}
+	seq, prevCommit := group.seqs[tx.TxHash], group.commits[tx.TxHash]
+	if seq == 0 && ts.flushing != nil {
+		// Saved by the group being written, not in the db yet
+		seq, prevCommit = ts.flushing.seqs[tx.TxHash], ts.flushing.commits[tx.TxHash]
+	}
+	if seq == 0 {
+		seq = ts.LoadCommitSeq(tx.TxHash)
+		if seq > 0 {
+			prevCommit = ts.mustLoadTxCommit(tx.TxHash)
+		}
+	}
+	if seq == 0 {
+		ts.stagedSeq++
+		seq = ts.stagedSeq
+	}
+	batch := group.batch
 
-	// Save tx commit (duplicate and separate from the Block)
-	txCommitBytes := cdc.MustMarshalBinaryBare(tx.MakeCommit())
-	ts.db.Set(calcTxCommitKey(tx.TxHash), txCommitBytes)
+	// Save the votes the commit leaves out
+	switch ttlBatch, ok := batch.(TTLSetter); {
+	case rejectBytes == nil:
+		batch.Delete(calcTxRejectVotesKey(tx.TxHash))
+	case ok && ts.txTTL > 0:
+		ttlBatch.SetWithTTL(calcTxRejectVotesKey(tx.TxHash), rejectBytes, ts.txTTL)
+	default:
+		batch.Set(calcTxRejectVotesKey(tx.TxHash), rejectBytes)
+	}
 
-	// Save new TxStoreStateJSON descriptor
-	TxStoreStateJSON{Height: height}.Save(ts.db)
+	// Save tx commit, replacing a commit saved before certificates
+	batch.Delete(calcTxCommitKey(tx.TxHash))
+	batch.Set(calcTxCertificateKey(tx.TxHash), certBytes)
 
-	// Done!
-	ts.mtx.Lock()
-	ts.height = height
+	// Append to the commit log
+	batch.Set(calcCommitLogKey(seq), []byte(tx.TxHash))
+	batch.Set(calcCommitSeqKey(tx.TxHash), cdc.MustMarshalBinaryBare(seq))
+	if prevCommit != nil {
+		// Saved again, maybe at a new height
+		unindexCommit(batch, seq, prevCommit)
+	}
+	indexCommit(batch, seq, commit)
+
+	group.add(tx.TxHash, tx.Height(), seq, commit, ts.groupMaxSize)
 	ts.mtx.Unlock()
 
-	// Flush
-	ts.db.SetSync(nil, nil)
+	if leader {
+		ts.flushGroup(group)
+	}
+	<-group.done
+}
+
+// mustLoadTxCommit is LoadTxCommit, but panics if the commit can't be
+// loaded.
+func (ts *TxStore) mustLoadTxCommit(txHash string) *types.Commit {
+	commit, err := ts.LoadTxCommit(txHash)
+	if err != nil {
+		panic(cmn.ErrorWrap(err, "Error loading tx commit"))
+	}
+	return commit
+}
+
+// rejectVotes returns the reject votes of the vote set, which its commit
+// leaves out.
+func rejectVotes(tx *types.TxVoteSet) *types.Commit {
+	var sigs []*types.CommitSig
+	for _, vote := range tx.GetVotes() {
+		if vote.IsReject() {
+			sigs = append(sigs, vote.CommitSig())
+		}
+	}
+	return types.NewCommit(tx.TxHash, sigs)
+}
+
+// CommitSeq returns the commit sequence of the last tx committed on the fast
+// path, or 0 if there is none.
+func (ts *TxStore) CommitSeq() int64 {
+	ts.mtx.RLock()
+	defer ts.mtx.RUnlock()
+	return ts.commitSeq
+}
+
+// CommitsUpdated returns a channel which is closed once a tx is appended to
+// the commit log. Get it before reading the log so no commit is missed.
+func (ts *TxStore) CommitsUpdated() <-chan struct{} {
+	ts.mtx.RLock()
+	defer ts.mtx.RUnlock()
+	return ts.commitsCh
+}
+
+// LoadCommitSeq returns the commit sequence of the tx, or 0 if it was not
+// committed on the fast path.
+func (ts *TxStore) LoadCommitSeq(txHash string) int64 {
+	bz := ts.db.Get(calcCommitSeqKey(txHash))
+	if len(bz) == 0 {
+		return 0
+	}
+	var seq int64
+	err := cdc.UnmarshalBinaryBare(bz, &seq)
+	if err != nil {
+		panic(cmn.ErrorWrap(err, "Error reading commit seq"))
+	}
+	return seq
+}
+
+// LoadCommittedTx returns the tx at the given commit sequence.
+// If there is no such tx, it returns nil. It fails if the commit of the tx
+// can't be loaded, see LoadTxCommit.
+func (ts *TxStore) LoadCommittedTx(seq int64) (*CommittedTx, error) {
+	bz := ts.db.Get(calcCommitLogKey(seq))
+	if len(bz) == 0 {
+		return nil, nil
+	}
+	txHash := string(bz)
+	commit, err := ts.LoadTxCommit(txHash)
+	if err != nil {
+		return nil, err
+	}
+	committed := &CommittedTx{
+		Seq:    seq,
+		TxHash: txHash,
+		Commit: commit,
+	}
+	if committed.Commit != nil {
+		committed.Height = committed.Commit.Height()
+	}
+	return committed, nil
+}
+
+// LoadCommittedTxs returns at most limit txs of the commit log, in order,
+// starting right after the cursor. A cursor of 0 starts at the first commit.
+// It returns ErrTxPruned if the log right after the cursor was pruned.
+func (ts *TxStore) LoadCommittedTxs(cursor int64, limit int) ([]*CommittedTx, error) {
+	last := ts.CommitSeq()
+	var txs []*CommittedTx
+	for seq := cursor + 1; seq <= last && len(txs) < limit; seq++ {
+		committed, err := ts.LoadCommittedTx(seq)
+		if err != nil {
+			return nil, err
+		}
+		if committed == nil {
+			if base := ts.PruneBase(); seq <= base {
+				return nil, ErrTxPruned{Seq: seq, Base: base}
+			}
+			continue
+		}
+		txs = append(txs, committed)
+	}
+	return txs, nil
+}
+
+// LoadTxResolution returns the height of the tick block which ordered the tx
+// after it failed to commit on the fast path, or 0 if there is none.
+func (ts *TxStore) LoadTxResolution(txHash string) int64 {
+	bz := ts.db.Get(calcTxResolutionKey(txHash))
+	if len(bz) == 0 {
+		return 0
+	}
+	var height int64
+	err := cdc.UnmarshalBinaryBare(bz, &height)
+	if err != nil {
+		panic(cmn.ErrorWrap(err, "Error reading tx resolution"))
+	}
+	return height
+}
+
+// LoadTxReject returns the reject certificate of the tx, the reject votes of
+// more than 1/3 of the voting power, or nil if the tx was not rejected.
+func (ts *TxStore) LoadTxReject(txHash string) *types.Commit {
+	var commit = new(types.Commit)
+	bz := ts.db.Get(calcTxRejectKey(txHash))
+	if len(bz) == 0 {
+		return nil
+	}
+	err := cdc.UnmarshalBinaryBare(bz, commit)
+	if err != nil {
+		panic(cmn.ErrorWrap(err, "Error reading tx reject"))
+	}
+	return commit
+}
+
+// SaveTxReject persists the reject certificate of the given tx.
+func (ts *TxStore) SaveTxReject(tx *types.TxVoteSet) {
+	if tx == nil {
+		panic("TxStore can only save a non-nil tx")
+	}
+	ts.db.SetSync(calcTxRejectKey(tx.TxHash), cdc.MustMarshalBinaryBare(tx.MakeRejectCommit()))
+}
+
+// IsFinal returns true if the tx was committed on the fast path, rejected,
+// or ordered by a tick block. Either way no more votes count for it.
+func (ts *TxStore) IsFinal(txHash string) bool {
+	return ts.HasTx(txHash) ||
+		ts.db.Has(calcTxRejectKey(txHash)) ||
+		ts.db.Has(calcTxResolutionKey(txHash))
+}
+
+// SaveTxResolutions records that the tick block at height ordered the txs.
+func (ts *TxStore) SaveTxResolutions(height int64, txHashes []string) {
+	if len(txHashes) == 0 {
+		return
+	}
+	batch := ts.db.NewBatch()
+	defer batch.Close()
+	heightBytes := cdc.MustMarshalBinaryBare(height)
+	for _, txHash := range txHashes {
+		batch.Set(calcTxResolutionKey(txHash), heightBytes)
+	}
+	batch.WriteSync()
+}
+
+// LastJournalSeq returns the sequence number of the last journal entry,
+// or 0 if the journal is empty.
+func (ts *TxStore) LastJournalSeq() int64 {
+	ts.mtx.RLock()
+	defer ts.mtx.RUnlock()
+	return ts.journalSeq
+}
+
+// LoadJournalEntry returns the journal entry with the given sequence number.
+// If there is no such entry, it returns nil.
+func (ts *TxStore) LoadJournalEntry(seq int64) *types.TxJournalEntry {
+	var entry = new(types.TxJournalEntry)
+	bz := ts.db.Get(calcJournalKey(seq))
+	if len(bz) == 0 {
+		return nil
+	}
+	err := cdc.UnmarshalBinaryBare(bz, entry)
+	if err != nil {
+		panic(cmn.ErrorWrap(err, "Error reading journal entry"))
+	}
+	return entry
+}
+
+// SaveJournalEntry persists the given journal entry and flushes it to disk.
+// Entries must be saved in sequence order, an entry may be saved again to
+// record its AppHash.
+func (ts *TxStore) SaveJournalEntry(entry *types.TxJournalEntry) {
+	ts.mtx.Lock()
+	defer ts.mtx.Unlock()
+
+	if entry.Seq != ts.journalSeq && entry.Seq != ts.journalSeq+1 {
+		panic(fmt.Sprintf("TxStore can only journal seq %d or %d, got %d", ts.journalSeq, ts.journalSeq+1, entry.Seq))
+	}
+
+	batch := ts.db.NewBatch()
+	defer batch.Close()
+	batch.Set(calcJournalKey(entry.Seq), cdc.MustMarshalBinaryBare(entry))
+	batch.Set(journalSeqKey, cdc.MustMarshalBinaryBare(entry.Seq))
+	batch.WriteSync()
+
+	ts.journalSeq = entry.Seq
 }
 
 //-----------------------------------------------------------------------------
 
-func calcTxKey(txHash string) []byte {
-	return []byte(fmt.Sprintf("H:%X", txHash))
+func calcJournalKey(seq int64) []byte {
+	return []byte(fmt.Sprintf("J:%v", seq))
+}
+
+var journalSeqKey = []byte("txJournalSeq")
+
+func loadJournalSeq(db dbm.DB) int64 {
+	bz := db.Get(journalSeqKey)
+	if len(bz) == 0 {
+		return 0
+	}
+	var seq int64
+	if err := cdc.UnmarshalBinaryBare(bz, &seq); err != nil {
+		panic(cmn.ErrorWrap(err, "Error reading journal seq"))
+	}
+	return seq
+}
+
+//-----------------------------------------------------------------------------
+
+func calcTxRejectVotesKey(txHash string) []byte {
+	return []byte(fmt.Sprintf("V:%X", txHash))
 }
 
 func calcTxCommitKey(txHash string) []byte {
 	return []byte(fmt.Sprintf("C:%X", txHash))
 }
 
+func calcTxResolutionKey(txHash string) []byte {
+	return []byte(fmt.Sprintf("R:%X", txHash))
+}
+
+func calcTxRejectKey(txHash string) []byte {
+	return []byte(fmt.Sprintf("X:%X", txHash))
+}
+
+func calcTxCertificateKey(txHash string) []byte {
+	return []byte(fmt.Sprintf("K:%X", txHash))
+}
+
+func calcCommitLogKey(seq int64) []byte {
+	// zero padded, so the log iterates in sequence order
+	return []byte(fmt.Sprintf("S:%020d", seq))
+}
+
+func calcCommitSeqKey(txHash string) []byte {
+	return []byte(fmt.Sprintf("Q:%X", txHash))
+}
+
 //-----------------------------------------------------------------------------
 
 var txStoreKey = []byte("txStore")
 
+// TxStoreStateJSON is the TxStore descriptor. Height is the vote height of
+// the last tx saved, CommitSeq the length of the commit log.
 type TxStoreStateJSON struct {
-	Height int64 `json:"height"`
+	Height    int64 `json:"height"`
+	CommitSeq int64 `json:"commit_seq"`
 }
 
 // Save persists the txStore state to the database as JSON.
 func (tsj TxStoreStateJSON) Save(db dbm.DB) {
+	db.SetSync(txStoreKey, tsj.mustMarshal())
+}
+
+func (tsj TxStoreStateJSON) mustMarshal() []byte {
 	bytes, err := cdc.MarshalJSON(tsj)
 	if err != nil {
 		panic(fmt.Sprintf("Could not marshal state bytes: %v", err))
 	}
-	db.SetSync(txStoreKey, bytes)
+	return bytes
 }
 
 // LoadTxStoreStateJSON returns the TxStoreStateJSON as loaded from disk.
@@ -2,9 +2,13 @@ package tx
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"runtime/debug"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -16,6 +20,7 @@ import (
 	dbm "github.com/tendermint/tendermint/libs/db"
 	"github.com/tendermint/tendermint/libs/log"
 	sm "github.com/tendermint/tendermint/state"
+	ttypes "github.com/tendermint/tendermint/types"
 
 	"github.com/Fantom-foundation/go-txflow/types"
 )
@@ -86,18 +91,63 @@ func TestNewTxStore(t *testing.T) {
 
 func freshBlockStore() (*TxStore, db.DB) {
 	db := db.NewMemDB()
-	return NewTxStore(db), db
+	ts := NewTxStore(db, TxStoreWithValidatorsLoader(loadTestValidators))
+	return ts, db
+}
+
+func loadTestValidators(int64) (*ttypes.ValidatorSet, error) {
+	return testValSet, nil
 }
 
 var (
 	state sm.State
 	tx    *types.TxVoteSet
+
+	// the validators signing makeCommittedTx
+	testPVs    []*types.MockPV
+	testValSet *ttypes.ValidatorSet
 )
 
+// makeCommittedTx returns a vote set signed by all testPVs at height 1.
+func makeCommittedTx(txHash string) *types.TxVoteSet {
+	return signTestTx(txHash, len(testPVs))
+}
+
+// makeCommittedTxWithReject is makeCommittedTx, but the last of the testPVs
+// rejects the tx.
+func makeCommittedTxWithReject(txHash string) *types.TxVoteSet {
+	return signTestTx(txHash, len(testPVs)-1)
+}
+
+// signTestTx returns a vote set at height 1 the first accepts testPVs
+// accepted and the others rejected.
+func signTestTx(txHash string, accepts int) *types.TxVoteSet {
+	voteSet := types.NewTxVoteSet("test_chain_id", 1, txHash, types.TxKey([]byte(txHash)), testValSet)
+	for i, pv := range testPVs {
+		vote := types.NewTxVote(1, txHash, types.TxKey([]byte(txHash)), pv.GetPubKey().Address())
+		if i >= accepts {
+			vote.Code = 1
+		}
+		if err := pv.SignTxVote("test_chain_id", &vote); err != nil {
+			panic(err)
+		}
+		if _, err := voteSet.AddVote(&vote); err != nil {
+			panic(err)
+		}
+	}
+	return voteSet
+}
+
 func TestMain(m *testing.M) {
 	var cleanup cleanupFunc
 	state, _, cleanup = makeStateAndTxStore(log.NewTMLogger(new(bytes.Buffer)))
 	tx = makeTx("0x1", state, new(types.Commit))
+	testPVs = []*types.MockPV{types.NewMockPV(), types.NewMockPV(), types.NewMockPV(), types.NewMockPV()}
+	vals := make([]*ttypes.Validator, len(testPVs))
+	for i, pv := range testPVs {
+		vals[i] = ttypes.NewValidator(pv.GetPubKey(), 1)
+	}
+	testValSet = ttypes.NewValidatorSet(vals)
 	code := m.Run()
 	cleanup()
 	os.Exit(code)
@@ -113,7 +163,7 @@ func TestTxStoreSaveLoadBlock(t *testing.T) {
 	// check there are no tx at various heights
 	noTxHashes := []string{"0", "-1", "100", "1000", "2"}
 	for i, hash := range noTxHashes {
-		if g := ts.LoadTx(hash); g != nil {
+		if g := ts.LoadTxRejectVotes(hash); g != nil {
 			t.Errorf("#%d: hash(%X) got a tx; want nil", i, hash)
 		}
 	}
@@ -156,3 +206,351 @@ func doFn(fn func() (interface{}, error)) (res interface{}, err error, panicErr
 	res, err = fn()
 	return res, err, panicErr
 }
+
+func TestTxStoreJournal(t *testing.T) {
+	ts, db := freshBlockStore()
+	require.Equal(t, int64(0), ts.LastJournalSeq())
+	require.Nil(t, ts.LoadJournalEntry(1))
+
+	entry := &types.TxJournalEntry{Seq: 1, Height: 5, TxHash: "0x1", Tx: []byte("tx1")}
+	ts.SaveJournalEntry(entry)
+	require.Equal(t, int64(1), ts.LastJournalSeq())
+	assert.False(t, ts.LoadJournalEntry(1).IsApplied())
+
+	// Complete the intent
+	entry.AppHash = []byte{0x01}
+	ts.SaveJournalEntry(entry)
+	loaded := ts.LoadJournalEntry(1)
+	require.NotNil(t, loaded)
+	assert.True(t, loaded.IsApplied())
+	assert.Equal(t, entry.Tx, loaded.Tx)
+	assert.Equal(t, entry.AppHash, loaded.AppHash)
+
+	// Entries must not skip a sequence number
+	assert.Panics(t, func() {
+		ts.SaveJournalEntry(&types.TxJournalEntry{Seq: 3, Height: 5})
+	})
+
+	// The sequence survives a restart
+	ts = NewTxStore(db)
+	assert.Equal(t, int64(1), ts.LastJournalSeq())
+}
+
+func TestTxStoreResolutions(t *testing.T) {
+	ts, _ := freshBlockStore()
+	assert.Equal(t, int64(0), ts.LoadTxResolution("0x1"))
+	assert.False(t, ts.IsFinal("0x1"))
+
+	ts.SaveTxResolutions(7, []string{"0x1", "0x2"})
+	assert.Equal(t, int64(7), ts.LoadTxResolution("0x1"))
+	assert.Equal(t, int64(7), ts.LoadTxResolution("0x2"))
+	assert.True(t, ts.IsFinal("0x2"))
+	// A resolved tx has no fast-path commit
+	assert.False(t, ts.HasTx("0x1"))
+	commit, err := ts.LoadTxCommit("0x1")
+	assert.NoError(t, err)
+	assert.Nil(t, commit)
+}
+
+func TestTxStoreCertificates(t *testing.T) {
+	ts, db := freshBlockStore()
+	voteSet := makeCommittedTx("0x1")
+	ts.SaveTx(voteSet)
+	assert.Nil(t, db.Get(calcTxCommitKey("0x1")))
+	cert := ts.LoadTxCertificate("0x1")
+	require.NotNil(t, cert)
+	assert.NoError(t, cert.Verify(testValSet))
+	commit, err := ts.LoadTxCommit("0x1")
+	require.NoError(t, err)
+	require.NotNil(t, commit)
+	assert.Len(t, commit.Commits, len(testPVs))
+	// The vote set itself is not kept
+	assert.Nil(t, ts.LoadTxRejectVotes("0x1"))
+	expanded, err := types.NewCommitCertificate("test_chain_id", commit, testValSet)
+	require.NoError(t, err)
+	assert.True(t, cert.Equals(expanded))
+
+	// Without validators a certificate can't be expanded
+	_, err = NewTxStore(db).LoadTxCommit("0x1")
+	assert.Error(t, err)
+
+	// A commit saved before certificates, with its vote set
+	legacySet := makeCommittedTx("0x2")
+	db.SetSync(calcTxCommitKey("0x2"), cdc.MustMarshalBinaryBare(legacySet.MakeCommit()))
+	db.SetSync([]byte(fmt.Sprintf("H:%X", "0x2")), cdc.MustMarshalBinaryBare(legacySet))
+	assert.Nil(t, ts.LoadTxCertificate("0x2"))
+	commit, err = ts.LoadTxCommit("0x2")
+	require.NoError(t, err)
+	require.NotNil(t, commit)
+
+	migrated, err := ts.MigrateCommits("test_chain_id")
+	require.NoError(t, err)
+	assert.Equal(t, 1, migrated)
+	assert.Nil(t, db.Get(calcTxCommitKey("0x2")))
+	assert.Nil(t, db.Get([]byte(fmt.Sprintf("H:%X", "0x2"))))
+	cert = ts.LoadTxCertificate("0x2")
+	require.NotNil(t, cert)
+	assert.NoError(t, cert.Verify(testValSet))
+	commit, err = ts.LoadTxCommit("0x2")
+	require.NoError(t, err)
+	require.NotNil(t, commit)
+	assert.Equal(t, "0x2", commit.TxHash)
+
+	// The migration is done, commits are not scanned again
+	db.SetSync(calcTxCommitKey("0x3"), cdc.MustMarshalBinaryBare(makeCommittedTx("0x3").MakeCommit()))
+	migrated, err = ts.MigrateCommits("test_chain_id")
+	require.NoError(t, err)
+	assert.Equal(t, 0, migrated)
+	assert.NotNil(t, db.Get(calcTxCommitKey("0x3")))
+}
+
+func TestTxStoreCertificateMixedHeights(t *testing.T) {
+	ts, _ := freshBlockStore()
+	// Votes at height 1 carried over to a set at height 2
+	voteSet := makeCommittedTx("0x1").Rebase(2, testValSet)
+	ts.SaveTx(voteSet)
+
+	cert := ts.LoadTxCertificate("0x1")
+	require.NotNil(t, cert)
+	assert.Equal(t, int64(2), cert.Height)
+	assert.Len(t, cert.HeightDeltas, len(testPVs))
+	assert.NoError(t, cert.Verify(testValSet))
+	commit, err := ts.LoadTxCommit("0x1")
+	require.NoError(t, err)
+	require.NotNil(t, commit)
+	assert.Equal(t, int64(1), commit.Height())
+}
+
+func TestTxStoreLoadTxVoteSet(t *testing.T) {
+	ts, _ := freshBlockStore()
+	txHash := types.TxHash([]byte("tx"))
+	ts.SaveTx(makeCommittedTxWithReject(txHash))
+
+	voteSet, err := ts.LoadTxVoteSet("test_chain_id", txHash)
+	require.NoError(t, err)
+	require.NotNil(t, voteSet)
+	assert.True(t, voteSet.HasTwoThirdsMajority())
+	assert.Len(t, voteSet.GetVotes(), len(testPVs))
+	assert.Equal(t, int64(1), voteSet.RejectStake())
+	assert.Equal(t, types.TxKey([]byte("tx")), voteSet.TxKey)
+
+	voteSet, err = ts.LoadTxVoteSet("test_chain_id", types.TxHash([]byte("other")))
+	assert.NoError(t, err)
+	assert.Nil(t, voteSet)
+}
+
+func TestTxStoreRejectVotes(t *testing.T) {
+	ts, _ := freshBlockStore()
+	ts.SaveTx(makeCommittedTxWithReject("0x1"))
+
+	// The certificate holds the accept votes, the reject vote is kept aside
+	cert := ts.LoadTxCertificate("0x1")
+	require.NotNil(t, cert)
+	assert.Equal(t, len(testPVs)-1, len(cert.Signatures))
+	rejects := ts.LoadTxRejectVotes("0x1")
+	require.NotNil(t, rejects)
+	require.Len(t, rejects.Commits, 1)
+	assert.Equal(t, testPVs[len(testPVs)-1].GetPubKey().Address(), rejects.Commits[0].ValidatorAddress)
+}
+
+func TestTxStoreCommitLog(t *testing.T) {
+	ts, db := freshBlockStore()
+	require.Equal(t, int64(0), ts.CommitSeq())
+	committed, err := ts.LoadCommittedTx(1)
+	require.NoError(t, err)
+	require.Nil(t, committed)
+
+	for _, txHash := range []string{"0x1", "0x2", "0x3"} {
+		ts.SaveTx(makeCommittedTx(txHash))
+	}
+	require.Equal(t, int64(3), ts.CommitSeq())
+	assert.Equal(t, int64(2), ts.LoadCommitSeq("0x2"))
+	assert.Equal(t, int64(0), ts.LoadCommitSeq("0x4"))
+
+	// Saving a tx again keeps its place in the log
+	ts.SaveTx(makeCommittedTx("0x1"))
+	assert.Equal(t, int64(3), ts.CommitSeq())
+	assert.Equal(t, int64(1), ts.LoadCommitSeq("0x1"))
+
+	commits, err := ts.LoadCommittedTxs(1, 10)
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	assert.Equal(t, int64(2), commits[0].Seq)
+	assert.Equal(t, "0x2", commits[0].TxHash)
+	assert.NotNil(t, commits[0].Commit)
+	assert.Equal(t, "0x3", commits[1].TxHash)
+	commits, err = ts.LoadCommittedTxs(0, 2)
+	require.NoError(t, err)
+	assert.Len(t, commits, 2)
+	commits, err = ts.LoadCommittedTxs(3, 10)
+	require.NoError(t, err)
+	assert.Empty(t, commits)
+
+	// The log survives a restart
+	ts = NewTxStore(db)
+	assert.Equal(t, int64(3), ts.CommitSeq())
+	assert.Equal(t, int64(3), LoadTxStoreStateJSON(db).CommitSeq)
+}
+
+func TestTxStoreCommitStream(t *testing.T) {
+	ts, _ := freshBlockStore()
+	ts.SaveTx(makeCommittedTx("0x1"))
+	ts.SaveTx(makeCommittedTx("0x2"))
+
+	// Replay from the cursor
+	stream := ts.NewCommitStream(1)
+	committed, err := stream.Next(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "0x2", committed.TxHash)
+	assert.Equal(t, int64(2), stream.Cursor())
+
+	// Nothing new yet
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = stream.Next(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	// Then tail the live commits
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		ts.SaveTx(makeCommittedTx("0x3"))
+	}()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	committed, err = stream.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "0x3", committed.TxHash)
+	assert.Equal(t, int64(3), committed.Seq)
+}
+
+func TestTxStoreGroupCommit(t *testing.T) {
+	ts, db := freshBlockStore()
+	ts.SetGroupCommit(5*time.Millisecond, 10)
+
+	const n = 50
+	var wg sync.WaitGroup
+	for i := 1; i <= n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			txHash := fmt.Sprintf("0x%d", i)
+			ts.SaveTx(makeCommittedTx(txHash))
+			// Synced once SaveTx returns
+			assert.True(t, ts.HasTx(txHash))
+			assert.True(t, ts.LoadCommitSeq(txHash) <= ts.CommitSeq())
+		}(i)
+	}
+	wg.Wait()
+
+	require.Equal(t, int64(n), ts.CommitSeq())
+	seen := make(map[string]bool, n)
+	commits, err := ts.LoadCommittedTxs(0, n)
+	require.NoError(t, err)
+	for i, committed := range commits {
+		assert.Equal(t, int64(i+1), committed.Seq)
+		seen[committed.TxHash] = true
+	}
+	assert.Len(t, seen, n)
+	assert.Equal(t, int64(n), LoadTxStoreStateJSON(db).CommitSeq)
+}
+
+// stallingDB holds the first batch WriteSync until release is closed.
+type stallingDB struct {
+	dbm.DB
+	writing chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+type stallingBatch struct {
+	dbm.Batch
+	db *stallingDB
+}
+
+func (sdb *stallingDB) NewBatch() dbm.Batch {
+	return &stallingBatch{sdb.DB.NewBatch(), sdb}
+}
+
+func (b *stallingBatch) WriteSync() {
+	b.db.once.Do(func() {
+		close(b.db.writing)
+		<-b.db.release
+	})
+	b.Batch.WriteSync()
+}
+
+func TestTxStoreSaveTxWhileFlushing(t *testing.T) {
+	sdb := &stallingDB{
+		DB:      db.NewMemDB(),
+		writing: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	ts := NewTxStore(sdb, TxStoreWithValidatorsLoader(loadTestValidators))
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		ts.SaveTx(makeCommittedTx("0x1"))
+	}()
+	<-sdb.writing
+
+	// Saved again while the first group is written
+	go func() {
+		defer wg.Done()
+		ts.SaveTx(makeCommittedTx("0x1"))
+	}()
+	for staged := false; !staged; {
+		time.Sleep(time.Millisecond)
+		ts.mtx.RLock()
+		staged = ts.group != nil && len(ts.group.seqs) == 1
+		ts.mtx.RUnlock()
+	}
+	close(sdb.release)
+	wg.Wait()
+
+	// The tx keeps its commit seq
+	assert.Equal(t, int64(1), ts.LoadCommitSeq("0x1"))
+	assert.Equal(t, int64(1), ts.CommitSeq())
+	committed, err := ts.LoadCommittedTx(2)
+	require.NoError(t, err)
+	assert.Nil(t, committed)
+}
+
+func benchmarkTxStoreSaveTx(b *testing.B, maxDelay time.Duration, maxSize int) {
+	dir, err := ioutil.TempDir("", "txstore_bench")
+	require.NoError(b, err)
+	defer os.RemoveAll(dir)
+	db, err := dbm.NewGoLevelDB("txstore", dir)
+	require.NoError(b, err)
+	defer db.Close()
+
+	ts := NewTxStore(db)
+	ts.SetGroupCommit(maxDelay, maxSize)
+	// Sign outside of the timer
+	txs := make([]*types.TxVoteSet, b.N)
+	for i := range txs {
+		txs[i] = makeCommittedTx(fmt.Sprintf("0x%d", i))
+	}
+	var i int64 = -1
+	b.SetParallelism(16)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			ts.SaveTx(txs[atomic.AddInt64(&i, 1)])
+		}
+	})
+}
+
+// Every tx synced on its own, as before commit groups
+func BenchmarkTxStoreSaveTxSync(b *testing.B) {
+	benchmarkTxStoreSaveTx(b, 0, 1)
+}
+
+func BenchmarkTxStoreSaveTxGroup(b *testing.B) {
+	benchmarkTxStoreSaveTx(b, 0, DefaultGroupMaxSize)
+}
+
+func BenchmarkTxStoreSaveTxGroupDelay(b *testing.B) {
+	benchmarkTxStoreSaveTx(b, time.Millisecond, DefaultGroupMaxSize)
+}
@@ -0,0 +1,90 @@
+package tx
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/tendermint/tendermint/crypto"
+
+	"github.com/Fantom-foundation/go-txflow/types"
+)
+
+// CommittedTx is an entry of the commit log: the Seq-th tx this node
+// committed on the fast path, along with its 2/3 commit and the height of its
+// votes.
+type CommittedTx struct {
+	Seq    int64         `json:"seq"`
+	TxHash string        `json:"tx_hash"`
+	Height int64         `json:"height"`
+	Commit *types.Commit `json:"commit"`
+}
+
+// SignedBy returns true if the validator signed the commit.
+func (ct *CommittedTx) SignedBy(addr crypto.Address) bool {
+	if ct.Commit == nil {
+		return false
+	}
+	for _, sig := range ct.Commit.Commits {
+		if sig != nil && bytes.Equal(sig.ValidatorAddress, addr) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+CommitStream delivers the commit log of a TxStore: first the txs committed
+after its cursor, then the live ones as they commit.
+
+Each tx is delivered once, in commit order. A consumer that persists Cursor
+along with what it did with the tx resumes exactly where it left off by
+opening a new stream at that cursor after a restart.
+
+A CommitStream is not safe for concurrent use.
+*/
+type CommitStream struct {
+	ts     *TxStore
+	cursor int64
+}
+
+// NewCommitStream returns a stream of the txs committed after the cursor.
+// A cursor of 0 replays the whole log.
+func (ts *TxStore) NewCommitStream(cursor int64) *CommitStream {
+	return &CommitStream{ts: ts, cursor: cursor}
+}
+
+// Cursor returns the commit sequence of the last tx delivered.
+func (cs *CommitStream) Cursor() int64 {
+	return cs.cursor
+}
+
+// Next returns the tx following the cursor, waiting for it to commit if
+// needed, and advances the cursor. It returns ctx.Err() if ctx is done first,
+// ErrTxPruned if the tx was pruned from the log, and the error of
+// LoadCommittedTx if the tx can't be loaded; the cursor then stays.
+func (cs *CommitStream) Next(ctx context.Context) (*CommittedTx, error) {
+	for {
+		updated := cs.ts.CommitsUpdated()
+		if cs.cursor < cs.ts.CommitSeq() {
+			committed, err := cs.ts.LoadCommittedTx(cs.cursor + 1)
+			if err != nil {
+				return nil, err
+			}
+			if committed != nil {
+				cs.cursor = committed.Seq
+				return committed, nil
+			}
+			if base := cs.ts.PruneBase(); cs.cursor < base {
+				return nil, ErrTxPruned{Seq: cs.cursor + 1, Base: base}
+			}
+			// A hole in the log can't be filled later, skip it
+			cs.cursor++
+			continue
+		}
+		select {
+		case <-updated:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
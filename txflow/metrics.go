@@ -51,6 +51,17 @@ type Metrics struct {
 
 	// Number of blockparts transmitted by peer.
 	BlockParts metrics.Counter
+
+	// Number of open tx vote sets.
+	TxVoteSets metrics.Gauge
+	// Number of tx vote sets evicted because the cap was reached.
+	TxVoteSetsEvicted metrics.Counter
+	// Number of tx vote sets expired without reaching 2/3.
+	TxVoteSetsExpired metrics.Counter
+	// Number of txs handed to the block proposer without reaching 2/3.
+	TxsStalled metrics.Counter
+	// Number of txs more than 1/3 rejected.
+	TxsRejected metrics.Counter
 }
 
 // PrometheusMetrics returns Metrics build using Prometheus client library.
@@ -155,6 +166,37 @@ func PrometheusMetrics(namespace string, labelsAndValues ...string) *Metrics {
 			Name:      "block_parts",
 			Help:      "Number of blockparts transmitted by peer.",
 		}, append(labels, "peer_id")).With(labelsAndValues...),
+
+		TxVoteSets: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "tx_vote_sets",
+			Help:      "Number of open tx vote sets.",
+		}, labels).With(labelsAndValues...),
+		TxVoteSetsEvicted: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "tx_vote_sets_evicted",
+			Help:      "Number of tx vote sets evicted because the cap was reached.",
+		}, labels).With(labelsAndValues...),
+		TxVoteSetsExpired: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "tx_vote_sets_expired",
+			Help:      "Number of tx vote sets expired without reaching 2/3.",
+		}, labels).With(labelsAndValues...),
+		TxsStalled: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "txs_stalled",
+			Help:      "Number of txs handed to the block proposer without reaching 2/3.",
+		}, labels).With(labelsAndValues...),
+		TxsRejected: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "txs_rejected",
+			Help:      "Number of txs more than 1/3 rejected.",
+		}, labels).With(labelsAndValues...),
 	}
 }
 
@@ -180,5 +222,11 @@ func NopMetrics() *Metrics {
 		CommittedHeight: discard.NewGauge(),
 		FastSyncing:     discard.NewGauge(),
 		BlockParts:      discard.NewCounter(),
+
+		TxVoteSets:        discard.NewGauge(),
+		TxVoteSetsEvicted: discard.NewCounter(),
+		TxVoteSetsExpired: discard.NewCounter(),
+		TxsStalled:        discard.NewCounter(),
+		TxsRejected:       discard.NewCounter(),
 	}
 }
@@ -0,0 +1,122 @@
+package txflow
+
+import (
+	"crypto/sha256"
+	"time"
+
+	"github.com/Fantom-foundation/go-txflow/types"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+const (
+	// how often the bodies of pending txs are requested again
+	txFetchIntervalMS = 1000
+)
+
+// TxFetcher fetches tx bodies from peers. Fetched bodies are handed back
+// through TxFlow.ReceiveTx. A request is cancelled once the body arrived
+// another way.
+type TxFetcher interface {
+	RequestTx(txKey [sha256.Size]byte)
+	CancelTxRequest(txKey [sha256.Size]byte)
+}
+
+// SetTxFetcher sets the fetcher used to get the bodies of committed txs this
+// node never received.
+func (txR *TxFlow) SetTxFetcher(fetcher TxFetcher) {
+	txR.fetcher = fetcher
+}
+
+// NumPendingTxs returns the number of committed txs waiting for their body.
+func (txR *TxFlow) NumPendingTxs() int {
+	txR.mtx.RLock()
+	defer txR.mtx.RUnlock()
+	return len(txR.pending)
+}
+
+// ReceiveTx hands TxFlow a tx body fetched from a peer. If a committed tx was
+// waiting for it, its commit is completed. The body must hash to both the key
+// and the hash the validators voted for, otherwise it is dropped.
+func (txR *TxFlow) ReceiveTx(tx ttypes.Tx) {
+	txKey := types.TxKey(tx)
+
+	txR.mtx.Lock()
+	txHash, ok := txR.pending[txKey]
+	if !ok {
+		txR.mtx.Unlock()
+		return
+	}
+	if txHash != types.TxHash(tx) {
+		txR.mtx.Unlock()
+		txR.Logger.Error("Fetched tx does not match the voted tx hash", "txHash", txHash, "tx", types.TxHash(tx))
+		return
+	}
+	delete(txR.pending, txKey)
+	txR.mtx.Unlock()
+	if txR.fetcher != nil {
+		txR.fetcher.CancelTxRequest(txKey)
+	}
+
+	if err := txR.commitTx(txHash, tx); err != nil {
+		txR.Logger.Error("Error committing fetched tx", "txHash", txHash, "err", err)
+	}
+}
+
+// addPending queues a committed tx whose body is missing and asks peers for it.
+func (txR *TxFlow) addPending(txHash string, txKey [sha256.Size]byte) {
+	txR.mtx.Lock()
+	txR.pending[txKey] = txHash
+	txR.mtx.Unlock()
+
+	txR.Logger.Info("Committed tx body missing, fetching it", "txHash", txHash)
+	if txR.fetcher != nil {
+		txR.fetcher.RequestTx(txKey)
+	}
+}
+
+// dropPending forgets about pending txs included in a tick block, which
+// carries their bodies. Assumes txR.mtx is held.
+func (txR *TxFlow) dropPending(vtxs ttypes.Txs) {
+	if len(txR.pending) == 0 {
+		return
+	}
+	for _, tx := range vtxs {
+		txKey := types.TxKey(tx)
+		if _, ok := txR.pending[txKey]; !ok {
+			continue
+		}
+		delete(txR.pending, txKey)
+		if txR.fetcher != nil {
+			txR.fetcher.CancelTxRequest(txKey)
+		}
+	}
+}
+
+// Ask peers again for the bodies of pending txs, until they arrive or a tick
+// block brings them. Bodies gossiped to the mempool in the meantime are
+// picked up from there.
+func (txR *TxFlow) fetchTxsRoutine() {
+	ticker := time.NewTicker(txFetchIntervalMS * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			txR.mtx.RLock()
+			keys := make([][sha256.Size]byte, 0, len(txR.pending))
+			for txKey := range txR.pending {
+				keys = append(keys, txKey)
+			}
+			txR.mtx.RUnlock()
+
+			for _, txKey := range keys {
+				if tx := txR.mempl.GetTx(txKey); tx != nil {
+					txR.ReceiveTx(tx)
+				} else if txR.fetcher != nil {
+					txR.fetcher.RequestTx(txKey)
+				}
+			}
+		case <-txR.Quit():
+			return
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package txflow
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/Fantom-foundation/go-txflow/txflowstate"
+	"github.com/Fantom-foundation/go-txflow/types"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+// CommutativeFunc is an application supplied filter that reports whether a
+// tx commutes with every other tx. The position of a commutative tx doesn't
+// change the app state, so tick blocks execute them in the order they list
+// them.
+//
+// Commutative txs are not applied the moment they reach 2/3 either: the app
+// hash is taken after every batch, and which txs a node had applied by then
+// would depend on the order it collected votes in. They wait for their tick
+// block like the others. The node sets it with node.CommutativeTxs.
+type CommutativeFunc func(ttypes.Tx) bool
+
+/*
+TxSequencer decides the order in which fast-path committed txs are executed,
+and the batches they are committed to the app in.
+
+Votes arrive in a different order on every node, so applying a tx the moment
+its TxVoteSet crosses 2/3 lets replicas drift apart. Instead, committed txs
+are held until a tick block lists them in Data.Vtxs. Tick blocks are applied
+in height order and, within a block, commutative txs are executed first, in
+the order of the block, followed by the others in ascending tx hash order.
+The sequence is committed to the app in batches of MaxBatchSize txs. Since
+every honest node applies the same blocks, every honest node executes the
+same sequence and reaches the same app hash after each batch, the one the
+next block carries.
+
+A TxSequencer holds no state: the Handshaker uses the same one to replay the
+Vtxs of the blocks the app missed.
+*/
+type TxSequencer struct {
+	isCommutative CommutativeFunc
+	maxBatchSize  int
+}
+
+// TxSequencerOption sets an optional parameter on the TxSequencer.
+type TxSequencerOption func(*TxSequencer)
+
+// TxSequencerWithCommutativeFunc lets the application declare which txs
+// commute.
+func TxSequencerWithCommutativeFunc(isCommutative CommutativeFunc) TxSequencerOption {
+	return func(seq *TxSequencer) { seq.isCommutative = isCommutative }
+}
+
+// TxSequencerWithMaxBatchSize sets the number of txs committed to the app at
+// once. All validators must use the same size.
+func TxSequencerWithMaxBatchSize(maxBatchSize int) TxSequencerOption {
+	return func(seq *TxSequencer) { seq.maxBatchSize = maxBatchSize }
+}
+
+// NewTxSequencer returns a new TxSequencer. Without a CommutativeFunc every
+// tx is ordered by hash.
+func NewTxSequencer(options ...TxSequencerOption) *TxSequencer {
+	seq := &TxSequencer{
+		maxBatchSize: txflowstate.DefaultMaxBatchSize,
+	}
+	for _, option := range options {
+		option(seq)
+	}
+	if seq.maxBatchSize < 1 {
+		seq.maxBatchSize = 1
+	}
+	return seq
+}
+
+// MaxBatchSize returns the number of txs committed to the app at once.
+func (seq *TxSequencer) MaxBatchSize() int {
+	return seq.maxBatchSize
+}
+
+// Sequence returns the Vtxs of a tick block in execution order, leaving out
+// duplicates, split into the batches they are committed in.
+// It implements state.TxOrderer.
+func (seq *TxSequencer) Sequence(vtxs ttypes.Txs) []ttypes.Txs {
+	seen := make(map[string]struct{}, len(vtxs))
+	var commutative, ordered ttypes.Txs
+	for _, tx := range vtxs {
+		txHash := types.TxHash(tx)
+		if _, ok := seen[txHash]; ok {
+			continue
+		}
+		seen[txHash] = struct{}{}
+
+		if seq.isCommutative != nil && seq.isCommutative(tx) {
+			commutative = append(commutative, tx)
+		} else {
+			ordered = append(ordered, tx)
+		}
+	}
+
+	sort.Slice(ordered, func(i, j int) bool {
+		return bytes.Compare(ordered[i].Hash(), ordered[j].Hash()) < 0
+	})
+	txs := append(commutative, ordered...)
+
+	var batches []ttypes.Txs
+	for len(txs) > 0 {
+		size := seq.maxBatchSize
+		if size > len(txs) {
+			size = len(txs)
+		}
+		batches = append(batches, txs[:size])
+		txs = txs[size:]
+	}
+	return batches
+}
@@ -0,0 +1,58 @@
+package txflow
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+func TestTxSequencerOrdersByHash(t *testing.T) {
+	txs := ttypes.Txs{ttypes.Tx("c"), ttypes.Tx("a"), ttypes.Tx("b")}
+
+	// two nodes get the same sequence from blocks listing the txs differently
+	seq := NewTxSequencer()
+	batchesA := seq.Sequence(txs)
+	batchesB := seq.Sequence(ttypes.Txs{txs[2], txs[0], txs[1]})
+	assert.Equal(t, batchesA, batchesB)
+	assert.Len(t, batchesA, 1)
+
+	ordered := batchesA[0]
+	for i := 1; i < len(ordered); i++ {
+		assert.True(t, bytes.Compare(ordered[i-1].Hash(), ordered[i].Hash()) < 0)
+	}
+}
+
+func TestTxSequencerSkipsDuplicates(t *testing.T) {
+	seq := NewTxSequencer()
+	tx := ttypes.Tx("a")
+	batches := seq.Sequence(ttypes.Txs{tx, tx})
+	assert.Equal(t, []ttypes.Txs{{tx}}, batches)
+}
+
+func TestTxSequencerCommutative(t *testing.T) {
+	isCommutative := func(tx ttypes.Tx) bool {
+		return bytes.HasPrefix(tx, []byte("inc:"))
+	}
+	seq := NewTxSequencer(TxSequencerWithCommutativeFunc(isCommutative))
+
+	// commutative txs go first, in the order of the block
+	set := ttypes.Tx("set:1")
+	inc1 := ttypes.Tx("inc:1")
+	inc2 := ttypes.Tx("inc:2")
+	batches := seq.Sequence(ttypes.Txs{set, inc2, inc1})
+	assert.Equal(t, []ttypes.Txs{{inc2, inc1, set}}, batches)
+}
+
+func TestTxSequencerBatches(t *testing.T) {
+	seq := NewTxSequencer(TxSequencerWithMaxBatchSize(2))
+	txs := ttypes.Txs{ttypes.Tx("a"), ttypes.Tx("b"), ttypes.Tx("c")}
+
+	batches := seq.Sequence(txs)
+	assert.Len(t, batches, 2)
+	assert.Len(t, batches[0], 2)
+	assert.Len(t, batches[1], 1)
+	assert.Empty(t, seq.Sequence(nil))
+}
@@ -1,22 +1,30 @@
 package txflow
 
 import (
+	"crypto/sha256"
 	"sync"
 	"time"
 
+	"github.com/pkg/errors"
+
 	"github.com/Fantom-foundation/go-txflow/mempool"
 	"github.com/Fantom-foundation/go-txflow/tx"
 	"github.com/Fantom-foundation/go-txflow/txflowstate"
 	"github.com/Fantom-foundation/go-txflow/txvotepool"
 	"github.com/Fantom-foundation/go-txflow/types"
 	"github.com/tendermint/tendermint/consensus"
-	"github.com/tendermint/tendermint/evidence"
+	"github.com/tendermint/tendermint/crypto"
 	"github.com/tendermint/tendermint/libs/clist"
 	cmn "github.com/tendermint/tendermint/libs/common"
+	dbm "github.com/tendermint/tendermint/libs/db"
+	"github.com/tendermint/tendermint/libs/fail"
 	sm "github.com/tendermint/tendermint/state"
 	ttypes "github.com/tendermint/tendermint/types"
 )
 
+// maxVerifyBatch is the most votes of the txvotepool verified at once
+const maxVerifyBatch = 16 * txvotepool.DefaultSigBatchSize
+
 //-----------------------------------------------------------------------------
 
 // TxFlow defines a reactor for the consensus service.
@@ -24,64 +32,171 @@ type TxFlow struct {
 	cmn.BaseService
 
 	StartTime  time.Time
-	TxVoteSets map[string]*types.TxVoteSet
+	TxVoteSets *TxVoteSets
 
 	txV    *txvotepool.TxVotePool
 	mempl  *mempool.CListMempool
-	commit *mempool.CListMempool
+	commit *mempool.CommitPool
+
+	// verify the signatures of votes before they reach their vote set
+	sigVerifier *txvotepool.SigVerifier
 
 	// store txs and commits
 	txStore *tx.TxStore
 
 	// execute finalized txs
-	txExec *txflowstate.TxExecutor
+	txExec    *txflowstate.TxExecutor
+	batchExec *txflowstate.BatchExecutor
+	// journal entries of the open batch
+	batchEntries []*types.TxJournalEntry
+
+	// order finalized txs by tick block and batch them
+	sequencer *TxSequencer
+
+	// committed txs waiting for their body: txKey -> txHash
+	pending map[[sha256.Size]byte]string
+	// txs without 2/3 waiting for a tick block to order them
+	stalled *stalledTxs
+	fetcher TxFetcher
 
-	evpool *evidence.EvidencePool
+	evpool EvidencePool
+	// signs the votes of this node, for the last tick block
+	signer VoteSigner
 
 	// internal state
 	mtx sync.RWMutex
 
 	// This is the blockchain state, which essentially becomes a BFT timer
 	state *sm.State // State until height-1.
+	// last tick block height, recorded in the journal with every applied tx
+	height int64
+	// validator history, to check votes against the set they were signed for
+	stateDB dbm.DB
 
 	// Broadcast new committed tx events to the application layer
 	eventBus *ttypes.EventBus
 
 	metrics *Metrics
+
+	maxVoteSets   int
+	voteSetMaxAge int64
+	stallHeights  int64
+}
+
+// TxFlowOption sets an optional parameter on the TxFlow.
+type TxFlowOption func(*TxFlow)
+
+// EvidencePool takes the evidence of validators signing conflicting tx votes.
+type EvidencePool interface {
+	AddEvidence(ttypes.Evidence) error
+}
+
+// VoteSigner signs the votes of this node for the txs it receives. Votes are
+// signed for the last tick block height, by the validators of the next block.
+type VoteSigner interface {
+	SetHeight(height int64, validators *ttypes.ValidatorSet)
+}
+
+// WithTxSequencer sets the TxSequencer ordering and batching the txs of tick
+// blocks. The Handshaker must replay blocks with the same one.
+func WithTxSequencer(sequencer *TxSequencer) TxFlowOption {
+	return func(txR *TxFlow) { txR.sequencer = sequencer }
+}
+
+// WithVoteSetLimits sets the maximum number of open vote sets and the number
+// of tick blocks after which a vote set that did not reach 2/3 is dropped.
+func WithVoteSetLimits(maxSize int, maxAge int64) TxFlowOption {
+	return func(txR *TxFlow) {
+		txR.maxVoteSets = maxSize
+		txR.voteSetMaxAge = maxAge
+	}
+}
+
+// WithStallHeights sets the number of tick blocks after which a tx that did
+// not reach 2/3 is handed to the block proposer. It should be lower than the
+// vote set max age (see WithVoteSetLimits), 0 disables the fallback.
+func WithStallHeights(stallHeights int64) TxFlowOption {
+	return func(txR *TxFlow) { txR.stallHeights = stallHeights }
+}
+
+// WithSigVerifier sets the SigVerifier checking the signatures of votes,
+// e.g. to size its worker pool. The TxFlow starts and stops it.
+func WithSigVerifier(sigVerifier *txvotepool.SigVerifier) TxFlowOption {
+	return func(txR *TxFlow) { txR.sigVerifier = sigVerifier }
+}
+
+// WithMetrics sets the metrics.
+func WithMetrics(metrics *Metrics) TxFlowOption {
+	return func(txR *TxFlow) { txR.metrics = metrics }
 }
 
 // NewTxFlow returns a new TxFlow service
 func NewTxFlow(
 	state *sm.State,
+	stateDB dbm.DB,
 	txV *txvotepool.TxVotePool,
 	mempl *mempool.CListMempool,
-	commit *mempool.CListMempool,
+	commit *mempool.CommitPool,
 	txExec *txflowstate.TxExecutor,
 	txStore *tx.TxStore,
-	evpool *evidence.EvidencePool,
+	evpool EvidencePool,
+	options ...TxFlowOption,
 ) *TxFlow {
 	txR := &TxFlow{
-		txV:        txV,
-		txExec:     txExec,
-		txStore:    txStore,
-		state:      state,
-		evpool:     evpool,
-		mempl:      mempl,
-		commit:     commit,
-		TxVoteSets: make(map[string]*types.TxVoteSet),
+		txV:           txV,
+		txExec:        txExec,
+		txStore:       txStore,
+		state:         state,
+		height:        state.LastBlockHeight,
+		stateDB:       stateDB,
+		evpool:        evpool,
+		mempl:         mempl,
+		commit:        commit,
+		sigVerifier:   txvotepool.NewSigVerifier(state.ChainID),
+		sequencer:     NewTxSequencer(),
+		pending:       make(map[[sha256.Size]byte]string),
+		stalled:       newStalledTxs(),
+		metrics:       NopMetrics(),
+		maxVoteSets:   DefaultMaxTxVoteSets,
+		voteSetMaxAge: DefaultTxVoteSetMaxAge,
+		stallHeights:  DefaultTxStallHeights,
 	}
 	txR.BaseService = *cmn.NewBaseService(nil, "TxFlow", txR)
 
+	for _, option := range options {
+		option(txR)
+	}
+	txR.TxVoteSets = NewTxVoteSets(txR.maxVoteSets, txR.voteSetMaxAge, txR.metrics)
+	txR.batchExec = txflowstate.NewBatchExecutor(
+		txExec,
+		txflowstate.BatchExecutorWithMaxSize(txR.sequencer.MaxBatchSize()),
+	)
+
 	return txR
 }
 
+// NewValidatorsLoader returns the ValidatorsLoader the TxStore makes and
+// expands certificates with: the votes at a height are counted against the
+// validators of height+1 saved in stateDB.
+func NewValidatorsLoader(stateDB dbm.DB) tx.ValidatorsLoader {
+	return func(height int64) (*ttypes.ValidatorSet, error) {
+		return sm.LoadValidators(stateDB, height+1)
+	}
+}
+
 // OnStart implements BaseService by subscribing to events, which later will be
 // broadcasted to other peers and starting state if we're not in fast sync.
 func (txR *TxFlow) OnStart() error {
 	txR.Logger.Info("TxFlowReactor OnStart()")
 
+	txR.sigVerifier.SetLogger(txR.Logger)
+	if err := txR.sigVerifier.Start(); err != nil {
+		return err
+	}
+
 	// Why do we check here and not onReceive in txvotepool?
 	go txR.checkMaj23Routine()
+	go txR.fetchTxsRoutine()
 
 	return nil
 }
@@ -89,7 +204,12 @@ func (txR *TxFlow) OnStart() error {
 // OnStop implements BaseService by unsubscribing from events and stopping
 // state.
 func (txR *TxFlow) OnStop() {
+	txR.sigVerifier.Stop()
+}
 
+// SetVoteSigner sets the signer moved on to the height of every tick block.
+func (txR *TxFlow) SetVoteSigner(signer VoteSigner) {
+	txR.signer = signer
 }
 
 // SetEventBus sets event bus.
@@ -113,12 +233,177 @@ func (txR *TxFlow) GetValidators() (int64, []*ttypes.Validator) {
 }
 
 // LoadCommit loads the commit for a given hash.
-func (txR *TxFlow) LoadCommit(txHash string) *types.Commit {
+func (txR *TxFlow) LoadCommit(txHash string) (*types.Commit, error) {
 	txR.mtx.RLock()
 	defer txR.mtx.RUnlock()
 	return txR.txStore.LoadTxCommit(txHash)
 }
 
+// LoadTxVoteSet rebuilds the vote set of a committed tx from the TxStore, see
+// tx.TxStore.LoadTxVoteSet.
+func (txR *TxFlow) LoadTxVoteSet(txHash string) (*types.TxVoteSet, error) {
+	txR.mtx.RLock()
+	chainID := txR.state.ChainID
+	txR.mtx.RUnlock()
+	return txR.txStore.LoadTxVoteSet(chainID, txHash)
+}
+
+// OpenVoteSets lists the vote sets of txs which have not reached 2/3 yet.
+func (txR *TxFlow) OpenVoteSets() []TxVoteSetInfo {
+	return txR.TxVoteSets.List()
+}
+
+// Lock implements sm.TxSequencer. Fast-path txs are only delivered by
+// SequenceBlock, so there is no open batch to commit.
+func (txR *TxFlow) Lock() error {
+	txR.mtx.Lock()
+	return nil
+}
+
+// Unlock implements sm.TxSequencer.
+func (txR *TxFlow) Unlock() {
+	txR.mtx.Unlock()
+}
+
+// SequenceBlock executes vtxs, the fast-path txs ordered by the given tick
+// block which no earlier block executed in its Txs. It returns the app hash
+// after the last applied tx, or nil if no tx was applied. The txs are
+// committed in the batches of the TxSequencer, so all nodes commit the app at
+// the same points.
+// Vote sets which stayed open for too long are dropped here as well, the txs
+// the block orders in its Txs are resolved, stalled txs are handed to the
+// block proposer, the conflict keys of final txs are released and the
+// VoteSigner moves on to the block's height.
+// Assumes txR.mtx is held (see Lock).
+func (txR *TxFlow) SequenceBlock(block *types.Block, vtxs ttypes.Txs) ([]byte, error) {
+	txR.height = block.Height
+	if expired := txR.TxVoteSets.Expire(block.Height); expired > 0 {
+		txR.Logger.Debug("Expired tx vote sets", "height", block.Height, "count", expired)
+	}
+	// The block carries the bodies, no need to fetch them anymore
+	txR.dropPending(block.Vtxs)
+	txR.resolveBlockTxs(block)
+	txR.detectStalled()
+	if released := txR.txV.ReleaseConflictKeys(txR.txStore.IsFinal); released > 0 {
+		txR.Logger.Debug("Released conflict keys of final txs", "height", block.Height, "count", released)
+	}
+	txR.updateSigner(block.Height)
+
+	batches := txR.sequencer.Sequence(vtxs)
+	if len(batches) == 0 {
+		return nil, nil
+	}
+	for _, batch := range batches {
+		for _, tx := range batch {
+			if err := txR.applyTx(types.TxHash(tx), tx); err != nil {
+				return nil, err
+			}
+		}
+		if err := txR.commitBatch(); err != nil {
+			return nil, err
+		}
+	}
+	return txR.state.AppHash, nil
+}
+
+// updateSigner moves the VoteSigner on to the given tick block height. The
+// validators of the next block were saved with the state of the previous one.
+func (txR *TxFlow) updateSigner(height int64) {
+	if txR.signer == nil {
+		return
+	}
+	valSet, err := sm.LoadValidators(txR.stateDB, height+1)
+	if err != nil {
+		txR.Logger.Error("Error loading the validators signing votes", "height", height, "err", err)
+		return
+	}
+	txR.signer.SetHeight(height, valSet)
+}
+
+// applyTx delivers the tx to the app as part of the open batch, journaling it
+// first, so the Handshaker can tell whether the app committed it after a
+// crash. The last entry of every batch is saved again with the app hash of
+// the batch.
+// Assumes txR.mtx is held.
+func (txR *TxFlow) applyTx(txHash string, tx ttypes.Tx) error {
+	entry := &types.TxJournalEntry{
+		Seq:    txR.txStore.LastJournalSeq() + 1,
+		Height: txR.height,
+		TxHash: txHash,
+		Tx:     tx,
+	}
+	txR.txStore.SaveJournalEntry(entry)
+	txR.batchEntries = append(txR.batchEntries, entry)
+
+	fail.Fail() // XXX
+
+	committed, err := txR.batchExec.AddTx(txR.state, tx)
+	if err != nil {
+		return err
+	}
+	if committed {
+		txR.journalBatch()
+	}
+	return nil
+}
+
+// commitBatch commits the open batch, if any.
+// Assumes txR.mtx is held.
+func (txR *TxFlow) commitBatch() error {
+	committed, err := txR.batchExec.Commit(txR.state)
+	if err != nil {
+		return err
+	}
+	if committed {
+		txR.journalBatch()
+	}
+	return nil
+}
+
+// journalBatch records the app hash of the committed batch, then fires the
+// events of its txs.
+// Assumes txR.mtx is held.
+func (txR *TxFlow) journalBatch() {
+	fail.Fail() // XXX
+
+	entries := txR.batchEntries
+	last := entries[len(entries)-1]
+	last.AppHash = txR.state.AppHash
+	txR.txStore.SaveJournalEntry(last)
+	txR.batchEntries = nil
+
+	txR.fireCommitEvents(entries)
+}
+
+// fireCommitEvents publishes TxFastCommitted for the txs of the committed
+// batch.
+// NOTE: if the node crashes before the events are published, they are lost.
+func (txR *TxFlow) fireCommitEvents(entries []*types.TxJournalEntry) {
+	if txR.eventBus == nil {
+		return
+	}
+	_, deliverTxs := txR.batchExec.LastBatch()
+	for i, entry := range entries {
+		commit, err := txR.txStore.LoadTxCommit(entry.TxHash)
+		if err != nil {
+			txR.Logger.Error("Error loading commit of committed tx", "txHash", entry.TxHash, "err", err)
+		}
+		data := types.EventDataTxFastCommitted{
+			TxHash: entry.TxHash,
+			Height: entry.Height,
+			Seq:    entry.Seq,
+			Tx:     entry.Tx,
+			Commit: commit,
+		}
+		if i < len(deliverTxs) {
+			data.Result = *deliverTxs[i]
+		}
+		if err := types.PublishTxEvent(txR.eventBus, types.EventTxFastCommitted, entry.TxHash, data); err != nil {
+			txR.Logger.Error("Error publishing tx committed event", "txHash", entry.TxHash, "err", err)
+		}
+	}
+}
+
 // Sign new mempool txs.
 func (txR *TxFlow) checkMaj23Routine() {
 	var next *clist.CElement
@@ -141,19 +426,23 @@ func (txR *TxFlow) checkMaj23Routine() {
 			}
 		}
 
-		memTx := next.Value.(*txvotepool.MempoolTxVote)
-		added, err := txR.TryAddVote(&memTx.Tx)
-		if added {
-			//ts.statsMsgQueue <- mi
-		}
-
-		if err == consensus.ErrAddingVote {
-			// TODO: punish peer
-			// We probably don't want to stop the peer here. The vote does not
-			// necessarily comes from a malicious peer but can be just broadcasted by
-			// a typical peer.
-			// https://github.com/tendermint/tendermint/issues/1281
+		// Take the votes already in the pool along, their signatures are
+		// verified at once
+		votes := []*types.TxVote{&next.Value.(*txvotepool.MempoolTxVote).Tx}
+		for len(votes) < maxVerifyBatch {
+			e := next.Next()
+			if e == nil {
+				break
+			}
+			next = e
+			votes = append(votes, &next.Value.(*txvotepool.MempoolTxVote).Tx)
 		}
+		// TODO: punish peer on consensus.ErrAddingVote
+		// We probably don't want to stop the peer here. The vote does not
+		// necessarily comes from a malicious peer but can be just broadcasted by
+		// a typical peer.
+		// https://github.com/tendermint/tendermint/issues/1281
+		txR.tryAddVotes(votes)
 
 		select {
 		case <-next.NextWaitChan():
@@ -167,15 +456,71 @@ func (txR *TxFlow) checkMaj23Routine() {
 
 // TryAddVote Attempt to add the vote. if its a duplicate signature, dupeout the validator
 func (txR *TxFlow) TryAddVote(vote *types.TxVote) (bool, error) {
-	added, err := txR.addVote(vote)
+	return txR.tryAddVote(vote, false)
+}
+
+/*
+tryAddVotes verifies the signatures of the votes on the SigVerifier, then
+adds the valid ones, which their vote set no longer checks.
+
+The key of a signer is looked up in the validators the vote was signed for.
+A vote without one, for an unknown height or validator, goes through
+TryAddVote to report why.
+*/
+func (txR *TxFlow) tryAddVotes(votes []*types.TxVote) {
+	// Late votes are ignored anyway, don't verify them
+	pending := votes[:0:0]
+	for _, vote := range votes {
+		if !txR.txStore.IsFinal(vote.TxHash) {
+			pending = append(pending, vote)
+		}
+	}
+
+	pubKeys := make([]crypto.PubKey, len(pending))
+	valSets := make(map[int64]*ttypes.ValidatorSet)
+	for i, vote := range pending {
+		valSet, ok := valSets[vote.Height]
+		if !ok {
+			// nil if unknown
+			valSet, _ = sm.LoadValidators(txR.stateDB, vote.Height+1)
+			valSets[vote.Height] = valSet
+		}
+		if valSet == nil {
+			continue
+		}
+		if _, val := valSet.GetByAddress(vote.ValidatorAddress); val != nil {
+			pubKeys[i] = val.PubKey
+		}
+	}
+
+	errs := txR.sigVerifier.Verify(pending, pubKeys)
+	for i, vote := range pending {
+		switch {
+		case pubKeys[i] == nil:
+			txR.TryAddVote(vote)
+		case errs[i] != nil:
+			txR.Logger.Error("Error attempting to add vote", "err", errs[i])
+		default:
+			txR.tryAddVote(vote, true)
+		}
+	}
+}
+
+// tryAddVote is TryAddVote, skipping the signature check if verified.
+func (txR *TxFlow) tryAddVote(vote *types.TxVote, verified bool) (bool, error) {
+	added, err := txR.addVote(vote, verified)
 	if err != nil {
 		// If the vote height is off, we'll just ignore it,
 		// But if it's a conflicting sig, add it to the cs.evpool.
 		// If it's otherwise invalid, punish peer.
-		if err == consensus.ErrVoteHeightMismatch {
+		if err == consensus.ErrVoteHeightMismatch || err == types.ErrVoteValidatorRemoved {
 			return added, err
-		} else if voteErr, ok := err.(*ttypes.ErrVoteConflictingVotes); ok {
-			txR.evpool.AddEvidence(voteErr.DuplicateVoteEvidence)
+		} else if voteErr, ok := err.(*types.ErrTxVoteConflictingVotes); ok {
+			if txR.evpool != nil {
+				if err := txR.evpool.AddEvidence(voteErr.DuplicateTxVoteEvidence); err != nil {
+					txR.Logger.Error("Error adding tx vote evidence", "err", err)
+				}
+			}
 			return added, err
 		} else {
 			// Probably an invalid signature / Bad peer.
@@ -189,7 +534,68 @@ func (txR *TxFlow) TryAddVote(vote *types.TxVote) (bool, error) {
 
 //-----------------------------------------------------------------------------
 
-func (txR *TxFlow) addVote(vote *types.TxVote) (added bool, err error) {
+/*
+voteSetFor returns the vote set the vote should be added to.
+
+A vote for height H is signed by the validators of block H+1 and is checked
+against that set, loaded from the validator history in the stateDB. Votes for
+heights without a known validator set are ignored.
+
+A vote set counts its votes against a single validator set, the one of the
+highest height seen for the tx. When a vote arrives that was signed under a
+newer validator set, the open set is rebased on it: votes of validators that
+were removed are dropped and the others are counted with their new power. A
+vote signed under an older validator set is counted with the power its signer
+has in the set's validators, or ignored if the signer was removed since.
+*/
+func (txR *TxFlow) voteSetFor(vote *types.TxVote) (*types.TxVoteSet, error) {
+	valSet, err := sm.LoadValidators(txR.stateDB, vote.Height+1)
+	if err != nil {
+		return nil, consensus.ErrVoteHeightMismatch
+	}
+	if !valSet.HasAddress(vote.ValidatorAddress) {
+		return nil, errors.Wrapf(ttypes.ErrVoteInvalidValidatorIndex,
+			"Cannot find validator %X in valSet of height %d", vote.ValidatorAddress, vote.Height+1)
+	}
+
+	voteSet, rebased := txR.TxVoteSets.Rebase(vote.TxHash, vote.Height, valSet)
+	if voteSet == nil {
+		return txR.TxVoteSets.GetOrAdd(types.NewTxVoteSet(
+			txR.state.ChainID,
+			vote.Height,
+			vote.TxHash,
+			vote.TxKey,
+			valSet,
+		)), nil
+	}
+	if rebased {
+		txR.Logger.Debug("Rebased tx vote set", "txHash", vote.TxHash, "height", vote.Height)
+	}
+	if !voteSet.HasValidator(vote.ValidatorAddress) {
+		return nil, types.ErrVoteValidatorRemoved
+	}
+	return voteSet, nil
+}
+
+// commitTx hands a tx which reached 2/3 on to execution. It waits in the
+// CommitPool for a tick block to fix its position (see SequenceBlock).
+func (txR *TxFlow) commitTx(txHash string, tx ttypes.Tx) error {
+	// Add transaction to commit pool to be added into validated block space for replay
+	commit, err := txR.txStore.LoadTxCommit(txHash)
+	if err != nil {
+		return err
+	}
+	if commit == nil {
+		txR.Logger.Error("Missing commit of committed tx", "txHash", txHash)
+		return nil
+	}
+	txR.commit.Add(txHash, tx, commit)
+	return nil
+}
+
+// addVote adds the vote to its vote set, which verifies its signature unless
+// verified.
+func (txR *TxFlow) addVote(vote *types.TxVote, verified bool) (added bool, err error) {
 	txR.Logger.Debug("addVote",
 		"voteHeight", vote.Height,
 		"valAddress", vote.ValidatorAddress,
@@ -197,38 +603,101 @@ func (txR *TxFlow) addVote(vote *types.TxVote) (added bool, err error) {
 		"txKey", vote.TxKey,
 	)
 
-	if _, ok := txR.TxVoteSets[vote.TxHash]; !ok {
-		voteSet := types.NewTxVoteSet(
-			txR.state.ChainID,
-			txR.state.LastBlockHeight,
-			vote.TxHash,
-			vote.TxKey,
-			txR.state.Validators,
-		)
-		txR.TxVoteSets[vote.TxHash] = voteSet
+	// Late votes for a tx that is already committed or ordered by a block
+	// are ignored
+	if txR.txStore.IsFinal(vote.TxHash) {
+		return false, nil
 	}
 
-	added, err = txR.TxVoteSets[vote.TxHash].AddVote(vote)
+	var voteSet *types.TxVoteSet
+	for {
+		voteSet, err = txR.voteSetFor(vote)
+		if err != nil {
+			return false, err
+		}
+		if verified {
+			added, err = voteSet.AddPreverifiedVote(vote)
+		} else {
+			added, err = voteSet.AddVote(vote)
+		}
+		// Rebased while the vote was on its way, add it to the new set
+		if err != types.ErrVoteSetRebased {
+			break
+		}
+	}
 	if !added {
 		// Either duplicate, or error upon cs.Votes.AddByIndex()
 		return
 	}
-	if txR.TxVoteSets[vote.TxHash].HasTwoThirdsMajority() {
+	txR.fireVoteEvent(vote, voteSet)
+	// The set is removed once committed, so this happens only once per tx.
+	// A rebased set may already hold 2/3 before this vote was added.
+	if voteSet.HasTwoThirdsMajority() {
 		//enter commit
-		txR.txStore.SaveTx(txR.TxVoteSets[vote.TxHash])
-		tx := txR.mempl.GetTx(txR.TxVoteSets[vote.TxHash].TxKey)
-		txR.txExec.ApplyTx(txR.state, tx)
+		txR.txStore.SaveTx(voteSet)
+		// Persisted, the set is no longer needed in memory
+		txR.TxVoteSets.Remove(vote.TxHash)
+		// A stalled tx made it after all, keep it out of proposals
+		txR.stalled.remove(vote.TxHash)
 
 		// Update txvotepool
 		// Remove votes from txvotepool
 		err = txR.txV.Update(
 			txR.state.LastBlockHeight,
-			txR.TxVoteSets[vote.TxHash].GetVotes(),
+			voteSet.GetVotes(),
 		)
+		if err != nil {
+			return
+		}
 
-		// Add transaction to commit pool to be added into validated block space for replay
-		// Commit can be found in txstore
-		txR.commit.CheckTx(tx, nil)
+		tx := txR.mempl.GetTx(voteSet.TxKey)
+		if tx == nil {
+			// Never received or already evicted, wait for a peer to send it
+			txR.addPending(vote.TxHash, voteSet.TxKey)
+			return
+		}
+		err = txR.commitTx(vote.TxHash, tx)
+	} else if voteSet.IsRejected() {
+		err = txR.rejectTx(voteSet)
 	}
 	return
 }
+
+// fireVoteEvent publishes TxVoteAdded for a vote just added to voteSet.
+func (txR *TxFlow) fireVoteEvent(vote *types.TxVote, voteSet *types.TxVoteSet) {
+	if txR.eventBus == nil {
+		return
+	}
+	err := types.PublishTxEvent(txR.eventBus, types.EventTxVoteAdded, vote.TxHash, types.EventDataTxVoteAdded{
+		Vote:             *vote,
+		Stake:            voteSet.Stake(),
+		RejectStake:      voteSet.RejectStake(),
+		TotalVotingPower: voteSet.TotalVotingPower(),
+	})
+	if err != nil {
+		txR.Logger.Error("Error publishing tx vote event", "txHash", vote.TxHash, "err", err)
+	}
+}
+
+// rejectTx records the reject certificate of a tx more than 1/3 rejected.
+// The tx can't reach 2/3 anymore, so its set is closed and its votes dropped.
+func (txR *TxFlow) rejectTx(voteSet *types.TxVoteSet) error {
+	txR.txStore.SaveTxReject(voteSet)
+	txR.TxVoteSets.Remove(voteSet.TxHash)
+	txR.stalled.remove(voteSet.TxHash)
+	txR.metrics.TxsRejected.Add(1)
+	txR.Logger.Info("Tx rejected", "txHash", voteSet.TxHash, "rejectStake", voteSet.RejectStake())
+
+	if txR.eventBus != nil {
+		err := types.PublishTxEvent(txR.eventBus, types.EventTxRejected, voteSet.TxHash, types.EventDataTxRejected{
+			TxHash:      voteSet.TxHash,
+			Height:      voteSet.Height(),
+			Certificate: txR.txStore.LoadTxReject(voteSet.TxHash),
+		})
+		if err != nil {
+			txR.Logger.Error("Error publishing tx rejected event", "txHash", voteSet.TxHash, "err", err)
+		}
+	}
+
+	return txR.txV.Update(txR.state.LastBlockHeight, voteSet.GetVotes())
+}
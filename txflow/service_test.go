@@ -53,16 +53,7 @@ func TestTxVotes(t *testing.T) {
 	mempool.SetLogger(logger)
 
 	// Make Commitpool
-	commitMetrics := tmempl.PrometheusMetrics("node_test_commitpool")
-	commit := mempl.NewCListMempool(
-		config.Mempool,
-		proxyApp.Mempool(),
-		state.LastBlockHeight,
-		mempl.WithMetrics(commitMetrics),
-		mempl.WithPreCheck(sm.TxPreCheck(state)),
-		mempl.WithPostCheck(sm.TxPostCheck(state)),
-	)
-	commit.SetLogger(logger)
+	commit := mempl.NewCommitPool(config.Mempool)
 
 	// Make TxVotePool
 	txvMetrics := tmempl.PrometheusMetrics("node_test_txvotepool")
@@ -95,7 +86,7 @@ func TestTxVotes(t *testing.T) {
 	assert.Equal(t, true, txVotePoolReactor.IsRunning())
 	assert.Equal(t, 16, mempool.Size())
 
-	txStore := tx.NewTxStore(stateDB)
+	txStore := tx.NewTxStore(stateDB, tx.TxStoreWithValidatorsLoader(NewValidatorsLoader(stateDB)))
 
 	// make block executor for consensus and blockchain reactors to execute blocks
 
@@ -109,6 +100,7 @@ func TestTxVotes(t *testing.T) {
 	txfLogger := logger.With("module", "txflow")
 	txf := NewTxFlow(
 		&state,
+		stateDB,
 		txVotePool,
 		mempool,
 		commit,
@@ -153,3 +145,71 @@ func stateWithPrivValidator(nVals int, height int64) (sm.State, dbm.DB, types.Pr
 	}
 	return s, stateDB, pk
 }
+
+func TestSequenceBlockJournalsTxHash(t *testing.T) {
+	txf, txStore, cleanup := newTestTxFlow(t)
+	defer cleanup()
+
+	tx := ttypes.Tx("key=value")
+
+	block := types.MakeBlock(2, nil, ttypes.Txs{tx}, nil, nil)
+	txf.Lock()
+	appHash, err := txf.SequenceBlock(block, block.Vtxs)
+	txf.Unlock()
+	require.NoError(t, err)
+	assert.NotNil(t, appHash)
+
+	entry := txStore.LoadJournalEntry(1)
+	require.NotNil(t, entry)
+	assert.Equal(t, types.TxHash(tx), entry.TxHash)
+	assert.Equal(t, tx, entry.Tx)
+}
+
+type heightSigner struct {
+	height     int64
+	validators *ttypes.ValidatorSet
+}
+
+func (hs *heightSigner) SetHeight(height int64, validators *ttypes.ValidatorSet) {
+	hs.height = height
+	hs.validators = validators
+}
+
+func TestSequenceBlockMovesVoteSigner(t *testing.T) {
+	txf, _, cleanup := newTestTxFlow(t)
+	defer cleanup()
+
+	signer := &heightSigner{}
+	txf.SetVoteSigner(signer)
+
+	block := types.MakeBlock(1, nil, nil, nil, nil)
+	txf.Lock()
+	_, err := txf.SequenceBlock(block, nil)
+	txf.Unlock()
+	require.NoError(t, err)
+
+	assert.EqualValues(t, 1, signer.height)
+	require.NotNil(t, signer.validators)
+	assert.Equal(t, txf.state.Validators.Hash(), signer.validators.Hash())
+}
+
+// newTestTxFlow returns a TxFlow over a kvstore app, which is not started.
+func newTestTxFlow(t *testing.T) (*TxFlow, *tx.TxStore, func()) {
+	config := cfg.ResetTestRoot("txflow_test")
+	cc := proxy.NewLocalClientCreator(kvstore.NewKVStoreApplication())
+	proxyApp := proxy.NewAppConns(cc)
+	require.NoError(t, proxyApp.Start())
+
+	state, stateDB, _ := stateWithPrivValidator(1, 1)
+	mempool := mempl.NewCListMempool(config.Mempool, proxyApp.Mempool(), state.LastBlockHeight)
+	txVotePool := txvotepool.NewTxVotePool(config.Mempool, state.LastBlockHeight)
+	txStore := tx.NewTxStore(stateDB, tx.TxStoreWithValidatorsLoader(NewValidatorsLoader(stateDB)))
+	txExec := txflowstate.NewTxExecutor(log.TestingLogger(), proxyApp.Consensus(), mempool, txVotePool)
+
+	txf := NewTxFlow(&state, stateDB, txVotePool, mempool, mempl.NewCommitPool(config.Mempool), txExec, txStore, nil)
+	txf.SetLogger(log.TestingLogger())
+	return txf, txStore, func() {
+		proxyApp.Stop()
+		os.RemoveAll(config.RootDir)
+	}
+}
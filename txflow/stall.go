@@ -0,0 +1,150 @@
+package txflow
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/Fantom-foundation/go-txflow/types"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+type stalledTx struct {
+	txHash string
+	tx     ttypes.Tx
+}
+
+// stalledTxs holds the txs which failed to reach 2/3 on the fast path, in the
+// order they stalled, until a tick block orders them.
+type stalledTxs struct {
+	mtx sync.Mutex
+
+	txs    *list.List               // *stalledTx, oldest first
+	txsMap map[string]*list.Element // txHash -> element
+}
+
+func newStalledTxs() *stalledTxs {
+	return &stalledTxs{
+		txs:    list.New(),
+		txsMap: make(map[string]*list.Element),
+	}
+}
+
+func (st *stalledTxs) add(txHash string, tx ttypes.Tx) {
+	st.mtx.Lock()
+	defer st.mtx.Unlock()
+	if _, ok := st.txsMap[txHash]; !ok {
+		st.txsMap[txHash] = st.txs.PushBack(&stalledTx{txHash: txHash, tx: tx})
+	}
+}
+
+func (st *stalledTxs) remove(txHash string) {
+	st.mtx.Lock()
+	defer st.mtx.Unlock()
+	if e, ok := st.txsMap[txHash]; ok {
+		st.txs.Remove(e)
+		delete(st.txsMap, txHash)
+	}
+}
+
+func (st *stalledTxs) size() int {
+	st.mtx.Lock()
+	defer st.mtx.Unlock()
+	return st.txs.Len()
+}
+
+// reap returns the oldest txs whose total amino size does not exceed
+// maxBytes. A negative maxBytes reaps all txs.
+func (st *stalledTxs) reap(maxBytes int64) ttypes.Txs {
+	st.mtx.Lock()
+	defer st.mtx.Unlock()
+
+	var totalBytes int64
+	txs := make(ttypes.Txs, 0, st.txs.Len())
+	for e := st.txs.Front(); e != nil; e = e.Next() {
+		tx := e.Value.(*stalledTx).tx
+		txBytes := int64(len(tx)) + ttypes.ComputeAminoOverhead(tx, 1)
+		if maxBytes > -1 && totalBytes+txBytes > maxBytes {
+			break
+		}
+		totalBytes += txBytes
+		txs = append(txs, tx)
+	}
+	return txs
+}
+
+//-----------------------------------------------------------------------------
+
+// NumStalledTxs returns the number of txs waiting for a tick block to order
+// them after failing to reach 2/3.
+func (txR *TxFlow) NumStalledTxs() int {
+	return txR.stalled.size()
+}
+
+// ReapStalled implements sm.StalledTxs. It returns the stalled txs, oldest
+// first, for the proposer to put in front of the mempool txs.
+func (txR *TxFlow) ReapStalled(maxBytes int64) ttypes.Txs {
+	return txR.stalled.reap(maxBytes)
+}
+
+// detectStalled hands the txs whose vote sets have been open for more than
+// stallHeights tick blocks to the block proposer. A tx whose body is not in
+// the mempool can't be proposed, it is left to the fast path.
+// Assumes txR.mtx is held.
+func (txR *TxFlow) detectStalled() {
+	for _, voteSet := range txR.TxVoteSets.Stalled(txR.stallHeights) {
+		tx := txR.mempl.GetTx(voteSet.TxKey)
+		if tx == nil {
+			txR.Logger.Debug("Stalled tx is not in the mempool", "txHash", voteSet.TxHash)
+			continue
+		}
+		txR.Logger.Info("Tx stalled, falling back to block consensus",
+			"txHash", voteSet.TxHash, "stake", voteSet.Stake(), "total", voteSet.TotalVotingPower())
+		txR.stalled.add(voteSet.TxHash, tx)
+		txR.metrics.TxsStalled.Add(1)
+	}
+}
+
+/*
+resolveBlockTxs records the block as the final outcome of every tx it orders
+in its Txs which was not committed on the fast path, stalled or not.
+
+Their vote sets are closed and their votes dropped from the txvotepool: votes
+arriving later are ignored (see addVote), so a tx ordered by a block can't
+also commit on the fast path afterwards. Txs recorded in the block's Vtxs
+were committed on the fast path and are skipped by the BlockExecutor.
+
+Assumes txR.mtx is held.
+*/
+func (txR *TxFlow) resolveBlockTxs(block *types.Block) {
+	if len(block.Txs) == 0 {
+		return
+	}
+	vtxs := make(map[string]bool, len(block.Vtxs))
+	for _, vtx := range block.Vtxs {
+		vtxs[types.TxHash(vtx)] = true
+	}
+
+	var (
+		resolved []string
+		votes    []types.TxVote
+	)
+	for _, tx := range block.Txs {
+		txHash := types.TxHash(tx)
+		if vtxs[txHash] || txR.txStore.HasTx(txHash) {
+			continue
+		}
+		resolved = append(resolved, txHash)
+		if voteSet := txR.TxVoteSets.Get(txHash); voteSet != nil {
+			votes = append(votes, voteSet.GetVotes()...)
+			txR.TxVoteSets.Remove(txHash)
+		}
+		txR.stalled.remove(txHash)
+	}
+	txR.txStore.SaveTxResolutions(block.Height, resolved)
+
+	if len(votes) > 0 {
+		if err := txR.txV.Update(txR.state.LastBlockHeight, votes); err != nil {
+			txR.Logger.Error("Error dropping votes of txs ordered by a block", "height", block.Height, "err", err)
+		}
+	}
+}
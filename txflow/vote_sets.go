@@ -0,0 +1,255 @@
+package txflow
+
+import (
+	"bytes"
+	"container/list"
+	"sync"
+
+	"github.com/Fantom-foundation/go-txflow/types"
+	"github.com/tendermint/tendermint/crypto"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+const (
+	// DefaultMaxTxVoteSets is the default number of open vote sets kept in
+	// memory before the oldest are evicted.
+	DefaultMaxTxVoteSets = 100000
+	// DefaultTxVoteSetMaxAge is the default number of tick blocks a vote set
+	// may stay open without reaching 2/3.
+	DefaultTxVoteSetMaxAge = 100
+	// DefaultTxStallHeights is the default number of tick blocks after which
+	// a tx without 2/3 is handed to the block proposer.
+	DefaultTxStallHeights = 10
+)
+
+// TxVoteSetInfo describes a vote set.
+type TxVoteSetInfo struct {
+	TxHash            string           `json:"tx_hash"`
+	Height            int64            `json:"height"`
+	Stake             int64            `json:"stake"`
+	RejectStake       int64            `json:"reject_stake"`
+	TotalVotingPower  int64            `json:"total_voting_power"`
+	MissingValidators []crypto.Address `json:"missing_validators"`
+}
+
+type txVoteSetEntry struct {
+	voteSet *types.TxVoteSet
+	// tick height at which the set was opened
+	height int64
+	// whether Stalled returned the set already
+	stalled bool
+}
+
+/*
+TxVoteSets holds the vote sets of txs which have not reached 2/3 yet.
+
+Sets are dropped once their tx is persisted to the TxStore, or when they have
+been open for more than maxAge tick blocks. If more than maxSize sets are open,
+the oldest ones are evicted. Evicted and expired sets are simply forgotten: if
+more votes for the tx arrive, a new set is opened for it.
+
+TxVoteSets is safe for concurrent use.
+*/
+type TxVoteSets struct {
+	mtx sync.Mutex
+
+	// txHash -> element in order, oldest first
+	sets  map[string]*list.Element
+	order *list.List
+
+	// latest tick block height seen
+	height int64
+
+	maxSize int
+	maxAge  int64
+
+	metrics *Metrics
+}
+
+// NewTxVoteSets returns an empty TxVoteSets. A maxSize or maxAge of 0
+// disables the corresponding limit.
+func NewTxVoteSets(maxSize int, maxAge int64, metrics *Metrics) *TxVoteSets {
+	return &TxVoteSets{
+		sets:    make(map[string]*list.Element),
+		order:   list.New(),
+		maxSize: maxSize,
+		maxAge:  maxAge,
+		metrics: metrics,
+	}
+}
+
+// Get returns the open vote set for txHash, or nil.
+func (vs *TxVoteSets) Get(txHash string) *types.TxVoteSet {
+	vs.mtx.Lock()
+	defer vs.mtx.Unlock()
+	if e, ok := vs.sets[txHash]; ok {
+		return e.Value.(*txVoteSetEntry).voteSet
+	}
+	return nil
+}
+
+// GetOrAdd returns the open vote set for voteSet.TxHash. If there is none,
+// voteSet is added and returned, evicting the oldest set if the cap is reached.
+func (vs *TxVoteSets) GetOrAdd(voteSet *types.TxVoteSet) *types.TxVoteSet {
+	vs.mtx.Lock()
+	defer vs.mtx.Unlock()
+	if e, ok := vs.sets[voteSet.TxHash]; ok {
+		return e.Value.(*txVoteSetEntry).voteSet
+	}
+
+	if vs.maxSize > 0 {
+		for vs.order.Len() >= vs.maxSize {
+			vs.remove(vs.order.Front())
+			vs.metrics.TxVoteSetsEvicted.Add(1)
+		}
+	}
+
+	vs.sets[voteSet.TxHash] = vs.order.PushBack(&txVoteSetEntry{
+		voteSet: voteSet,
+		height:  vs.height,
+	})
+	vs.metrics.TxVoteSets.Set(float64(vs.order.Len()))
+	return voteSet
+}
+
+// Rebase rebases the open vote set for txHash on valSet, which took effect
+// after height, and returns the open set and whether it was rebased. The set
+// is looked up, rebased and swapped under the lock, keeping its age, so
+// concurrent callers never rebase the same set twice. A set at height or past
+// it, or with the same validators, is returned as is; nil if there is none.
+func (vs *TxVoteSets) Rebase(txHash string, height int64, valSet *ttypes.ValidatorSet) (*types.TxVoteSet, bool) {
+	vs.mtx.Lock()
+	defer vs.mtx.Unlock()
+	e, ok := vs.sets[txHash]
+	if !ok {
+		return nil, false
+	}
+	entry := e.Value.(*txVoteSetEntry)
+	if height <= entry.voteSet.Height() || bytes.Equal(valSet.Hash(), entry.voteSet.ValidatorsHash()) {
+		return entry.voteSet, false
+	}
+	entry.voteSet = entry.voteSet.Rebase(height, valSet)
+	return entry.voteSet, true
+}
+
+// Remove drops the vote set for txHash, if any.
+// It is called once the tx is persisted to the TxStore.
+func (vs *TxVoteSets) Remove(txHash string) {
+	vs.mtx.Lock()
+	defer vs.mtx.Unlock()
+	if e, ok := vs.sets[txHash]; ok {
+		vs.remove(e)
+		vs.metrics.TxVoteSets.Set(float64(vs.order.Len()))
+	}
+}
+
+// Expire records the latest tick block height and drops all sets which have
+// been open for more than maxAge blocks. It returns the number of dropped sets.
+func (vs *TxVoteSets) Expire(height int64) int {
+	vs.mtx.Lock()
+	defer vs.mtx.Unlock()
+
+	if height > vs.height {
+		vs.height = height
+	}
+	if vs.maxAge <= 0 {
+		return 0
+	}
+
+	// Sets are opened in height order, so the expired ones are at the front
+	expired := 0
+	for e := vs.order.Front(); e != nil; e = vs.order.Front() {
+		if vs.height-e.Value.(*txVoteSetEntry).height <= vs.maxAge {
+			break
+		}
+		vs.remove(e)
+		expired++
+	}
+	if expired > 0 {
+		vs.metrics.TxVoteSetsExpired.Add(float64(expired))
+		vs.metrics.TxVoteSets.Set(float64(vs.order.Len()))
+	}
+	return expired
+}
+
+// Stalled returns the sets which have been open for more than after tick
+// blocks. Each set is returned once; it stays open, since its tx may still
+// reach 2/3 before a block orders it. An after of 0 disables stall detection.
+func (vs *TxVoteSets) Stalled(after int64) []*types.TxVoteSet {
+	if after <= 0 {
+		return nil
+	}
+
+	vs.mtx.Lock()
+	defer vs.mtx.Unlock()
+
+	var stalled []*types.TxVoteSet
+	for e := vs.order.Front(); e != nil; e = e.Next() {
+		entry := e.Value.(*txVoteSetEntry)
+		if vs.height-entry.height <= after {
+			break
+		}
+		if !entry.stalled {
+			entry.stalled = true
+			stalled = append(stalled, entry.voteSet)
+		}
+	}
+	return stalled
+}
+
+// Size returns the number of open vote sets.
+func (vs *TxVoteSets) Size() int {
+	vs.mtx.Lock()
+	defer vs.mtx.Unlock()
+	return vs.order.Len()
+}
+
+// List returns the open vote sets, oldest first.
+func (vs *TxVoteSets) List() []TxVoteSetInfo {
+	vs.mtx.Lock()
+	entries := make([]*txVoteSetEntry, 0, vs.order.Len())
+	for e := vs.order.Front(); e != nil; e = e.Next() {
+		entries = append(entries, e.Value.(*txVoteSetEntry))
+	}
+	vs.mtx.Unlock()
+
+	// The vote sets have their own locks, don't hold ours while reading them
+	infos := make([]TxVoteSetInfo, len(entries))
+	for i, entry := range entries {
+		infos[i] = NewTxVoteSetInfo(entry.voteSet)
+	}
+	return infos
+}
+
+// Info returns the state of the open vote set for txHash, or nil.
+func (vs *TxVoteSets) Info(txHash string) *TxVoteSetInfo {
+	voteSet := vs.Get(txHash)
+	if voteSet == nil {
+		return nil
+	}
+	info := NewTxVoteSetInfo(voteSet)
+	return &info
+}
+
+// NewTxVoteSetInfo returns the info of the vote set.
+func NewTxVoteSetInfo(voteSet *types.TxVoteSet) TxVoteSetInfo {
+	missing := voteSet.MissingValidators()
+	addrs := make([]crypto.Address, len(missing))
+	for j, val := range missing {
+		addrs[j] = val.Address
+	}
+	return TxVoteSetInfo{
+		TxHash:            voteSet.TxHash,
+		Height:            voteSet.Height(),
+		Stake:             voteSet.Stake(),
+		RejectStake:       voteSet.RejectStake(),
+		TotalVotingPower:  voteSet.TotalVotingPower(),
+		MissingValidators: addrs,
+	}
+}
+
+// remove assumes vs.mtx is held.
+func (vs *TxVoteSets) remove(e *list.Element) {
+	entry := vs.order.Remove(e).(*txVoteSetEntry)
+	delete(vs.sets, entry.voteSet.TxHash)
+}
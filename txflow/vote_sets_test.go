@@ -0,0 +1,143 @@
+package txflow
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-txflow/types"
+	ttypes "github.com/tendermint/tendermint/types"
+	tmtime "github.com/tendermint/tendermint/types/time"
+)
+
+func newTestTxVoteSet(i int, valSet *ttypes.ValidatorSet) *types.TxVoteSet {
+	tx := ttypes.Tx(fmt.Sprintf("tx%d", i))
+	return types.NewTxVoteSet("test_chain_id", 1, types.TxHash(tx), types.TxKey(tx), valSet)
+}
+
+func randTestValidatorSet(n int, power int64) (*ttypes.ValidatorSet, []*types.MockPV) {
+	vals := make([]*ttypes.Validator, n)
+	privVals := make([]*types.MockPV, n)
+	for i := range vals {
+		privVals[i] = types.NewMockPV()
+		vals[i] = ttypes.NewValidator(privVals[i].GetPubKey(), power)
+	}
+	return ttypes.NewValidatorSet(vals), privVals
+}
+
+func TestTxVoteSetsEvictsOldest(t *testing.T) {
+	valSet, _ := randTestValidatorSet(1, 10)
+	vs := NewTxVoteSets(2, 0, NopMetrics())
+
+	sets := make([]*types.TxVoteSet, 3)
+	for i := range sets {
+		sets[i] = newTestTxVoteSet(i, valSet)
+		assert.Equal(t, sets[i], vs.GetOrAdd(sets[i]))
+	}
+
+	assert.Equal(t, 2, vs.Size())
+	assert.Nil(t, vs.Get(sets[0].TxHash))
+	assert.Equal(t, sets[1], vs.Get(sets[1].TxHash))
+
+	// an existing set is returned instead of the new one
+	assert.Equal(t, sets[2], vs.GetOrAdd(newTestTxVoteSet(2, valSet)))
+
+	vs.Remove(sets[1].TxHash)
+	assert.Equal(t, 1, vs.Size())
+	assert.Nil(t, vs.Get(sets[1].TxHash))
+}
+
+func TestTxVoteSetsExpire(t *testing.T) {
+	valSet, _ := randTestValidatorSet(1, 10)
+	vs := NewTxVoteSets(0, 2, NopMetrics())
+
+	vs.GetOrAdd(newTestTxVoteSet(0, valSet))
+	vs.Expire(2)
+	vs.GetOrAdd(newTestTxVoteSet(1, valSet))
+
+	assert.Equal(t, 0, vs.Expire(2))
+	assert.Equal(t, 1, vs.Expire(3))
+	assert.Equal(t, 1, vs.Size())
+	assert.Equal(t, 1, vs.Expire(5))
+	assert.Equal(t, 0, vs.Size())
+}
+
+func TestTxVoteSetsStalled(t *testing.T) {
+	valSet, _ := randTestValidatorSet(1, 10)
+	vs := NewTxVoteSets(0, 0, NopMetrics())
+
+	set0 := vs.GetOrAdd(newTestTxVoteSet(0, valSet))
+	vs.Expire(2)
+	vs.GetOrAdd(newTestTxVoteSet(1, valSet))
+
+	assert.Empty(t, vs.Stalled(2))
+	vs.Expire(3)
+	assert.Equal(t, []*types.TxVoteSet{set0}, vs.Stalled(2))
+	// Returned once, but still open
+	assert.Empty(t, vs.Stalled(2))
+	assert.Equal(t, set0, vs.Get(set0.TxHash))
+
+	vs.Expire(5)
+	assert.Len(t, vs.Stalled(2), 1)
+	assert.Empty(t, vs.Stalled(0))
+}
+
+func TestTxVoteSetsList(t *testing.T) {
+	valSet, privVals := randTestValidatorSet(2, 10)
+	vs := NewTxVoteSets(0, 0, NopMetrics())
+	voteSet := vs.GetOrAdd(newTestTxVoteSet(0, valSet))
+
+	addr := privVals[0].GetPubKey().Address()
+	vote := &types.TxVote{
+		Height:           1,
+		TxHash:           voteSet.TxHash,
+		TxKey:            voteSet.TxKey,
+		Timestamp:        tmtime.Now(),
+		ValidatorAddress: addr,
+	}
+	require.NoError(t, privVals[0].SignTxVote(voteSet.ChainID(), vote))
+	added, err := voteSet.AddVote(vote)
+	require.NoError(t, err)
+	require.True(t, added)
+
+	infos := vs.List()
+	require.Len(t, infos, 1)
+	assert.Equal(t, voteSet.TxHash, infos[0].TxHash)
+	assert.Equal(t, int64(10), infos[0].Stake)
+	assert.Equal(t, int64(20), infos[0].TotalVotingPower)
+	require.Len(t, infos[0].MissingValidators, 1)
+	assert.NotEqual(t, addr, infos[0].MissingValidators[0])
+}
+
+func TestTxVoteSetsRebase(t *testing.T) {
+	valSet, _ := randTestValidatorSet(2, 10)
+	newValSet, _ := randTestValidatorSet(2, 10)
+	vs := NewTxVoteSets(0, 0, NopMetrics())
+
+	voteSet := newTestTxVoteSet(0, valSet)
+	vs.GetOrAdd(voteSet)
+
+	// Not rebased on the same validators, nor on older ones
+	got, rebased := vs.Rebase(voteSet.TxHash, 2, valSet)
+	assert.False(t, rebased)
+	assert.Equal(t, voteSet, got)
+	got, rebased = vs.Rebase(voteSet.TxHash, 1, newValSet)
+	assert.False(t, rebased)
+	assert.Equal(t, voteSet, got)
+
+	got, rebased = vs.Rebase(voteSet.TxHash, 2, newValSet)
+	require.True(t, rebased)
+	assert.Equal(t, int64(2), got.Height())
+	assert.Equal(t, got, vs.Get(voteSet.TxHash))
+
+	// A concurrent caller with the same validators finds the rebased set
+	again, rebased := vs.Rebase(voteSet.TxHash, 2, newValSet)
+	assert.False(t, rebased)
+	assert.Equal(t, got, again)
+
+	got, rebased = vs.Rebase("unknown", 2, newValSet)
+	assert.False(t, rebased)
+	assert.Nil(t, got)
+}
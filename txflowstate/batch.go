@@ -0,0 +1,139 @@
+package txflowstate
+
+import (
+	"time"
+
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/libs/fail"
+	"github.com/tendermint/tendermint/state"
+	ttypes "github.com/tendermint/tendermint/types"
+
+	"github.com/Fantom-foundation/go-txflow/types"
+)
+
+// DefaultMaxBatchSize is the number of txs after which a batch is committed.
+const DefaultMaxBatchSize = 100
+
+// BatchExecutor delivers txs to the app as they come, but commits the app and
+// updates the mempool once per batch instead of once per tx.
+//
+// A batch is committed when it holds MaxBatchSize txs, or when Commit is
+// called. There is no time bound: a batch closing on a timer would close at
+// a different tx on every node. To keep the batch boundaries of sequenced txs
+// the same on all nodes, the owner must commit the open batch before the
+// first and after the last tx of a sequence, and all nodes must use the same
+// MaxBatchSize. The TxFlow commits after the Vtxs of every tick block, so no
+// batch stays open longer than a block.
+//
+// BatchExecutor is not safe for concurrent use.
+type BatchExecutor struct {
+	txExec *TxExecutor
+
+	maxSize int
+
+	// open batch
+	txs        ttypes.Txs
+	deliverTxs []*abci.ResponseDeliverTx
+	opened     time.Time
+
+	// last committed batch
+	lastTxs        ttypes.Txs
+	lastDeliverTxs []*abci.ResponseDeliverTx
+}
+
+type BatchExecutorOption func(*BatchExecutor)
+
+// BatchExecutorWithMaxSize sets the number of txs after which a batch is
+// committed. A size of 1 commits every tx, like TxExecutor.ApplyTx.
+func BatchExecutorWithMaxSize(maxSize int) BatchExecutorOption {
+	return func(batchExec *BatchExecutor) {
+		batchExec.maxSize = maxSize
+	}
+}
+
+// NewBatchExecutor returns a new BatchExecutor executing txs with txExec.
+func NewBatchExecutor(txExec *TxExecutor, options ...BatchExecutorOption) *BatchExecutor {
+	batchExec := &BatchExecutor{
+		txExec:  txExec,
+		maxSize: DefaultMaxBatchSize,
+	}
+	for _, option := range options {
+		option(batchExec)
+	}
+	if batchExec.maxSize < 1 {
+		batchExec.maxSize = 1
+	}
+	return batchExec
+}
+
+// Size returns the number of delivered txs waiting for the commit.
+func (batchExec *BatchExecutor) Size() int {
+	return len(batchExec.txs)
+}
+
+// LastBatch returns the txs of the last committed batch and their DeliverTx
+// responses.
+func (batchExec *BatchExecutor) LastBatch() (ttypes.Txs, []*abci.ResponseDeliverTx) {
+	return batchExec.lastTxs, batchExec.lastDeliverTxs
+}
+
+// AddTx delivers the tx to the app and adds it to the open batch. If the
+// batch is full, it's committed and the state's AppHash updated.
+// It returns true if the batch was committed.
+func (batchExec *BatchExecutor) AddTx(st *state.State, tx ttypes.Tx) (bool, error) {
+	if len(batchExec.txs) == 0 {
+		batchExec.opened = time.Now()
+		// Collect the responses of the batch, they arrive in order
+		batchExec.txExec.proxyApp.SetResponseCallback(func(req *abci.Request, res *abci.Response) {
+			if r, ok := res.Value.(*abci.Response_DeliverTx); ok {
+				batchExec.deliverTxs = append(batchExec.deliverTxs, r.DeliverTx)
+			}
+		})
+	}
+
+	batchExec.txExec.proxyApp.DeliverTxAsync(abci.RequestDeliverTx{Tx: tx})
+	if err := batchExec.txExec.proxyApp.Error(); err != nil {
+		return false, state.ErrProxyAppConn(err)
+	}
+	batchExec.txExec.logger.Debug("Delivered tx", "txHash", types.TxHash(tx))
+	batchExec.txs = append(batchExec.txs, tx)
+
+	if len(batchExec.txs) < batchExec.maxSize {
+		return false, nil
+	}
+	return batchExec.Commit(st)
+}
+
+// Commit commits the open batch, updates the mempool with it and sets the
+// state's AppHash. It returns false if there was nothing to commit.
+func (batchExec *BatchExecutor) Commit(st *state.State) (bool, error) {
+	if len(batchExec.txs) == 0 {
+		return false, nil
+	}
+	txs := batchExec.txs
+
+	fail.Fail() // XXX
+
+	// Lock mempool, commit app state, update mempoool.
+	// CommitSync returns after all DeliverTx responses were received.
+	appHash, err := batchExec.txExec.Commit(*st, txs, batchExec.deliverTxs)
+	deliverTxs := batchExec.deliverTxs
+	batchExec.txExec.metrics.BlockProcessingTime.Observe(float64(time.Since(batchExec.opened).Nanoseconds()) / 1000000)
+	batchExec.txExec.metrics.TxBatchSize.Observe(float64(len(txs)))
+	batchExec.txs = nil
+	batchExec.deliverTxs = nil
+	if err != nil {
+		return false, err
+	}
+
+	fail.Fail() // XXX
+
+	st.AppHash = appHash
+	batchExec.lastTxs = txs
+	batchExec.lastDeliverTxs = deliverTxs
+
+	// Events are fired after everything else.
+	fireEvents(batchExec.txExec.logger, batchExec.txExec.eventBus, txs, deliverTxs)
+
+	return true, nil
+}
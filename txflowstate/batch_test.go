@@ -0,0 +1,86 @@
+package txflowstate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-txflow/txvotepool"
+	"github.com/tendermint/tendermint/abci/example/kvstore"
+	cfg "github.com/tendermint/tendermint/config"
+	"github.com/tendermint/tendermint/libs/log"
+	"github.com/tendermint/tendermint/mock"
+	"github.com/tendermint/tendermint/proxy"
+	sm "github.com/tendermint/tendermint/state"
+	"github.com/tendermint/tendermint/types"
+)
+
+func TestBatchExecutorCommitsFullBatches(t *testing.T) {
+	cc := proxy.NewLocalClientCreator(kvstore.NewKVStoreApplication())
+	proxyApp := proxy.NewAppConns(cc)
+	err := proxyApp.Start()
+	require.Nil(t, err)
+	defer proxyApp.Stop()
+
+	txExec := NewTxExecutor(log.TestingLogger(), proxyApp.Consensus(), mock.Mempool{}, txvotepool.NewTxVotePool(cfg.TestMempoolConfig(), 0))
+	batchExec := NewBatchExecutor(txExec, BatchExecutorWithMaxSize(3))
+
+	st := newBatchTestState()
+	for i, tx := range []string{"a=1", "b=2"} {
+		committed, err := batchExec.AddTx(st, makeTx([]byte(tx)))
+		require.NoError(t, err)
+		assert.False(t, committed, "#%d: batch is not full yet", i)
+	}
+	assert.Equal(t, 2, batchExec.Size())
+	assert.Nil(t, st.AppHash)
+
+	committed, err := batchExec.AddTx(st, makeTx([]byte("c=3")))
+	require.NoError(t, err)
+	assert.True(t, committed)
+	assert.Equal(t, 0, batchExec.Size())
+	assert.NotNil(t, st.AppHash)
+	lastTxs, deliverTxs := batchExec.LastBatch()
+	assert.Len(t, lastTxs, 3)
+	assert.Len(t, deliverTxs, 3)
+
+	// An empty batch is not committed
+	committed, err = batchExec.Commit(st)
+	require.NoError(t, err)
+	assert.False(t, committed)
+}
+
+func TestBatchExecutorMatchesExecCommitTxs(t *testing.T) {
+	txs := types.Txs{makeTx([]byte("a=1")), makeTx([]byte("b=2")), makeTx([]byte("c=3")), makeTx([]byte("d=4"))}
+
+	// Batched execution
+	cc := proxy.NewLocalClientCreator(kvstore.NewKVStoreApplication())
+	proxyApp := proxy.NewAppConns(cc)
+	require.Nil(t, proxyApp.Start())
+	defer proxyApp.Stop()
+
+	txExec := NewTxExecutor(log.TestingLogger(), proxyApp.Consensus(), mock.Mempool{}, txvotepool.NewTxVotePool(cfg.TestMempoolConfig(), 0))
+	batchExec := NewBatchExecutor(txExec, BatchExecutorWithMaxSize(len(txs)))
+	st := newBatchTestState()
+	for _, tx := range txs {
+		_, err := batchExec.AddTx(st, tx)
+		require.NoError(t, err)
+	}
+
+	// Replayed execution
+	cc2 := proxy.NewLocalClientCreator(kvstore.NewKVStoreApplication())
+	proxyApp2 := proxy.NewAppConns(cc2)
+	require.Nil(t, proxyApp2.Start())
+	defer proxyApp2.Stop()
+
+	appHash, err := ExecCommitTxs(proxyApp2.Consensus(), txs, log.TestingLogger())
+	require.NoError(t, err)
+	assert.Equal(t, appHash, st.AppHash)
+}
+
+func newBatchTestState() *sm.State {
+	return &sm.State{
+		ConsensusParams: *types.DefaultConsensusParams(),
+		Validators:      types.NewValidatorSet(nil),
+	}
+}
@@ -87,7 +87,7 @@ func (txExec *TxExecutor) ApplyTx(st *state.State, tx ttypes.Tx) error {
 	fail.Fail() // XXX
 
 	// Lock mempool, commit app state, update mempoool.
-	appHash, err := txExec.Commit(*st, tx, abciResponses.DeliverTx)
+	appHash, err := txExec.Commit(*st, ttypes.Txs{tx}, abciResponses.DeliverTx)
 	if err != nil {
 		return fmt.Errorf("Commit failed for application: %v", err)
 	}
@@ -98,7 +98,7 @@ func (txExec *TxExecutor) ApplyTx(st *state.State, tx ttypes.Tx) error {
 
 	// Events are fired after everything else.
 	// NOTE: if we crash between Commit and Save, events wont be fired during replay
-	fireEvents(txExec.logger, txExec.eventBus, tx, abciResponses)
+	fireEvents(txExec.logger, txExec.eventBus, ttypes.Txs{tx}, abciResponses.DeliverTx)
 
 	return nil
 }
@@ -111,7 +111,7 @@ func (txExec *TxExecutor) ApplyTx(st *state.State, tx ttypes.Tx) error {
 // state before new txs are run in the mempool, lest they be invalid.
 func (txExec *TxExecutor) Commit(
 	state state.State,
-	tx ttypes.Tx,
+	txs ttypes.Txs,
 	deliverTxResponses []*abci.ResponseDeliverTx,
 ) ([]byte, error) {
 	txExec.mempool.Lock()
@@ -139,13 +139,14 @@ func (txExec *TxExecutor) Commit(
 	txExec.logger.Info(
 		"Committed state",
 		"height", state.LastBlockHeight,
+		"txs", len(txs),
 		"appHash", fmt.Sprintf("%X", res.Data),
 	)
 
 	// Update mempool.
 	err = txExec.mempool.Update(
 		state.LastBlockHeight,
-		ttypes.Txs{tx},
+		txs,
 		deliverTxResponses,
 		TxPreCheck(state),
 		TxPostCheck(state),
@@ -179,19 +180,20 @@ func execTxOnProxyApp(
 		return nil, err
 	}
 
-	logger.Info("Executed tx", "txHash", types.TxHash(tx.Hash()))
+	logger.Info("Executed tx", "txHash", types.TxHash(tx))
 
 	return abciResponses, nil
 }
 
-// Fire NewBlock, NewBlockHeader.
 // Fire TxEvent for every tx.
 // NOTE: if Tendermint crashes before commit, some or all of these events may be published again.
-func fireEvents(logger log.Logger, eventBus ttypes.BlockEventPublisher, tx ttypes.Tx, abciResponses *state.ABCIResponses) {
-	eventBus.PublishEventTx(ttypes.EventDataTx{TxResult: ttypes.TxResult{
-		Tx:     tx,
-		Result: *(abciResponses.DeliverTx[0]),
-	}})
+func fireEvents(logger log.Logger, eventBus ttypes.BlockEventPublisher, txs ttypes.Txs, deliverTxs []*abci.ResponseDeliverTx) {
+	for i, tx := range txs {
+		eventBus.PublishEventTx(ttypes.EventDataTx{TxResult: ttypes.TxResult{
+			Tx:     tx,
+			Result: *(deliverTxs[i]),
+		}})
+	}
 }
 
 //----------------------------------------------------------------------------------------------------
@@ -204,10 +206,23 @@ func ExecCommitTx(
 	tx ttypes.Tx,
 	logger log.Logger,
 ) ([]byte, error) {
-	_, err := execTxOnProxyApp(logger, appConnConsensus, tx)
-	if err != nil {
-		logger.Error("Error executing tx on proxy app", "tx_hash", tx.Hash(), "err", err)
-		return nil, err
+	return ExecCommitTxs(appConnConsensus, ttypes.Txs{tx}, logger)
+}
+
+// ExecCommitTxs executes a batch of txs and commits them at once on the
+// proxyApp, like the BatchExecutor.
+// It returns the application root hash (result of abci.Commit).
+func ExecCommitTxs(
+	appConnConsensus proxy.AppConnConsensus,
+	txs ttypes.Txs,
+	logger log.Logger,
+) ([]byte, error) {
+	for _, tx := range txs {
+		_, err := execTxOnProxyApp(logger, appConnConsensus, tx)
+		if err != nil {
+			logger.Error("Error executing tx on proxy app", "tx_hash", tx.Hash(), "err", err)
+			return nil, err
+		}
 	}
 	// Commit block, get hash back
 	res, err := appConnConsensus.CommitSync()
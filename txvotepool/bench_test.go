@@ -1,11 +1,14 @@
 package txvotepool
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/Fantom-foundation/go-txflow/types"
 	"github.com/tendermint/tendermint/abci/example/kvstore"
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/crypto/ed25519"
 	"github.com/tendermint/tendermint/proxy"
 )
 
@@ -17,7 +20,12 @@ func BenchmarkReap(b *testing.B) {
 
 	size := 10000
 	for i := 0; i < size; i++ {
-		tx := types.TxVote{int64(i), types.TxHash([]byte("0x1")), types.TxKey([]byte("0x1")), time.Now(), nil, nil}
+		tx := types.TxVote{
+			Height:    int64(i),
+			TxHash:    types.TxHash([]byte("0x1")),
+			TxKey:     types.TxKey([]byte("0x1")),
+			Timestamp: time.Now(),
+		}
 		txvotepool.CheckTx(tx)
 	}
 	b.ResetTimer()
@@ -30,7 +38,12 @@ func BenchmarkCheckTx(b *testing.B) {
 	defer cleanup()
 
 	for i := 0; i < b.N; i++ {
-		tx := types.TxVote{int64(i), types.TxHash([]byte("0x1")), types.TxKey([]byte("0x1")), time.Now(), nil, nil}
+		tx := types.TxVote{
+			Height:    int64(i),
+			TxHash:    types.TxHash([]byte("0x1")),
+			TxKey:     types.TxKey([]byte("0x1")),
+			Timestamp: time.Now(),
+		}
 		txvotepool.CheckTx(tx)
 	}
 }
@@ -39,7 +52,12 @@ func BenchmarkCacheInsertTime(b *testing.B) {
 	cache := newMapTxCache(b.N)
 	txs := make([]types.TxVote, b.N)
 	for i := 0; i < b.N; i++ {
-		txs[i] = types.TxVote{int64(i), types.TxHash([]byte("0x1")), types.TxKey([]byte("0x1")), time.Now(), nil, nil}
+		txs[i] = types.TxVote{
+			Height:    int64(i),
+			TxHash:    types.TxHash([]byte("0x1")),
+			TxKey:     types.TxKey([]byte("0x1")),
+			Timestamp: time.Now(),
+		}
 	}
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
@@ -53,7 +71,12 @@ func BenchmarkCacheRemoveTime(b *testing.B) {
 	cache := newMapTxCache(b.N)
 	txs := make([]types.TxVote, b.N)
 	for i := 0; i < b.N; i++ {
-		txs[i] = types.TxVote{int64(i), types.TxHash([]byte("0x1")), types.TxKey([]byte("0x1")), time.Now(), nil, nil}
+		txs[i] = types.TxVote{
+			Height:    int64(i),
+			TxHash:    types.TxHash([]byte("0x1")),
+			TxKey:     types.TxKey([]byte("0x1")),
+			Timestamp: time.Now(),
+		}
 		cache.Push(txs[i])
 	}
 	b.ResetTimer()
@@ -61,3 +84,67 @@ func BenchmarkCacheRemoveTime(b *testing.B) {
 		cache.Remove(txs[i])
 	}
 }
+
+// The signatures of 100 validators voting on 10 txs, verified one by one as
+// the vote sets used to, then by the SigVerifier.
+func benchmarkVotes(b *testing.B) ([]*types.TxVote, []crypto.PubKey) {
+	keys := make([]crypto.PrivKey, 100)
+	for i := range keys {
+		keys[i] = ed25519.GenPrivKey()
+	}
+	return signedVotes(b, keys, 10)
+}
+
+func BenchmarkVerifySerial(b *testing.B) {
+	votes, pubKeys := benchmarkVotes(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j, vote := range votes {
+			if err := vote.Verify(sigTestChainID, pubKeys[j]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkSigVerifier(b *testing.B) {
+	votes, pubKeys := benchmarkVotes(b)
+	sv := NewSigVerifier(sigTestChainID, SigVerifierWithCacheSize(0))
+	if err := sv.Start(); err != nil {
+		b.Fatal(err)
+	}
+	defer sv.Stop()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sv.Verify(votes, pubKeys)
+	}
+}
+
+// The caller verifies batches too, so n workers verify on up to n+1 CPUs.
+func BenchmarkSigVerifierWorkers(b *testing.B) {
+	votes, pubKeys := benchmarkVotes(b)
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			sv := NewSigVerifier(sigTestChainID, SigVerifierWithWorkers(workers), SigVerifierWithCacheSize(0))
+			if err := sv.Start(); err != nil {
+				b.Fatal(err)
+			}
+			defer sv.Stop()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				sv.Verify(votes, pubKeys)
+			}
+		})
+	}
+}
+
+// Every vote is a gossip duplicate of one verified before.
+func BenchmarkSigVerifierCached(b *testing.B) {
+	votes, pubKeys := benchmarkVotes(b)
+	sv := NewSigVerifier(sigTestChainID)
+	sv.Verify(votes, pubKeys)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sv.Verify(votes, pubKeys)
+	}
+}
@@ -18,8 +18,8 @@ func TestCacheRemove(t *testing.T) {
 	numTxs := 10
 	txs := make([]types.TxVote, numTxs)
 	for i := 0; i < numTxs; i++ {
-		tx := ttypes.Tx(string(i))
-		txs[i] = types.TxVote{int64(i), types.TxHash(tx), types.TxKey(tx), time.Now(), nil, tx}
+		tx := ttypes.Tx(string(rune(i)))
+		txs[i] = types.TxVote{Height: int64(i), TxHash: types.TxHash(tx), TxKey: types.TxKey(tx), Timestamp: time.Now(), Signature: tx}
 		cache.Push(txs[i])
 		// make sure its added to both the linked list and the map
 		require.Equal(t, i+1, cache.list.Len())
@@ -55,20 +55,20 @@ func TestCacheAfterUpdate(t *testing.T) {
 	}
 	for tcIndex, tc := range tests {
 		for i := 0; i < tc.numTxsToCreate; i++ {
-			tx := types.TxVote{int64(i), types.TxHash([]byte("0x1")), types.TxKey([]byte("0x1")), time.Now(), nil, []byte("0x1")}
+			tx := types.TxVote{Height: int64(i), TxHash: types.TxHash([]byte("0x1")), TxKey: types.TxKey([]byte("0x1")), Timestamp: time.Now(), Signature: []byte("0x1")}
 			err := txvotepool.CheckTx(tx)
 			require.NoError(t, err)
 		}
 
 		updateTxs := []types.TxVote{}
 		for _, v := range tc.updateIndices {
-			tx := types.TxVote{int64(v), types.TxHash([]byte("0x1")), types.TxKey([]byte("0x1")), time.Now(), nil, []byte("0x1")}
+			tx := types.TxVote{Height: int64(v), TxHash: types.TxHash([]byte("0x1")), TxKey: types.TxKey([]byte("0x1")), Timestamp: time.Now(), Signature: []byte("0x1")}
 			updateTxs = append(updateTxs, tx)
 		}
 		txvotepool.Update(1, updateTxs)
 
 		for _, v := range tc.reAddIndices {
-			tx := types.TxVote{int64(v), types.TxHash([]byte("0x1")), types.TxKey([]byte("0x1")), time.Now(), nil, []byte("0x1")}
+			tx := types.TxVote{Height: int64(v), TxHash: types.TxHash([]byte("0x1")), TxKey: types.TxKey([]byte("0x1")), Timestamp: time.Now(), Signature: []byte("0x1")}
 			_ = txvotepool.CheckTx(tx)
 		}
 
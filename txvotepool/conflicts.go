@@ -0,0 +1,136 @@
+package txvotepool
+
+import (
+	"fmt"
+	"sync"
+
+	dbm "github.com/tendermint/tendermint/libs/db"
+)
+
+/*
+conflictGuard remembers the conflict keys of the txs this validator signed.
+A key is held by the first signed tx until that tx is final: committed,
+rejected or ordered by a tick block. Other txs touching a held key are not
+signed; they stay in the mempool and get ordered by a tick block instead.
+
+Tx votes never expire, so neither do the locks: they are saved before the
+vote is signed and reloaded on start, so a restarted validator doesn't sign a
+tx conflicting with one it accepted before.
+
+Schema:
+
+"conflictTx:"<txHash> -> conflict keys held by the tx
+*/
+type conflictGuard struct {
+	mtx sync.Mutex
+	db  dbm.DB
+
+	// conflict key -> holding tx
+	keys map[string]string
+	// txHash -> conflict keys it holds
+	txKeys map[string][]string
+}
+
+func calcConflictTxKey(txHash string) []byte {
+	return []byte(fmt.Sprintf("conflictTx:%v", txHash))
+}
+
+// newConflictGuard returns a conflictGuard with the locks saved in db.
+func newConflictGuard(db dbm.DB) *conflictGuard {
+	cg := &conflictGuard{
+		db:     db,
+		keys:   make(map[string]string),
+		txKeys: make(map[string][]string),
+	}
+
+	prefix := calcConflictTxKey("")
+	iter := dbm.IteratePrefix(db, prefix)
+	defer iter.Close()
+	for ; iter.Valid(); iter.Next() {
+		var keys []string
+		if err := cdc.UnmarshalJSON(iter.Value(), &keys); err != nil {
+			panic(fmt.Sprintf("Error reading conflict keys: %v", err))
+		}
+		txHash := string(iter.Key()[len(prefix):])
+		for _, key := range keys {
+			cg.keys[key] = txHash
+		}
+		cg.txKeys[txHash] = keys
+	}
+	return cg
+}
+
+// acquire takes all keys for txHash. It returns false, taking none of them,
+// if any key is held by another tx.
+func (cg *conflictGuard) acquire(txHash string, keys []string) bool {
+	if len(keys) == 0 {
+		return true
+	}
+
+	cg.mtx.Lock()
+	defer cg.mtx.Unlock()
+
+	if _, ok := cg.txKeys[txHash]; ok {
+		// signed before
+		return true
+	}
+	for _, key := range keys {
+		if holder, ok := cg.keys[key]; ok && holder != txHash {
+			return false
+		}
+	}
+
+	// The lock must survive a crash after the vote is signed
+	cg.db.SetSync(calcConflictTxKey(txHash), cdc.MustMarshalJSON(keys))
+	for _, key := range keys {
+		cg.keys[key] = txHash
+	}
+	cg.txKeys[txHash] = keys
+	return true
+}
+
+// release frees the keys held by txHash, once it is final.
+func (cg *conflictGuard) release(txHash string) {
+	cg.mtx.Lock()
+	defer cg.mtx.Unlock()
+	cg.releaseLocked(txHash)
+}
+
+// releaseFinal frees the keys held by the txs which are final and returns
+// their number.
+func (cg *conflictGuard) releaseFinal(isFinal func(txHash string) bool) int {
+	cg.mtx.Lock()
+	defer cg.mtx.Unlock()
+
+	released := 0
+	for txHash := range cg.txKeys {
+		if isFinal(txHash) {
+			cg.releaseLocked(txHash)
+			released++
+		}
+	}
+	return released
+}
+
+// size returns the number of txs holding keys.
+func (cg *conflictGuard) size() int {
+	cg.mtx.Lock()
+	defer cg.mtx.Unlock()
+	return len(cg.txKeys)
+}
+
+// releaseLocked assumes cg.mtx is held.
+// A release lost in a crash is redone by the next releaseFinal.
+func (cg *conflictGuard) releaseLocked(txHash string) {
+	keys, ok := cg.txKeys[txHash]
+	if !ok {
+		return
+	}
+	for _, key := range keys {
+		if cg.keys[key] == txHash {
+			delete(cg.keys, key)
+		}
+	}
+	delete(cg.txKeys, txHash)
+	cg.db.Delete(calcConflictTxKey(txHash))
+}
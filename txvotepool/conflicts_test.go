@@ -0,0 +1,50 @@
+package txvotepool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	dbm "github.com/tendermint/tendermint/libs/db"
+)
+
+func TestConflictGuard(t *testing.T) {
+	cg := newConflictGuard(dbm.NewMemDB())
+
+	// txs without conflict keys never conflict
+	assert.True(t, cg.acquire("tx0", nil))
+
+	assert.True(t, cg.acquire("tx1", []string{"acc/1", "obj/a"}))
+	// the same tx may be signed again
+	assert.True(t, cg.acquire("tx1", []string{"acc/1", "obj/a"}))
+	// a second tx touching one of the keys is refused, and takes no key
+	assert.False(t, cg.acquire("tx2", []string{"obj/b", "obj/a"}))
+	assert.True(t, cg.acquire("tx3", []string{"obj/b"}))
+
+	// once tx1 commits its keys are free
+	cg.release("tx1")
+	assert.True(t, cg.acquire("tx2", []string{"obj/a"}))
+}
+
+func TestConflictGuardReleaseFinal(t *testing.T) {
+	db := dbm.NewMemDB()
+	cg := newConflictGuard(db)
+
+	assert.True(t, cg.acquire("tx1", []string{"acc/1"}))
+	assert.True(t, cg.acquire("tx2", []string{"acc/2"}))
+
+	// the locks survive a restart
+	cg = newConflictGuard(db)
+	assert.False(t, cg.acquire("tx3", []string{"acc/1"}))
+	assert.Equal(t, 2, cg.size())
+
+	// and are only released once the tx is final
+	isFinal := func(txHash string) bool { return txHash == "tx1" }
+	assert.Equal(t, 1, cg.releaseFinal(isFinal))
+	assert.True(t, cg.acquire("tx3", []string{"acc/1"}))
+	assert.False(t, cg.acquire("tx4", []string{"acc/2"}))
+
+	cg = newConflictGuard(db)
+	assert.Equal(t, 2, cg.size())
+	assert.False(t, cg.acquire("tx1", []string{"acc/1"}))
+}
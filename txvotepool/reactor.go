@@ -44,9 +44,18 @@ type Reactor struct {
 	config     *cfg.MempoolConfig
 	mempool    *mempool.CListMempool
 	txVotePool *TxVotePool
-	state      *state.State // State until height-1.
 	privVal    types.PrivValidator
 	ids        *txVotePoolIDs
+
+	// votes are signed for the last block height, by the validators of the
+	// next block, see SetHeight
+	mtx        sync.RWMutex
+	chainID    string
+	height     int64
+	validators *ttypes.ValidatorSet
+
+	// txs which failed CheckTx, to sign reject votes for
+	rejected chan rejectedTx
 }
 
 // NewReactor returns a new TxpoolReactor with the given config and txpool.
@@ -60,11 +69,17 @@ func NewReactor(config *cfg.MempoolConfig,
 		config:     config,
 		mempool:    mempool,
 		txVotePool: txVotePool,
-		state:      state,
 		privVal:    privVal,
 		ids:        newTxVotePoolIDs(),
+		rejected:   make(chan rejectedTx, rejectQueueSize),
+	}
+	if state != nil {
+		txR.chainID = state.ChainID
+		txR.height = state.LastBlockHeight
+		txR.validators = state.Validators
 	}
 	txR.BaseReactor = *p2p.NewBaseReactor("TxVotePoolReactor", txR)
+	mempool.SetRejectedTxHandler(txR.onRejectedTx)
 	return txR
 }
 
@@ -75,12 +90,34 @@ func (txR *Reactor) SetLogger(l log.Logger) {
 	txR.mempool.SetLogger(l)
 }
 
+// SetHeight sets the last block height, the height new votes are signed for,
+// and the validators of the next block, which sign them. The TxFlow calls it
+// with every tick block.
+func (txR *Reactor) SetHeight(height int64, validators *ttypes.ValidatorSet) {
+	txR.mtx.Lock()
+	defer txR.mtx.Unlock()
+	txR.height = height
+	txR.validators = validators
+}
+
+// signHeight returns the height to sign votes for, and whether this node is
+// one of the validators signing them.
+func (txR *Reactor) signHeight() (int64, bool) {
+	txR.mtx.RLock()
+	defer txR.mtx.RUnlock()
+	if txR.privVal == nil || txR.validators == nil {
+		return txR.height, false
+	}
+	return txR.height, txR.validators.HasAddress(txR.privVal.GetPubKey().Address())
+}
+
 // OnStart implements p2p.BaseReactor.
 func (txR *Reactor) OnStart() error {
 	if !txR.config.Broadcast {
 		txR.Logger.Info("Tx broadcasting is disabled")
 	}
 	go txR.signTxRoutine()
+	go txR.signRejectRoutine()
 	return nil
 }
 
@@ -109,23 +146,29 @@ func (txR *Reactor) signTxRoutine() {
 		memTx := next.Value.(*mempool.MempoolTx)
 
 		//We keep the routine running since we could turn into a validator at any round
-		if _, val := txR.state.Validators.GetByAddress(txR.privVal.GetPubKey().Address()); val != nil {
+		height, isValidator := txR.signHeight()
+		txHash := types.TxHash(memTx.Tx)
+		if isValidator && !txR.txVotePool.AcquireConflictKeys(txHash, memTx.ConflictKeys) {
+			// Never sign two conflicting txs, this one waits for a tick block
+			txR.Logger.Info("Not signing conflicting tx", "tx", txHash, "keys", memTx.ConflictKeys)
+		} else if isValidator {
 			//Only sign if I'm a validator
 			txVote := types.NewTxVote(
-				txR.state.LastBlockHeight,
-				types.TxHash(memTx.Tx),
+				height,
+				txHash,
 				types.TxKey(memTx.Tx),
 				txR.privVal.GetPubKey().Address(),
 			)
-			err := txR.privVal.SignTxVote(txR.state.ChainID, &txVote)
-			if err != nil {
-				//panic error here
+			if err := txR.privVal.SignTxVote(txR.chainID, &txVote); err != nil {
+				// The privVal refuses to sign a vote conflicting with one it
+				// signed before, e.g. after rejecting the tx.
+				txR.Logger.Error("Error signing vote", "tx", txHash, "err", err)
+			} else {
+				//This could fail, need another mechanism to run through missing transactions
+				//Should have a 1:1 parity
+				//Tx is signed at this point, and propagated outwards
+				txR.txVotePool.CheckTx(txVote)
 			}
-			//This could fail, need another mechanism to run through missing transactions
-			//Should have a 1:1 parity
-			//Tx is signed at this point, and propagated outwards
-			txR.txVotePool.CheckTx(txVote)
-
 		}
 
 		select {
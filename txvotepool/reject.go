@@ -0,0 +1,102 @@
+package txvotepool
+
+import (
+	"container/list"
+
+	"github.com/Fantom-foundation/go-txflow/types"
+	abci "github.com/tendermint/tendermint/abci/types"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+const (
+	// rejected txs waiting to be signed, more are dropped
+	rejectQueueSize = 1000
+	// number of rejected txs remembered, so they are not signed twice
+	rejectCacheSize = 10000
+)
+
+type rejectedTx struct {
+	tx  ttypes.Tx
+	res *abci.ResponseCheckTx
+}
+
+// rejectCache remembers the most recently rejected txs. It is only used by
+// signRejectRoutine and is not safe for concurrent use.
+type rejectCache struct {
+	size  int
+	txs   map[string]*list.Element
+	order *list.List
+}
+
+func newRejectCache(size int) *rejectCache {
+	return &rejectCache{
+		size:  size,
+		txs:   make(map[string]*list.Element, size),
+		order: list.New(),
+	}
+}
+
+// push adds txHash and returns false if it was already there.
+func (rc *rejectCache) push(txHash string) bool {
+	if _, ok := rc.txs[txHash]; ok {
+		return false
+	}
+	if rc.order.Len() >= rc.size {
+		front := rc.order.Front()
+		delete(rc.txs, front.Value.(string))
+		rc.order.Remove(front)
+	}
+	rc.txs[txHash] = rc.order.PushBack(txHash)
+	return true
+}
+
+// onRejectedTx queues a tx which failed CheckTx for signRejectRoutine. It is
+// called from the mempool's app callback, so it never blocks.
+func (txR *Reactor) onRejectedTx(tx ttypes.Tx, res *abci.ResponseCheckTx) {
+	select {
+	case txR.rejected <- rejectedTx{tx: tx, res: res}:
+	default:
+		txR.Logger.Debug("Reject queue is full, not voting", "tx", types.TxHash(tx))
+	}
+}
+
+// Sign reject votes for the txs which failed CheckTx, so clients learn early
+// that they will never commit. Each tx is rejected only once: a second reject
+// vote at the same height would be a conflicting vote.
+func (txR *Reactor) signRejectRoutine() {
+	signed := newRejectCache(rejectCacheSize)
+	for {
+		select {
+		case rejected := <-txR.rejected:
+			height, isValidator := txR.signHeight()
+			if !isValidator {
+				continue
+			}
+			txHash := types.TxHash(rejected.tx)
+			if !signed.push(txHash) {
+				continue
+			}
+			res := *rejected.res
+			if len(res.Codespace) > types.MaxCodespaceBytes {
+				res.Codespace = res.Codespace[:types.MaxCodespaceBytes]
+			}
+			txVote := types.NewRejectTxVote(
+				height,
+				txHash,
+				types.TxKey(rejected.tx),
+				&res,
+				txR.privVal.GetPubKey().Address(),
+			)
+			if err := txR.privVal.SignTxVote(txR.chainID, &txVote); err != nil {
+				txR.Logger.Error("Error signing reject vote", "tx", txHash, "err", err)
+				continue
+			}
+			txR.Logger.Info("Rejecting tx", "tx", txHash, "code", res.Code, "codespace", res.Codespace)
+			if err := txR.txVotePool.CheckTx(txVote); err != nil {
+				txR.Logger.Info("Could not add reject vote", "tx", txHash, "err", err)
+			}
+		case <-txR.Quit():
+			return
+		}
+	}
+}
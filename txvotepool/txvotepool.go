@@ -14,6 +14,7 @@ import (
 	auto "github.com/tendermint/tendermint/libs/autofile"
 	"github.com/tendermint/tendermint/libs/clist"
 	cmn "github.com/tendermint/tendermint/libs/common"
+	dbm "github.com/tendermint/tendermint/libs/db"
 	"github.com/tendermint/tendermint/libs/log"
 	"github.com/tendermint/tendermint/mempool"
 )
@@ -44,6 +45,11 @@ type TxVotePool struct {
 
 	// A log of mempool txs
 	wal *auto.AutoFile
+	// votes committed since the WAL was last trimmed (atomic)
+	walCommitted int64
+
+	// conflict keys of the txs we signed
+	conflicts *conflictGuard
 
 	logger log.Logger
 
@@ -60,11 +66,12 @@ func NewTxVotePool(
 	options ...TxVotePoolOption,
 ) *TxVotePool {
 	txVotePool := &TxVotePool{
-		config:  config,
-		txs:     clist.New(),
-		height:  height,
-		logger:  log.NewNopLogger(),
-		metrics: mempool.NopMetrics(),
+		config:    config,
+		txs:       clist.New(),
+		height:    height,
+		conflicts: newConflictGuard(dbm.NewMemDB()),
+		logger:    log.NewNopLogger(),
+		metrics:   mempool.NopMetrics(),
 	}
 	if config.CacheSize > 0 {
 		txVotePool.cache = newMapTxCache(config.CacheSize)
@@ -94,6 +101,28 @@ func WithMetrics(metrics *mempool.Metrics) TxVotePoolOption {
 	return func(txVotePool *TxVotePool) { txVotePool.metrics = metrics }
 }
 
+// WithConflictDB sets the database the conflict keys of the signed txs are
+// saved in, so they survive a restart. Without it they are kept in memory.
+func WithConflictDB(db dbm.DB) TxVotePoolOption {
+	return func(txVotePool *TxVotePool) { txVotePool.conflicts = newConflictGuard(db) }
+}
+
+// AcquireConflictKeys reserves the conflict keys of a tx before it is signed.
+// It returns false if another signed tx holds one of the keys, in which case
+// the tx must not be signed and is left to the tick block path.
+// The keys are held until the tx is final, see ReleaseConflictKeys.
+func (txVotePool *TxVotePool) AcquireConflictKeys(txHash string, keys []string) bool {
+	return txVotePool.conflicts.acquire(txHash, keys)
+}
+
+// ReleaseConflictKeys frees the conflict keys held by the signed txs which
+// are final: committed, rejected or ordered by a tick block. Update frees
+// those of the txs whose votes it drops, this catches the others, e.g. txs
+// ordered by a block without a vote set.
+func (txVotePool *TxVotePool) ReleaseConflictKeys(isFinal func(txHash string) bool) int {
+	return txVotePool.conflicts.releaseFinal(isFinal)
+}
+
 // InitWAL creates a directory for the WAL file and opens a file itself.
 //
 // *panics* if can't create directory or open file.
@@ -229,17 +258,7 @@ func (txVotePool *TxVotePool) CheckTxWithInfo(tx types.TxVote, txInfo mempool.Tx
 	// END CACHE
 
 	// WAL
-	if txVotePool.wal != nil {
-		// TODO: Notify administrators when WAL fails
-		_, err := txVotePool.wal.Write([]byte(cdc.MustMarshalBinaryBare(tx)))
-		if err != nil {
-			txVotePool.logger.Error("Error writing to WAL", "err", err)
-		}
-		_, err = txVotePool.wal.Write([]byte("\n"))
-		if err != nil {
-			txVotePool.logger.Error("Error writing to WAL", "err", err)
-		}
-	}
+	txVotePool.writeWAL(tx)
 	// END WAL
 
 	memTxVote := &MempoolTxVote{
@@ -335,8 +354,12 @@ func (txVotePool *TxVotePool) Update(
 	txVotePool.height = height
 	txVotePool.notifiedTxsAvailable = false
 
+	atomic.AddInt64(&txVotePool.walCommitted, int64(len(txs)))
+
 	// Add committed transactions to cache (if missing).
 	for _, tx := range txs {
+		// a final tx no longer needs its conflict keys
+		txVotePool.conflicts.release(tx.TxHash)
 		_ = txVotePool.cache.Push(tx)
 		if e, ok := txVotePool.txsMap.Load(txVoteKey(tx)); ok {
 			txVotePool.removeTx(tx, e.(*clist.CElement), false)
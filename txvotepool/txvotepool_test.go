@@ -72,7 +72,7 @@ func checkTxs(t *testing.T, txvotepool *TxVotePool, count int, peerID uint16) []
 		txBytes := make([]byte, 20)
 		tx := ttypes.Tx(txBytes)
 		_, err := rand.Read(txBytes)
-		txs[i] = types.TxVote{int64(i), types.TxHash(tx), types.TxKey(tx), time.Now(), nil, nil}
+		txs[i] = types.TxVote{Height: int64(i), TxHash: types.TxHash(tx), TxKey: types.TxKey(tx), Timestamp: time.Now()}
 		if err != nil {
 			t.Error(err)
 		}
@@ -180,9 +180,9 @@ func TestSerialReap(t *testing.T) {
 	deliverTxsRange := func(start, end int) {
 		// Deliver some txs.
 		for i := start; i < end; i++ {
-			tx := ttypes.Tx(string(i))
+			tx := ttypes.Tx(string(rune(i)))
 			// This will succeed
-			txVote := types.TxVote{int64(i), types.TxHash(tx), types.TxKey(tx), time.Now(), nil, nil}
+			txVote := types.TxVote{Height: int64(i), TxHash: types.TxHash(tx), TxKey: types.TxKey(tx), Timestamp: time.Now()}
 			err := txvotepool.CheckTx(txVote)
 			_, cached := cacheMap[TxVoteID(txVote)]
 			if cached {
@@ -201,8 +201,8 @@ func TestSerialReap(t *testing.T) {
 	updateRange := func(start, end int) {
 		txs := make([]types.TxVote, 0)
 		for i := start; i < end; i++ {
-			tx := ttypes.Tx(string(i))
-			txVote := types.TxVote{int64(i), types.TxHash(tx), types.TxKey(tx), time.Now(), nil, nil}
+			tx := ttypes.Tx(string(rune(i)))
+			txVote := types.TxVote{Height: int64(i), TxHash: types.TxHash(tx), TxKey: types.TxKey(tx), Timestamp: time.Now()}
 			txs = append(txs, txVote)
 		}
 		if err := txvotepool.Update(3, txs); err != nil {
@@ -274,8 +274,8 @@ func TestMempoolCloseWAL(t *testing.T) {
 	require.Equal(t, 1, len(m2), "expecting the wal match in")
 
 	// 5. Write some contents to the WAL
-	tx := ttypes.Tx(string(1))
-	txvotepool.CheckTx(types.TxVote{int64(1), types.TxHash(tx), types.TxKey(tx), time.Now(), nil, nil})
+	tx := ttypes.Tx(string(rune(1)))
+	txvotepool.CheckTx(types.TxVote{Height: int64(1), TxHash: types.TxHash(tx), TxKey: types.TxKey(tx), Timestamp: time.Now()})
 	walFilepath := txvotepool.wal.Path
 	sum1 := checksumFile(walFilepath, t)
 
@@ -285,7 +285,7 @@ func TestMempoolCloseWAL(t *testing.T) {
 	// 7. Invoke CloseWAL() and ensure it discards the
 	// WAL thus any other write won't go through.
 	txvotepool.CloseWAL()
-	txvotepool.CheckTx(types.TxVote{int64(1), types.TxHash(tx), types.TxKey(tx), time.Now(), nil, nil})
+	txvotepool.CheckTx(types.TxVote{Height: int64(1), TxHash: types.TxHash(tx), TxKey: types.TxKey(tx), Timestamp: time.Now()})
 	sum2 := checksumFile(walFilepath, t)
 	require.Equal(t, sum1, sum2, "expected no change to the WAL after invoking CloseWAL() since it was discarded")
 
@@ -295,6 +295,52 @@ func TestMempoolCloseWAL(t *testing.T) {
 	require.Equal(t, 1, len(m3), "expecting the wal match in")
 }
 
+func TestTxVotePoolReplayWAL(t *testing.T) {
+	rootDir, err := ioutil.TempDir("", "txvotepool-wal-test")
+	require.Nil(t, err, "expecting successful tmpdir creation")
+	defer os.RemoveAll(rootDir)
+
+	wcfg := cfg.DefaultMempoolConfig()
+	wcfg.RootDir = rootDir
+	txvotepool := NewTxVotePool(wcfg, 10)
+	txvotepool.InitWAL()
+
+	// the cache tells votes apart by their signature
+	pv := types.NewMockPV()
+	votes := make([]types.TxVote, 3)
+	for i := range votes {
+		tx := ttypes.Tx(fmt.Sprintf("tx%d", i))
+		votes[i] = types.NewTxVote(1, types.TxHash(tx), types.TxKey(tx), pv.GetPubKey().Address())
+		require.NoError(t, pv.SignTxVote("test_chain_id", &votes[i]))
+		require.NoError(t, txvotepool.CheckTx(votes[i]))
+	}
+	walFilepath := txvotepool.wal.Path
+	txvotepool.CloseWAL()
+
+	// a crash in the middle of a write leaves a truncated record behind
+	f, err := os.OpenFile(walFilepath, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{50, 1, 2, 3})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	// restart, votes[1] was committed in the meantime
+	txvotepool = NewTxVotePool(wcfg, 10)
+	txvotepool.InitWAL()
+	defer txvotepool.CloseWAL()
+	replayed, err := txvotepool.ReplayWAL(func(txHash string) bool { return txHash == votes[1].TxHash })
+	require.NoError(t, err)
+	assert.Equal(t, 2, replayed)
+	assert.Equal(t, 2, txvotepool.Size())
+
+	// the WAL is rewritten without the committed vote and the truncated record
+	walVotes, err := readWAL(walFilepath)
+	require.NoError(t, err)
+	require.Len(t, walVotes, 2)
+	assert.Equal(t, votes[0].TxHash, walVotes[0].TxHash)
+	assert.Equal(t, votes[2].TxHash, walVotes[1].TxHash)
+}
+
 // Size of the amino encoded TxMessage is the length of the
 // encoded byte array, plus 1 for the struct field, plus 4
 // for the amino prefix.
@@ -334,8 +380,8 @@ func TestMempoolMaxMsgSize(t *testing.T) {
 	for i, testCase := range testCases {
 		caseString := fmt.Sprintf("case %d, len %d", i, testCase.len)
 
-		tx := ttypes.Tx(string(i))
-		txVote := types.TxVote{int64(i), types.TxHash(tx), types.TxKey(tx), time.Now(), nil, tx}
+		tx := ttypes.Tx(string(rune(i)))
+		txVote := types.TxVote{Height: int64(i), TxHash: types.TxHash(tx), TxKey: types.TxKey(tx), Timestamp: time.Now(), Signature: tx}
 		err := txvotepool.CheckTx(txVote)
 		msg := &TxVoteMessage{txVote}
 		encoded := cdc.MustMarshalBinaryBare(msg)
@@ -363,18 +409,18 @@ func TestMempoolTxsBytes(t *testing.T) {
 	assert.EqualValues(t, 0, txvotepool.TxsBytes())
 
 	// 2. len(tx) after CheckTx
-	tx := ttypes.Tx(string(1))
-	err := txvotepool.CheckTx(types.TxVote{int64(1), types.TxHash(tx), types.TxKey(tx), time.Now(), nil, nil})
+	tx := ttypes.Tx(string(rune(1)))
+	err := txvotepool.CheckTx(types.TxVote{Height: int64(1), TxHash: types.TxHash(tx), TxKey: types.TxKey(tx), Timestamp: time.Now()})
 	require.NoError(t, err)
 	assert.EqualValues(t, 1, txvotepool.TxsBytes())
 
 	// 3. zero again after tx is removed by Update
-	txvotepool.Update(1, []types.TxVote{{int64(1), types.TxHash(tx), types.TxKey(tx), time.Now(), nil, nil}})
+	txvotepool.Update(1, []types.TxVote{{Height: int64(1), TxHash: types.TxHash(tx), TxKey: types.TxKey(tx), Timestamp: time.Now()}})
 	assert.EqualValues(t, 0, txvotepool.TxsBytes())
 
 	// 4. zero after Flush
-	tx = ttypes.Tx(string(2))
-	err = txvotepool.CheckTx(types.TxVote{int64(2), types.TxHash(tx), types.TxKey(tx), time.Now(), nil, nil})
+	tx = ttypes.Tx(string(rune(2)))
+	err = txvotepool.CheckTx(types.TxVote{Height: int64(2), TxHash: types.TxHash(tx), TxKey: types.TxKey(tx), Timestamp: time.Now()})
 	require.NoError(t, err)
 	assert.EqualValues(t, 2, txvotepool.TxsBytes())
 
@@ -382,11 +428,11 @@ func TestMempoolTxsBytes(t *testing.T) {
 	assert.EqualValues(t, 0, txvotepool.TxsBytes())
 
 	// 5. ErrMempoolIsFull is returned when/if MaxTxsBytes limit is reached.
-	tx = ttypes.Tx(string(4))
-	err = txvotepool.CheckTx(types.TxVote{int64(4), types.TxHash(tx), types.TxKey(tx), time.Now(), nil, nil})
+	tx = ttypes.Tx(string(rune(4)))
+	err = txvotepool.CheckTx(types.TxVote{Height: int64(4), TxHash: types.TxHash(tx), TxKey: types.TxKey(tx), Timestamp: time.Now()})
 	require.NoError(t, err)
-	tx = ttypes.Tx(string(5))
-	err = txvotepool.CheckTx(types.TxVote{int64(5), types.TxHash(tx), types.TxKey(tx), time.Now(), nil, nil})
+	tx = ttypes.Tx(string(rune(5)))
+	err = txvotepool.CheckTx(types.TxVote{Height: int64(5), TxHash: types.TxHash(tx), TxKey: types.TxKey(tx), Timestamp: time.Now()})
 	if assert.Error(t, err) {
 		assert.IsType(t, mempool.ErrMempoolIsFull{}, err)
 	}
@@ -397,8 +443,8 @@ func TestMempoolTxsBytes(t *testing.T) {
 	txvotepool, _, cleanup = newMempoolWithApp(cc)
 	defer cleanup()
 
-	tx = ttypes.Tx(string(0))
-	err = txvotepool.CheckTx(types.TxVote{int64(0), types.TxHash(tx), types.TxKey(tx), time.Now(), nil, nil})
+	tx = ttypes.Tx(string(rune(0)))
+	err = txvotepool.CheckTx(types.TxVote{Height: int64(0), TxHash: types.TxHash(tx), TxKey: types.TxKey(tx), Timestamp: time.Now()})
 	require.NoError(t, err)
 	assert.EqualValues(t, 8, txvotepool.TxsBytes())
 
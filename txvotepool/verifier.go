@@ -0,0 +1,324 @@
+package txvotepool
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/hdevalence/ed25519consensus"
+	"github.com/pkg/errors"
+
+	"github.com/Fantom-foundation/go-txflow/types"
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	cmn "github.com/tendermint/tendermint/libs/common"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+const (
+	// DefaultSigBatchSize is the most signatures a worker verifies at once
+	DefaultSigBatchSize = 64
+	// DefaultSigCacheSize is the number of verification results kept
+	DefaultSigCacheSize = 100000
+)
+
+var errUnknownSigner = errors.New("Unknown signer")
+
+/*
+SigVerifier verifies the signatures of tx votes on a pool of workers, before
+the votes reach their TxVoteSet, which then only has to count them (see
+TxVoteSet.AddPreverifiedVote).
+
+The votes handed to Verify are split in batches of up to batchSize
+signatures, one per worker; the caller verifies the batches no worker is
+free for. The ed25519 signatures of a batch are verified
+at once, which is much faster than one by one. If the batch fails, its
+signatures are verified one by one to find the bad ones. Other key types are
+always verified one by one.
+
+Both ed25519 checks follow the ZIP-215 rules, so the outcome does not depend
+on how the votes were batched. They accept every signature the plain ed25519
+check of TxVote.Verify accepts, plus a few crafted ones: a light client
+checking a commit never counts a vote the nodes did not.
+
+Results are cached by vote hash, over the sign bytes, the signature and the
+signer, so the copies of a vote gossiped by each peer are verified once.
+*/
+type SigVerifier struct {
+	cmn.BaseService
+
+	chainID   string
+	workers   int
+	batchSize int
+
+	jobs  chan *sigJob
+	cache *sigCache
+
+	// signatures actually verified (atomic)
+	numVerified int64
+}
+
+// SigVerifierOption sets an optional parameter on the SigVerifier.
+type SigVerifierOption func(*SigVerifier)
+
+// SigVerifierWithWorkers sets the number of workers, runtime.NumCPU() by
+// default.
+func SigVerifierWithWorkers(workers int) SigVerifierOption {
+	return func(sv *SigVerifier) { sv.workers = workers }
+}
+
+// SigVerifierWithBatchSize sets the most signatures a worker verifies at
+// once.
+func SigVerifierWithBatchSize(batchSize int) SigVerifierOption {
+	return func(sv *SigVerifier) { sv.batchSize = batchSize }
+}
+
+// SigVerifierWithCacheSize sets the number of verification results kept,
+// 0 disables the cache.
+func SigVerifierWithCacheSize(cacheSize int) SigVerifierOption {
+	return func(sv *SigVerifier) { sv.cache = newSigCache(cacheSize) }
+}
+
+// NewSigVerifier returns a new SigVerifier for the votes of the chain.
+// Until it is started, Verify does all the work on the calling goroutine.
+func NewSigVerifier(chainID string, options ...SigVerifierOption) *SigVerifier {
+	sv := &SigVerifier{
+		chainID:   chainID,
+		workers:   runtime.NumCPU(),
+		batchSize: DefaultSigBatchSize,
+		jobs:      make(chan *sigJob),
+		cache:     newSigCache(DefaultSigCacheSize),
+	}
+	for _, option := range options {
+		option(sv)
+	}
+	if sv.workers < 1 || sv.batchSize < 1 {
+		panic("SigVerifier needs at least one worker and a batch size of at least 1")
+	}
+	sv.BaseService = *cmn.NewBaseService(nil, "SigVerifier", sv)
+	return sv
+}
+
+// OnStart implements cmn.Service by starting the workers.
+func (sv *SigVerifier) OnStart() error {
+	for i := 0; i < sv.workers; i++ {
+		go sv.workerRoutine()
+	}
+	return nil
+}
+
+func (sv *SigVerifier) workerRoutine() {
+	for {
+		select {
+		case job := <-sv.jobs:
+			sv.verifyJob(job)
+		case <-sv.Quit():
+			return
+		}
+	}
+}
+
+// Verify verifies the signature of each vote against the key at the same
+// index, and returns the error of each, nil if the signature is valid. A
+// nil key fails the vote.
+func (sv *SigVerifier) Verify(votes []*types.TxVote, pubKeys []crypto.PubKey) []error {
+	if len(votes) != len(pubKeys) {
+		panic("SigVerifier.Verify needs a key per vote")
+	}
+	errs := make([]error, len(votes))
+	var jobs []*sigJob
+	var job *sigJob
+	for i, vote := range votes {
+		pubKey := pubKeys[i]
+		if pubKey == nil {
+			errs[i] = errUnknownSigner
+			continue
+		}
+		if !bytes.Equal(pubKey.Address(), vote.ValidatorAddress) {
+			errs[i] = ttypes.ErrVoteInvalidValidatorAddress
+			continue
+		}
+		if job == nil || len(job.indices) == sv.batchSize {
+			job = &sigJob{errs: errs}
+			jobs = append(jobs, job)
+		}
+		job.add(i, vote, pubKey)
+	}
+	if len(jobs) == 0 {
+		return errs
+	}
+
+	// Jobs no worker is free for, and the last one, are verified on this
+	// goroutine: it never just waits while there is work left.
+	var wg sync.WaitGroup
+	wg.Add(len(jobs))
+	for _, job := range jobs {
+		job.wg = &wg
+	}
+	for _, job := range jobs[:len(jobs)-1] {
+		select {
+		case sv.jobs <- job:
+		default:
+			sv.verifyJob(job)
+		}
+	}
+	sv.verifyJob(jobs[len(jobs)-1])
+	wg.Wait()
+	return errs
+}
+
+// verifyJob verifies the signatures of the job, in a batch when it can. The
+// sign bytes and cache keys are computed here too, on the worker.
+func (sv *SigVerifier) verifyJob(job *sigJob) {
+	defer job.wg.Done()
+
+	signBytes := make([][]byte, len(job.votes))
+	keys := make([][sha256.Size]byte, len(job.votes))
+	var toVerify []int
+	for j, vote := range job.votes {
+		signBytes[j] = vote.SignBytes(sv.chainID)
+		keys[j] = sigCacheKey(signBytes[j], vote.Signature, vote.ValidatorAddress)
+		if valid, ok := sv.cache.Get(keys[j]); ok {
+			job.setResult(j, valid)
+			continue
+		}
+		toVerify = append(toVerify, j)
+	}
+	if len(toVerify) == 0 {
+		return
+	}
+
+	batch := ed25519consensus.NewPreallocatedBatchVerifier(len(toVerify))
+	var batched []int
+	for _, j := range toVerify {
+		pubKey, sig := job.pubKeys[j], job.votes[j].Signature
+		if pk, ok := pubKey.(ed25519.PubKeyEd25519); ok {
+			batch.Add(pk[:], signBytes[j], sig)
+			batched = append(batched, j)
+			continue
+		}
+		job.setResult(j, verifySig(pubKey, signBytes[j], sig))
+	}
+	atomic.AddInt64(&sv.numVerified, int64(len(toVerify)))
+
+	// A batch of one is no faster
+	if len(batched) > 1 && batch.Verify() {
+		for _, j := range batched {
+			job.setResult(j, true)
+		}
+	} else {
+		for _, j := range batched {
+			job.setResult(j, verifySig(job.pubKeys[j], signBytes[j], job.votes[j].Signature))
+		}
+	}
+	for _, j := range toVerify {
+		sv.cache.Push(keys[j], job.errs[job.indices[j]] == nil)
+	}
+}
+
+// verifySig returns true if sig is a valid signature of msg by pubKey. An
+// ed25519 signature is checked with the ZIP-215 rules of the batches.
+func verifySig(pubKey crypto.PubKey, msg []byte, sig []byte) bool {
+	if pk, ok := pubKey.(ed25519.PubKeyEd25519); ok {
+		return ed25519consensus.Verify(pk[:], msg, sig)
+	}
+	return pubKey.VerifyBytes(msg, sig)
+}
+
+// sigCacheKey returns the vote hash verification results are cached by.
+func sigCacheKey(signBytes []byte, sig []byte, address []byte) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write(signBytes)
+	h.Write(sig)
+	h.Write(address)
+	var key [sha256.Size]byte
+	copy(key[:], h.Sum(nil))
+	return key
+}
+
+//-----------------------------------------------------------------------------
+
+// sigJob is a batch of signatures verified by one worker. The results are
+// set at their index in the errs of the Verify call.
+type sigJob struct {
+	indices []int
+	votes   []*types.TxVote
+	pubKeys []crypto.PubKey
+
+	errs []error
+	wg   *sync.WaitGroup
+}
+
+func (job *sigJob) add(i int, vote *types.TxVote, pubKey crypto.PubKey) {
+	job.indices = append(job.indices, i)
+	job.votes = append(job.votes, vote)
+	job.pubKeys = append(job.pubKeys, pubKey)
+}
+
+func (job *sigJob) setResult(j int, valid bool) {
+	if valid {
+		job.errs[job.indices[j]] = nil
+	} else {
+		job.errs[job.indices[j]] = types.ErrVoteInvalidSignature
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// sigCache is a LRU cache of signature verification results.
+type sigCache struct {
+	mtx  sync.Mutex
+	size int
+	map_ map[[sha256.Size]byte]*list.Element
+	list *list.List
+}
+
+type sigCacheEntry struct {
+	key   [sha256.Size]byte
+	valid bool
+}
+
+func newSigCache(cacheSize int) *sigCache {
+	return &sigCache{
+		size: cacheSize,
+		map_: make(map[[sha256.Size]byte]*list.Element),
+		list: list.New(),
+	}
+}
+
+// Get returns the cached result for key, ok is false if there is none.
+func (cache *sigCache) Get(key [sha256.Size]byte) (valid bool, ok bool) {
+	cache.mtx.Lock()
+	defer cache.mtx.Unlock()
+
+	e, ok := cache.map_[key]
+	if !ok {
+		return false, false
+	}
+	cache.list.MoveToBack(e)
+	return e.Value.(*sigCacheEntry).valid, true
+}
+
+// Push caches the result for key, evicting the least recently used one if
+// the cache is full.
+func (cache *sigCache) Push(key [sha256.Size]byte, valid bool) {
+	if cache.size == 0 {
+		return
+	}
+	cache.mtx.Lock()
+	defer cache.mtx.Unlock()
+
+	if e, ok := cache.map_[key]; ok {
+		cache.list.MoveToBack(e)
+		return
+	}
+	if cache.list.Len() >= cache.size {
+		popped := cache.list.Front()
+		delete(cache.map_, popped.Value.(*sigCacheEntry).key)
+		cache.list.Remove(popped)
+	}
+	cache.map_[key] = cache.list.PushBack(&sigCacheEntry{key: key, valid: valid})
+}
@@ -0,0 +1,123 @@
+package txvotepool
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Fantom-foundation/go-txflow/types"
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+	ttypes "github.com/tendermint/tendermint/types"
+	tmtime "github.com/tendermint/tendermint/types/time"
+)
+
+const sigTestChainID = "test_chain_id"
+
+// signedVotes returns a vote for each of n txs signed by each key.
+func signedVotes(t testing.TB, keys []crypto.PrivKey, n int) ([]*types.TxVote, []crypto.PubKey) {
+	var votes []*types.TxVote
+	var pubKeys []crypto.PubKey
+	for i := 0; i < n; i++ {
+		tx := ttypes.Tx(fmt.Sprintf("tx%d", i))
+		for _, key := range keys {
+			pv := types.NewMockPVWithParams(key, false, false, false)
+			vote := types.NewTxVote(1, types.TxHash(tx), types.TxKey(tx), pv.GetPubKey().Address())
+			vote.Timestamp = tmtime.Now()
+			require.NoError(t, pv.SignTxVote(sigTestChainID, &vote))
+			votes = append(votes, &vote)
+			pubKeys = append(pubKeys, key.PubKey())
+		}
+	}
+	return votes, pubKeys
+}
+
+func TestSigVerifier(t *testing.T) {
+	keys := []crypto.PrivKey{
+		ed25519.GenPrivKey(),
+		ed25519.GenPrivKey(),
+		ed25519.GenPrivKey(),
+		secp256k1.GenPrivKey(),
+	}
+	votes, pubKeys := signedVotes(t, keys, 5)
+
+	sv := NewSigVerifier(sigTestChainID, SigVerifierWithWorkers(2), SigVerifierWithBatchSize(3))
+	require.NoError(t, sv.Start())
+	defer sv.Stop()
+
+	// A bad signature fails its batch, but only its own vote
+	bad := *votes[1]
+	bad.Signature = append([]byte{}, bad.Signature...)
+	bad.Signature[0] ^= 0xFF
+	votes[1] = &bad
+	// Unknown signer, key of another validator
+	pubKeys[2] = nil
+	pubKeys[5] = pubKeys[4]
+
+	errs := sv.Verify(votes, pubKeys)
+	require.Len(t, errs, len(votes))
+	for i, err := range errs {
+		switch i {
+		case 1:
+			assert.Equal(t, types.ErrVoteInvalidSignature, err)
+		case 2:
+			assert.Error(t, err)
+		case 5:
+			assert.Equal(t, ttypes.ErrVoteInvalidValidatorAddress, err)
+		default:
+			assert.NoError(t, err, "vote %d", i)
+		}
+	}
+	verified := atomic.LoadInt64(&sv.numVerified)
+	assert.EqualValues(t, len(votes)-2, verified)
+
+	// Gossip duplicates are answered from the cache, bad ones too
+	dups := make([]*types.TxVote, len(votes))
+	for i, vote := range votes {
+		dup := *vote
+		dups[i] = &dup
+	}
+	assert.Equal(t, errs, sv.Verify(dups, pubKeys))
+	assert.Equal(t, verified, atomic.LoadInt64(&sv.numVerified))
+
+	// A vote for another chain is another vote
+	other := NewSigVerifier("other_chain_id")
+	for _, err := range other.Verify(votes[:1], pubKeys[:1]) {
+		assert.Equal(t, types.ErrVoteInvalidSignature, err)
+	}
+}
+
+func TestSigVerifierNotRunning(t *testing.T) {
+	votes, pubKeys := signedVotes(t, []crypto.PrivKey{ed25519.GenPrivKey(), ed25519.GenPrivKey()}, 10)
+
+	// Without workers the batches are verified on the calling goroutine
+	sv := NewSigVerifier(sigTestChainID, SigVerifierWithBatchSize(4), SigVerifierWithCacheSize(0))
+	for _, err := range sv.Verify(votes, pubKeys) {
+		assert.NoError(t, err)
+	}
+	sv.Verify(votes, pubKeys)
+	assert.EqualValues(t, 2*len(votes), atomic.LoadInt64(&sv.numVerified))
+}
+
+func TestSigCache(t *testing.T) {
+	cache := newSigCache(2)
+	keys := [3][32]byte{{1}, {2}, {3}}
+	cache.Push(keys[0], true)
+	cache.Push(keys[1], false)
+
+	valid, ok := cache.Get(keys[1])
+	assert.True(t, ok)
+	assert.False(t, valid)
+
+	// keys[0] is the least recently used
+	cache.Push(keys[2], true)
+	_, ok = cache.Get(keys[0])
+	assert.False(t, ok)
+	valid, ok = cache.Get(keys[2])
+	assert.True(t, ok)
+	assert.True(t, valid)
+}
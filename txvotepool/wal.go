@@ -0,0 +1,162 @@
+package txvotepool
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"os"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+
+	"github.com/Fantom-foundation/go-txflow/types"
+	auto "github.com/tendermint/tendermint/libs/autofile"
+)
+
+const (
+	// rewrite the WAL without the committed votes once this many were committed
+	walTrimThreshold = 10000
+)
+
+// writeWAL appends a length prefixed vote to the WAL.
+// Assumes txVotePool.proxyMtx is held.
+func (txVotePool *TxVotePool) writeWAL(tx types.TxVote) {
+	if txVotePool.wal == nil {
+		return
+	}
+	if atomic.LoadInt64(&txVotePool.walCommitted) >= walTrimThreshold {
+		if err := txVotePool.trimWAL(); err != nil {
+			txVotePool.logger.Error("Error trimming WAL", "err", err)
+		}
+	}
+	// TODO: Notify administrators when WAL fails
+	if _, err := txVotePool.wal.Write(cdc.MustMarshalBinaryLengthPrefixed(tx)); err != nil {
+		txVotePool.logger.Error("Error writing to WAL", "err", err)
+	}
+}
+
+// ReplayWAL adds the votes found in the WAL back to the pool, so the votes
+// collected before a restart are not lost. Votes for which isCommitted
+// returns true are skipped. A truncated last record, left by a crash in the
+// middle of a write, is dropped. The WAL is then rewritten with only the
+// replayed votes. It returns the number of replayed votes.
+//
+// It must be called after InitWAL and before the pool is used.
+func (txVotePool *TxVotePool) ReplayWAL(isCommitted func(txHash string) bool) (int, error) {
+	txVotePool.proxyMtx.Lock()
+	defer txVotePool.proxyMtx.Unlock()
+
+	if txVotePool.wal == nil {
+		return 0, errors.New("WAL is not initialized")
+	}
+
+	votes, err := readWAL(txVotePool.wal.Path)
+	if err != nil {
+		txVotePool.logger.Error("Dropping corrupted WAL tail", "err", err, "replayed", len(votes))
+	}
+
+	replayed := 0
+	for _, tx := range votes {
+		if isCommitted(tx.TxHash) || txVotePool.Size() >= txVotePool.config.Size {
+			continue
+		}
+		if !txVotePool.cache.Push(tx) {
+			continue
+		}
+		txVotePool.addTx(&MempoolTxVote{
+			height: txVotePool.height,
+			Tx:     tx,
+		})
+		replayed++
+	}
+	if replayed > 0 {
+		txVotePool.notifyTxsAvailable()
+	}
+
+	return replayed, txVotePool.trimWAL()
+}
+
+// readWAL decodes the votes in the WAL at path. On a truncated or corrupted
+// record it returns the votes read so far along with the error.
+func readWAL(path string) ([]types.TxVote, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var (
+		votes []types.TxVote
+		r     = bufio.NewReader(f)
+	)
+	for {
+		size, err := binary.ReadUvarint(r)
+		if err == io.EOF {
+			return votes, nil
+		}
+		if err != nil {
+			return votes, errors.Wrap(err, "reading WAL record size")
+		}
+		if size > maxMsgSize {
+			return votes, errors.Errorf("WAL record too large (%d > %d)", size, maxMsgSize)
+		}
+		bz := make([]byte, size)
+		if _, err := io.ReadFull(r, bz); err != nil {
+			return votes, errors.Wrap(err, "reading WAL record")
+		}
+		var tx types.TxVote
+		if err := cdc.UnmarshalBinaryBare(bz, &tx); err != nil {
+			return votes, errors.Wrap(err, "decoding WAL record")
+		}
+		votes = append(votes, tx)
+	}
+}
+
+// trimWAL rewrites the WAL with the votes still in the pool, dropping the
+// committed ones. The new WAL is written next to the old one and renamed over
+// it, so a crash leaves either of them intact.
+// Assumes txVotePool.proxyMtx is held.
+func (txVotePool *TxVotePool) trimWAL() error {
+	path := txVotePool.wal.Path
+	tmpPath := path + ".tmp"
+
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for e := txVotePool.txs.Front(); e != nil; e = e.Next() {
+		memTx := e.Value.(*MempoolTxVote)
+		if _, err := w.Write(cdc.MustMarshalBinaryLengthPrefixed(memTx.Tx)); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := txVotePool.wal.Close(); err != nil {
+		txVotePool.logger.Error("Error closing WAL", "err", err)
+	}
+	// Reopen even if the rename failed, to keep appending to the old WAL
+	renameErr := os.Rename(tmpPath, path)
+	af, err := auto.OpenAutoFile(path)
+	if err != nil {
+		panic(errors.Wrap(err, "Error reopening Mempool WAL file"))
+	}
+	txVotePool.wal = af
+	if renameErr != nil {
+		return renameErr
+	}
+	atomic.StoreInt64(&txVotePool.walCommitted, 0)
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/crypto/merkle"
 	cmn "github.com/tendermint/tendermint/libs/common"
 	"github.com/tendermint/tendermint/types"
 )
@@ -42,8 +43,12 @@ func MakeBlock(height int64, txs []types.Tx, vtxs []types.Tx, lastCommit *types.
 	return block
 }
 
+// GetTendermintBlock returns the block without its Vtxs. The header is kept
+// as is, so the block hashes the same, but its DataHash also covers the Vtxs.
 func (b *Block) GetTendermintBlock() *types.Block {
-	return types.MakeBlock(b.Height, b.Txs, b.LastCommit, b.Evidence.Evidence)
+	block := types.MakeBlock(b.Height, b.Txs, b.LastCommit, b.Evidence.Evidence)
+	block.Header = b.Header
+	return block
 }
 
 // ValidateBasic performs basic validation that doesn't involve state data.
@@ -121,6 +126,20 @@ func (b *Block) ValidateBasic() error {
 		)
 	}
 
+	// Every Vtx must come with its commit.
+	// The commits are verified against state in state#ValidateBlock.
+	if len(b.VtxCommits) != len(b.Vtxs) {
+		return fmt.Errorf("Wrong Data.VtxCommits. Expected %d commits, got %d", len(b.Vtxs), len(b.VtxCommits))
+	}
+	for i, commit := range b.VtxCommits {
+		if commit == nil {
+			return fmt.Errorf("nil commit of Vtx #%d", i)
+		}
+		if commit.TxHash != TxHash(b.Vtxs[i]) {
+			return fmt.Errorf("Wrong commit of Vtx #%d. Expected tx hash %s, got %s", i, TxHash(b.Vtxs[i]), commit.TxHash)
+		}
+	}
+
 	// Basic validation of hashes related to application data.
 	// Will validate fully against state in state#ValidateBlock.
 	if err := types.ValidateHash(b.ValidatorsHash); err != nil {
@@ -296,22 +315,36 @@ type Data struct {
 
 	// Validated Txs that should not be applied (already applied)
 	Vtxs types.Txs `json:"vtxs"`
+	// Commits of the Vtxs, in the same order.
+	// NOTE: not part of the data hash, any valid commit will do.
+	VtxCommits []*Commit `json:"vtx_commits"`
 
 	// Volatile
 	hash cmn.HexBytes
 }
 
-// Hash returns the hash of the data
+// Hash returns the hash of the data. Without Vtxs it's the root of the Txs,
+// as in Tendermint, otherwise the root of a merkle tree with the roots of Txs
+// and Vtxs as leaves.
 func (data *Data) Hash() cmn.HexBytes {
 	if data == nil {
 		return (types.Txs{}).Hash()
 	}
 	if data.hash == nil {
-		data.hash = data.Txs.Hash() // NOTE: leaves of merkle tree are TxIDs
+		// NOTE: leaves of merkle trees are TxIDs
+		if len(data.Vtxs) == 0 {
+			data.hash = data.Txs.Hash()
+		} else {
+			data.hash = dataHash(data.Txs.Hash(), data.Vtxs.Hash())
+		}
 	}
 	return data.hash
 }
 
+func dataHash(txsHash, vtxsHash []byte) cmn.HexBytes {
+	return merkle.SimpleHashFromByteSlices([][]byte{txsHash, vtxsHash})
+}
+
 // StringIndented returns a string representation of the transactions
 func (data *Data) StringIndented(indent string) string {
 	if data == nil {
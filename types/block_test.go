@@ -371,3 +371,31 @@ func TestCommitToVoteSet(t *testing.T) {
 		assert.Equal(t, vote1bz, vote3bz)
 	}
 }
+
+func TestBlockDataHashCoversVtxs(t *testing.T) {
+	txs := []types.Tx{types.Tx("foo"), types.Tx("bar")}
+	vtxs := []types.Tx{types.Tx("baz"), types.Tx("qux"), types.Tx("quux")}
+
+	// Without Vtxs the data hash is Tendermint's
+	block := MakeBlock(3, txs, nil, nil, nil)
+	assert.Equal(t, types.Txs(txs).Hash(), []byte(block.DataHash))
+
+	block = MakeBlock(3, txs, vtxs, nil, nil)
+	other := MakeBlock(3, txs, vtxs[:2], nil, nil)
+	assert.NotEqual(t, block.DataHash, other.DataHash)
+
+	for i, vtx := range vtxs {
+		proof, err := block.VtxProof(vtx)
+		require.NoError(t, err, "#%d", i)
+		assert.Equal(t, vtx, proof.Tx())
+		assert.NoError(t, proof.Validate(block.DataHash), "#%d", i)
+		assert.Error(t, proof.Validate(other.DataHash), "#%d", i)
+
+		// A proof for a tampered tx fails
+		proof.Proof.Data = types.Tx("evil")
+		assert.Error(t, proof.Validate(block.DataHash), "#%d", i)
+	}
+
+	_, err := block.VtxProof(types.Tx("foo"))
+	assert.Error(t, err, "Txs are not Vtxs")
+}
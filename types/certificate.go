@@ -0,0 +1,240 @@
+package types
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/tendermint/tendermint/crypto/tmhash"
+	cmn "github.com/tendermint/tendermint/libs/common"
+	"github.com/tendermint/tendermint/types"
+)
+
+/*
+CommitCertificate is the compact form of a Commit.
+
+The fields every vote of a commit shares are stored once, in the header. The
+signers are a bit array over the validator set the votes are counted
+against, in validator set order, and only their timestamps and signatures
+are stored, in the same order. The TxKey of the votes is not signed and is
+not kept.
+
+Height is the height of the TxVoteSet, the votes are counted against the
+validators of Height+1. Validators sign at their own height, and the votes
+of a rebased set were cast at earlier ones: HeightDeltas then holds, for
+every signer, Height minus the height of its vote. It is left out when all
+votes are at Height.
+
+A certificate has a single encoding: the signers are ordered by the validator
+set, not by map iteration.
+*/
+type CommitCertificate struct {
+	ChainID string `json:"chain_id"`
+	TxHash  string `json:"tx_hash"`
+	Height  int64  `json:"height"`
+
+	Signers      *cmn.BitArray `json:"signers"`
+	Timestamps   []time.Time   `json:"timestamps"`
+	Signatures   [][]byte      `json:"signatures"`
+	HeightDeltas []int64       `json:"height_deltas,omitempty"`
+}
+
+// NewCommitCertificate returns the certificate of the accept votes of the
+// commit, counted against valSet, the validators of the height after the
+// highest vote.
+func NewCommitCertificate(chainID string, commit *Commit, valSet *types.ValidatorSet) (*CommitCertificate, error) {
+	return newCommitCertificate(chainID, commit.Height(), commit, valSet)
+}
+
+// newCommitCertificate returns the certificate of the accept votes of the
+// commit, counted against valSet, the validators of height+1.
+func newCommitCertificate(chainID string, height int64, commit *Commit, valSet *types.ValidatorSet) (*CommitCertificate, error) {
+	sigs := make([]*CommitSig, valSet.Size())
+	cert := &CommitCertificate{
+		ChainID: chainID,
+		TxHash:  commit.TxHash,
+		Height:  height,
+		Signers: cmn.NewBitArray(valSet.Size()),
+	}
+	for _, cs := range commit.Commits {
+		if cs == nil || cs.toVote().IsReject() {
+			continue
+		}
+		if cs.TxHash != commit.TxHash {
+			return nil, errors.Wrapf(ErrVoteInvalidTxHash, "Vote is for tx %s, not %s", cs.TxHash, commit.TxHash)
+		}
+		idx, val := valSet.GetByAddress(cs.ValidatorAddress)
+		if val == nil {
+			return nil, errors.Wrapf(types.ErrVoteInvalidValidatorIndex,
+				"Cannot find validator %X in valSet of size %d", cs.ValidatorAddress, valSet.Size())
+		}
+		if sigs[idx] != nil {
+			return nil, errors.Errorf("Duplicate vote of validator %X", cs.ValidatorAddress)
+		}
+		sigs[idx] = cs
+		cert.Signers.SetIndex(idx, true)
+	}
+	mixed := false
+	for _, cs := range sigs {
+		if cs != nil {
+			cert.Timestamps = append(cert.Timestamps, cs.Timestamp)
+			cert.Signatures = append(cert.Signatures, cs.Signature)
+			cert.HeightDeltas = append(cert.HeightDeltas, cert.Height-cs.Height)
+			mixed = mixed || cs.Height != cert.Height
+		}
+	}
+	if !mixed {
+		cert.HeightDeltas = nil
+	}
+	return cert, nil
+}
+
+// MakeCertificate constructs the certificate of the 2/3 commit of the
+// VoteSet, at the height of the set. The votes of a rebased set keep their
+// own heights.
+// Panics unless the tx has +2/3.
+func (voteSet *TxVoteSet) MakeCertificate() (*CommitCertificate, error) {
+	return newCommitCertificate(voteSet.chainID, voteSet.height, voteSet.MakeCommit(), voteSet.valSet)
+}
+
+// ValidateBasic performs basic validation.
+func (cert *CommitCertificate) ValidateBasic() error {
+	if cert.Height < 0 {
+		return errors.New("Negative Height")
+	}
+	if cert.Signers == nil {
+		return errors.New("Signers are missing")
+	}
+	signers := len(cert.signerIndices())
+	if len(cert.Timestamps) != signers || len(cert.Signatures) != signers {
+		return errors.Errorf("Expected %d timestamps and signatures, got %d and %d",
+			signers, len(cert.Timestamps), len(cert.Signatures))
+	}
+	if len(cert.HeightDeltas) != 0 && len(cert.HeightDeltas) != signers {
+		return errors.Errorf("Expected %d height deltas, got %d", signers, len(cert.HeightDeltas))
+	}
+	for i, delta := range cert.HeightDeltas {
+		if delta > cert.Height {
+			return errors.Errorf("Height delta #%d is above the height: %d", i, delta)
+		}
+	}
+	for i, sig := range cert.Signatures {
+		if len(sig) == 0 {
+			return errors.Errorf("Signature #%d is missing", i)
+		}
+		if len(sig) > types.MaxSignatureSize {
+			return errors.Errorf("Signature #%d is too big (max: %d)", i, types.MaxSignatureSize)
+		}
+	}
+	return nil
+}
+
+// signerIndices returns the validator indices of the signers, in order.
+func (cert *CommitCertificate) signerIndices() []int {
+	var indices []int
+	for i := 0; i < cert.Signers.Size(); i++ {
+		if cert.Signers.GetIndex(i) {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// votes returns the votes of the certificate along with their validators.
+func (cert *CommitCertificate) votes(valSet *types.ValidatorSet) ([]*TxVote, []*types.Validator, error) {
+	if err := cert.ValidateBasic(); err != nil {
+		return nil, nil, err
+	}
+	if cert.Signers.Size() != valSet.Size() {
+		return nil, nil, errors.Errorf("Certificate has %d signer bits, the valSet has %d validators",
+			cert.Signers.Size(), valSet.Size())
+	}
+	indices := cert.signerIndices()
+	votes := make([]*TxVote, len(indices))
+	vals := make([]*types.Validator, len(indices))
+	for i, idx := range indices {
+		_, val := valSet.GetByIndex(idx)
+		height := cert.Height
+		if len(cert.HeightDeltas) > 0 {
+			height -= cert.HeightDeltas[i]
+		}
+		votes[i] = &TxVote{
+			Height:           height,
+			TxHash:           cert.TxHash,
+			Timestamp:        cert.Timestamps[i],
+			ValidatorAddress: val.Address,
+			Signature:        cert.Signatures[i],
+		}
+		vals[i] = val
+	}
+	return votes, vals, nil
+}
+
+// VotingPower verifies the signatures of the certificate against valSet and
+// returns the voting power of the signers.
+func (cert *CommitCertificate) VotingPower(valSet *types.ValidatorSet) (int64, error) {
+	votes, vals, err := cert.votes(valSet)
+	if err != nil {
+		return 0, err
+	}
+	talliedVotingPower := int64(0)
+	for i, vote := range votes {
+		if err := vote.Verify(cert.ChainID, vals[i].PubKey); err != nil {
+			return 0, errors.Wrapf(err, "Invalid certificate -- invalid signature of validator %X", vals[i].Address)
+		}
+		talliedVotingPower += vals[i].VotingPower
+	}
+	return talliedVotingPower, nil
+}
+
+// Verify verifies that the certificate holds valid accept votes from more
+// than 2/3 of the voting power of valSet, the validators of Height+1.
+func (cert *CommitCertificate) Verify(valSet *types.ValidatorSet) error {
+	talliedVotingPower, err := cert.VotingPower(valSet)
+	if err != nil {
+		return err
+	}
+	if talliedVotingPower <= valSet.TotalVotingPower()*2/3 {
+		return errors.Errorf("Invalid certificate -- insufficient voting power: got %v, needed %v",
+			talliedVotingPower, valSet.TotalVotingPower()*2/3+1)
+	}
+	return nil
+}
+
+// VerifyTx verifies the certificate like Verify, and that it is for tx.
+func (cert *CommitCertificate) VerifyTx(tx types.Tx, valSet *types.ValidatorSet) error {
+	if cert.TxHash != TxHash(tx) {
+		return errors.Wrapf(ErrVoteInvalidTxHash, "Certificate is for tx %s, not %s", cert.TxHash, TxHash(tx))
+	}
+	return cert.Verify(valSet)
+}
+
+// ToCommit returns the Commit of the certificate, given the validator set
+// it was made against. The TxKey of its votes is empty.
+func (cert *CommitCertificate) ToCommit(valSet *types.ValidatorSet) (*Commit, error) {
+	votes, _, err := cert.votes(valSet)
+	if err != nil {
+		return nil, err
+	}
+	commitSigs := make([]*CommitSig, len(votes))
+	for i, vote := range votes {
+		commitSigs[i] = vote.CommitSig()
+	}
+	return NewCommit(cert.TxHash, commitSigs), nil
+}
+
+// Bytes returns the amino encoding of the certificate.
+func (cert *CommitCertificate) Bytes() []byte {
+	return cdc.MustMarshalBinaryBare(cert)
+}
+
+// Hash returns the hash of the encoded certificate.
+func (cert *CommitCertificate) Hash() cmn.HexBytes {
+	return tmhash.Sum(cert.Bytes())
+}
+
+// Equals returns true if both certificates have the same encoding.
+func (cert *CommitCertificate) Equals(other *CommitCertificate) bool {
+	return bytes.Equal(cert.Bytes(), other.Bytes())
+}
@@ -0,0 +1,127 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/types"
+	tmtime "github.com/tendermint/tendermint/types/time"
+)
+
+func TestCommitCertificate(t *testing.T) {
+	height := int64(1)
+	voteSet, valSet, privValidators := RandTxVoteSet(height, 4, 1)
+	tx := types.Tx{}
+
+	voteProto := &TxVote{
+		Height:    height,
+		Timestamp: tmtime.Now(),
+		TxHash:    voteSet.TxHash,
+		TxKey:     voteSet.TxKey,
+	}
+	for i := 0; i < 3; i++ {
+		addr := privValidators[i].GetPubKey().Address()
+		_, err := signAddVote(privValidators[i], withValidator(voteProto, addr, i), voteSet)
+		require.NoError(t, err)
+	}
+	cert, err := voteSet.MakeCertificate()
+	require.NoError(t, err)
+	assert.Equal(t, height, cert.Height)
+	assert.Len(t, cert.Signatures, 3)
+	assert.Equal(t, []int{0, 1, 2}, cert.signerIndices())
+	assert.NoError(t, cert.VerifyTx(tx, valSet))
+
+	// The encoding does not depend on the order of the commit
+	commit := voteSet.MakeCommit()
+	reversed := make([]*CommitSig, len(commit.Commits))
+	for i, cs := range commit.Commits {
+		reversed[len(reversed)-1-i] = cs
+	}
+	other, err := NewCommitCertificate(voteSet.ChainID(), NewCommit(commit.TxHash, reversed), valSet)
+	require.NoError(t, err)
+	assert.True(t, cert.Equals(other))
+	assert.Equal(t, cert.Hash(), other.Hash())
+
+	// Round trip through the Commit
+	fromCert, err := cert.ToCommit(valSet)
+	require.NoError(t, err)
+	assert.NoError(t, fromCert.VerifyTx(voteSet.ChainID(), tx, valSet))
+	again, err := NewCommitCertificate(voteSet.ChainID(), fromCert, valSet)
+	require.NoError(t, err)
+	assert.True(t, cert.Equals(again))
+
+	// Wrong tx, other validators
+	assert.Error(t, cert.VerifyTx(types.Tx("foo"), valSet))
+	otherValSet, _ := RandValidatorSet(4, 1)
+	assert.Error(t, cert.Verify(otherValSet))
+
+	// Too few signers
+	short, err := NewCommitCertificate(voteSet.ChainID(), NewCommit(commit.TxHash, commit.Commits[:2]), valSet)
+	require.NoError(t, err)
+	power, err := short.VotingPower(valSet)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), power)
+	assert.Error(t, short.Verify(valSet))
+
+	// Tampered signature
+	tampered := *cert
+	tampered.Signatures = append([][]byte{}, cert.Signatures...)
+	tampered.Signatures[0] = append([]byte{}, cert.Signatures[0]...)
+	tampered.Signatures[0][0] ^= 0xFF
+	assert.Error(t, tampered.Verify(valSet))
+
+	// Signatures not matching the signers
+	tampered = *cert
+	tampered.Signatures = cert.Signatures[:2]
+	assert.Error(t, tampered.ValidateBasic())
+}
+
+func TestCommitCertificateMixedHeights(t *testing.T) {
+	voteSet, valSet, privValidators := RandTxVoteSet(2, 4, 1)
+	tx := types.Tx{}
+
+	voteProto := &TxVote{
+		Height:    2,
+		Timestamp: tmtime.Now(),
+		TxHash:    voteSet.TxHash,
+		TxKey:     voteSet.TxKey,
+	}
+	// Validators sign at heights 1, 2 and 3
+	for i := 0; i < 3; i++ {
+		addr := privValidators[i].GetPubKey().Address()
+		_, err := signAddVote(privValidators[i], withHeight(withValidator(voteProto, addr, i), int64(1+i)), voteSet)
+		require.NoError(t, err)
+	}
+	cert, err := voteSet.MakeCertificate()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), cert.Height)
+	assert.Equal(t, []int64{1, 0, -1}, cert.HeightDeltas)
+	assert.NoError(t, cert.VerifyTx(tx, valSet))
+
+	// The votes keep their heights through the Commit
+	commit, err := cert.ToCommit(valSet)
+	require.NoError(t, err)
+	for i, cs := range commit.Commits {
+		assert.Equal(t, int64(1+i), cs.Height)
+	}
+	assert.NoError(t, commit.VerifyTx(voteSet.ChainID(), tx, valSet))
+
+	// A wrong height breaks the signature
+	tampered := *cert
+	tampered.HeightDeltas = []int64{0, 0, -1}
+	assert.Error(t, tampered.Verify(valSet))
+	tampered.HeightDeltas = []int64{0, 0}
+	assert.Error(t, tampered.ValidateBasic())
+
+	// Single height certificates leave the deltas out
+	single, err := NewCommitCertificate(voteSet.ChainID(), NewCommit(commit.TxHash, commit.Commits[1:2]), valSet)
+	require.NoError(t, err)
+	assert.Nil(t, single.HeightDeltas)
+
+	// A vote counted twice
+	dup := NewCommit(voteSet.TxHash, []*CommitSig{commit.Commits[0], commit.Commits[0]})
+	_, err = NewCommitCertificate(voteSet.ChainID(), dup, valSet)
+	assert.Error(t, err)
+}
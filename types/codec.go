@@ -15,6 +15,7 @@ func init() {
 func RegisterBlockAmino(cdc *amino.Codec) {
 	cryptoAmino.RegisterAmino(cdc)
 	types.RegisterEvidences(cdc)
+	RegisterTxVoteEvidences(cdc)
 }
 
 // GetCodec returns a codec used by the package. For testing purposes only.
@@ -0,0 +1,90 @@
+package types
+
+import (
+	"fmt"
+
+	amino "github.com/tendermint/go-amino"
+	abci "github.com/tendermint/tendermint/abci/types"
+	tmpubsub "github.com/tendermint/tendermint/libs/pubsub"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+// Reserved event types of the fast path, published through the
+// ttypes.EventBus.
+const (
+	EventTxVoteAdded     = "TxVoteAdded"
+	EventTxFastCommitted = "TxFastCommitted"
+	EventTxRejected      = "TxRejected"
+
+	// eventTxFlowPrefix prefixes the per tx event type, see EventTypeTxFlowFor
+	eventTxFlowPrefix = "TxFlow/"
+)
+
+// RegisterEventDatas registers the fast-path event datas on a codec which
+// already knows ttypes.TMEventData, like the RPC codec.
+func RegisterEventDatas(cdc *amino.Codec) {
+	cdc.RegisterConcrete(EventDataTxVoteAdded{}, "txflow/event/TxVoteAdded", nil)
+	cdc.RegisterConcrete(EventDataTxFastCommitted{}, "txflow/event/TxFastCommitted", nil)
+	cdc.RegisterConcrete(EventDataTxRejected{}, "txflow/event/TxRejected", nil)
+}
+
+// EventDataTxVoteAdded is published for every vote added to an open vote set,
+// along with the stake the set holds after it.
+type EventDataTxVoteAdded struct {
+	Vote             TxVote `json:"vote"`
+	Stake            int64  `json:"stake"`
+	RejectStake      int64  `json:"reject_stake"`
+	TotalVotingPower int64  `json:"total_voting_power"`
+}
+
+// EventDataTxFastCommitted is published once the app committed a tx which
+// passed 2/3. Seq is the journal sequence of the tx, the order in which this
+// node applied it.
+type EventDataTxFastCommitted struct {
+	TxHash string                 `json:"tx_hash"`
+	Height int64                  `json:"height"`
+	Seq    int64                  `json:"seq"`
+	Tx     ttypes.Tx              `json:"tx"`
+	Commit *Commit                `json:"commit"`
+	Result abci.ResponseDeliverTx `json:"result"`
+}
+
+// EventDataTxRejected is published once more than 1/3 of the voting power
+// rejected a tx. The Certificate holds the reject votes with their reasons.
+type EventDataTxRejected struct {
+	TxHash      string  `json:"tx_hash"`
+	Height      int64   `json:"height"`
+	Certificate *Commit `json:"certificate"`
+}
+
+// The Tendermint EventBus can't tag events with custom keys, so each
+// fast-path event is also published under the event type of its tx.
+
+// EventTypeTxFlowFor returns the event type under which all fast-path events
+// of the tx are published.
+func EventTypeTxFlowFor(txHash string) string {
+	return eventTxFlowPrefix + txHash
+}
+
+// EventQueryTxFlowFor matches all fast-path events of the tx: its votes, its
+// commit or its rejection.
+func EventQueryTxFlowFor(txHash string) tmpubsub.Query {
+	return ttypes.QueryForEvent(EventTypeTxFlowFor(txHash))
+}
+
+// TxEventPublisher publishes the fast-path events.
+type TxEventPublisher interface {
+	Publish(eventType string, eventData ttypes.TMEventData) error
+}
+
+// PublishTxEvent publishes the event under eventType and under the event type
+// of its tx.
+func PublishTxEvent(eventBus TxEventPublisher, eventType string, txHash string, data ttypes.TMEventData) error {
+	if err := eventBus.Publish(eventType, data); err != nil {
+		return err
+	}
+	if err := eventBus.Publish(EventTypeTxFlowFor(txHash), data); err != nil {
+		return fmt.Errorf("publishing %s for tx %s: %v", eventType, txHash, err)
+	}
+	return nil
+}
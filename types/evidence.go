@@ -0,0 +1,178 @@
+package types
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	amino "github.com/tendermint/go-amino"
+	abci "github.com/tendermint/tendermint/abci/types"
+	"github.com/tendermint/tendermint/crypto"
+	"github.com/tendermint/tendermint/crypto/tmhash"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+const (
+	// ABCIEvidenceTypeDuplicateTxVote is the abci.Evidence type reported to
+	// the app for a validator that signed the same tx twice.
+	ABCIEvidenceTypeDuplicateTxVote = "duplicate/txvote"
+)
+
+// ErrTxVoteConflictingVotes is returned by TxVoteSet.AddVote when a
+// validator signed two conflicting votes for the same tx, see
+// TxVote.ConflictsWith.
+type ErrTxVoteConflictingVotes struct {
+	*DuplicateTxVoteEvidence
+}
+
+func (err *ErrTxVoteConflictingVotes) Error() string {
+	return fmt.Sprintf("Conflicting tx votes from validator %v", err.PubKey.Address())
+}
+
+// NewConflictingVoteError returns the error for two conflicting votes
+// signed by val.
+func NewConflictingVoteError(val *ttypes.Validator, voteA, voteB *TxVote) *ErrTxVoteConflictingVotes {
+	return &ErrTxVoteConflictingVotes{
+		&DuplicateTxVoteEvidence{
+			PubKey: val.PubKey,
+			VoteA:  voteA,
+			VoteB:  voteB,
+		},
+	}
+}
+
+// RegisterTxVoteEvidences registers the TxFlow evidence types on a codec
+// that already knows the ttypes.Evidence interface.
+func RegisterTxVoteEvidences(cdc *amino.Codec) {
+	cdc.RegisterConcrete(&DuplicateTxVoteEvidence{}, "txflow/DuplicateTxVoteEvidence", nil)
+}
+
+//-------------------------------------------
+
+// DuplicateTxVoteEvidence contains evidence a validator signed two
+// conflicting votes for the same tx at the same height: one accepting and one
+// rejecting it, or two rejecting it with different codes.
+//
+// NOTE: PubKey comes last: the amino decoder misreads an interface in field
+// number 1 holding a non-struct concrete type, like an ed25519 key.
+type DuplicateTxVoteEvidence struct {
+	VoteA  *TxVote
+	VoteB  *TxVote
+	PubKey crypto.PubKey
+}
+
+var _ ttypes.Evidence = &DuplicateTxVoteEvidence{}
+
+// String returns a string representation of the evidence.
+func (dve *DuplicateTxVoteEvidence) String() string {
+	return fmt.Sprintf("TxVoteA: %v; TxVoteB: %v", dve.VoteA, dve.VoteB)
+}
+
+// Height returns the height this evidence refers to.
+// A TxVote carries the last committed block height, while it is signed by
+// the validators of the next block, so the equivocation happened at
+// VoteA.Height+1. This keeps validator set lookups by evidence height right.
+func (dve *DuplicateTxVoteEvidence) Height() int64 {
+	return dve.VoteA.Height + 1
+}
+
+// Address returns the address of the validator.
+func (dve *DuplicateTxVoteEvidence) Address() []byte {
+	return dve.PubKey.Address()
+}
+
+// Bytes returns the evidence as amino encoded bytes.
+func (dve *DuplicateTxVoteEvidence) Bytes() []byte {
+	return cdcEncode(dve)
+}
+
+// Hash returns the hash of the evidence.
+func (dve *DuplicateTxVoteEvidence) Hash() []byte {
+	return tmhash.Sum(cdcEncode(dve))
+}
+
+// Verify returns an error if the two votes aren't conflicting.
+// To be conflicting, they must be from the same validator, for the same
+// tx and height, but differ in what they say about the tx. Votes only
+// differing in their timestamp and signature are not conflicting.
+func (dve *DuplicateTxVoteEvidence) Verify(chainID string, pubKey crypto.PubKey) error {
+	// Tx and height must be the same
+	if dve.VoteA.TxHash != dve.VoteB.TxHash || dve.VoteA.Height != dve.VoteB.Height {
+		return fmt.Errorf("DuplicateTxVoteEvidence Error: TxHash/Height do not match: %s/%d vs %s/%d",
+			dve.VoteA.TxHash, dve.VoteA.Height, dve.VoteB.TxHash, dve.VoteB.Height)
+	}
+
+	// Address must be the same
+	if !bytes.Equal(dve.VoteA.ValidatorAddress, dve.VoteB.ValidatorAddress) {
+		return fmt.Errorf("DuplicateTxVoteEvidence Error: Validator addresses do not match. Got %X and %X",
+			dve.VoteA.ValidatorAddress, dve.VoteB.ValidatorAddress)
+	}
+
+	// Code or codespace must be different
+	if !dve.VoteA.ConflictsWith(dve.VoteB) {
+		return fmt.Errorf("DuplicateTxVoteEvidence Error: votes do not conflict, both have code %s/%d",
+			dve.VoteA.Codespace, dve.VoteA.Code)
+	}
+
+	// Both votes must be signed by the alleged equivocator
+	if err := dve.VoteA.Verify(chainID, pubKey); err != nil {
+		return fmt.Errorf("DuplicateTxVoteEvidence Error verifying VoteA: %v", err)
+	}
+	if err := dve.VoteB.Verify(chainID, pubKey); err != nil {
+		return fmt.Errorf("DuplicateTxVoteEvidence Error verifying VoteB: %v", err)
+	}
+
+	return nil
+}
+
+// Equal checks if two pieces of evidence are equal.
+func (dve *DuplicateTxVoteEvidence) Equal(ev ttypes.Evidence) bool {
+	if _, ok := ev.(*DuplicateTxVoteEvidence); !ok {
+		return false
+	}
+
+	// just check their hashes
+	dveHash := tmhash.Sum(cdcEncode(dve))
+	evHash := tmhash.Sum(cdcEncode(ev))
+	return bytes.Equal(dveHash, evHash)
+}
+
+// ValidateBasic performs basic validation.
+func (dve *DuplicateTxVoteEvidence) ValidateBasic() error {
+	if len(dve.PubKey.Bytes()) == 0 {
+		return fmt.Errorf("Empty PubKey")
+	}
+	if dve.VoteA == nil || dve.VoteB == nil {
+		return fmt.Errorf("One or both of the votes are empty %v, %v", dve.VoteA, dve.VoteB)
+	}
+	if err := dve.VoteA.ValidateBasic(); err != nil {
+		return fmt.Errorf("Invalid VoteA: %v", err)
+	}
+	if err := dve.VoteB.ValidateBasic(); err != nil {
+		return fmt.Errorf("Invalid VoteB: %v", err)
+	}
+	return nil
+}
+
+//-------------------------------------------
+
+// TM2PBEvidence converts evidence to its abci form, like ttypes.TM2PB.Evidence,
+// but also knows about the TxFlow evidence types.
+func TM2PBEvidence(ev ttypes.Evidence, valSet *ttypes.ValidatorSet, evTime time.Time) abci.Evidence {
+	if _, ok := ev.(*DuplicateTxVoteEvidence); !ok {
+		return ttypes.TM2PB.Evidence(ev, valSet, evTime)
+	}
+
+	_, val := valSet.GetByAddress(ev.Address())
+	if val == nil {
+		// should already have checked this
+		panic(val)
+	}
+	return abci.Evidence{
+		Type:             ABCIEvidenceTypeDuplicateTxVote,
+		Validator:        ttypes.TM2PB.Validator(val),
+		Height:           ev.Height(),
+		Time:             evTime,
+		TotalVotingPower: valSet.TotalVotingPower(),
+	}
+}
@@ -0,0 +1,77 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tendermint/types"
+	tmtime "github.com/tendermint/tendermint/types/time"
+)
+
+func TestConflictingTxVoteEvidence(t *testing.T) {
+	height := int64(1)
+	voteSet, valSet, privValidators := RandTxVoteSet(height, 4, 1)
+	val0 := privValidators[0]
+	val0Addr := val0.GetPubKey().Address()
+
+	voteA := &TxVote{
+		ValidatorAddress: val0Addr,
+		Height:           height,
+		Timestamp:        tmtime.Now(),
+		TxHash:           voteSet.TxHash,
+		TxKey:            voteSet.TxKey,
+	}
+	added, err := signAddVote(val0, voteA, voteSet)
+	require.True(t, added)
+	require.NoError(t, err)
+
+	// the same vote signed again at a later time is no conflict
+	voteA2 := voteA.Copy()
+	voteA2.Timestamp = voteA.Timestamp.Add(time.Second)
+	added, err = signAddVote(val0, voteA2, voteSet)
+	assert.False(t, added)
+	assert.Equal(t, ErrVoteNonDeterministicSignature, errors.Cause(err))
+	assert.Error(t, (&DuplicateTxVoteEvidence{PubKey: val0.GetPubKey(), VoteA: voteA, VoteB: voteA2}).
+		Verify(voteSet.ChainID(), val0.GetPubKey()))
+
+	// same tx and height, but rejected
+	voteB := voteA.Copy()
+	voteB.Code = 1
+	voteB.Codespace = "app"
+	added, err = signAddVote(val0, voteB, voteSet)
+	assert.False(t, added)
+	conflictErr, ok := err.(*ErrTxVoteConflictingVotes)
+	require.True(t, ok, "expected ErrTxVoteConflictingVotes, got %v", err)
+
+	ev := conflictErr.DuplicateTxVoteEvidence
+	assert.NoError(t, ev.ValidateBasic())
+	assert.NoError(t, ev.Verify(voteSet.ChainID(), val0.GetPubKey()))
+	assert.Equal(t, height+1, ev.Height())
+	assert.Equal(t, []byte(val0Addr), ev.Address())
+	assert.True(t, ev.Equal(ev))
+
+	// evidence survives an amino round trip through the evidence interface
+	var decoded types.Evidence
+	require.NoError(t, cdc.UnmarshalBinaryBare(cdc.MustMarshalBinaryBare(types.Evidence(ev)), &decoded))
+	assert.True(t, ev.Equal(decoded))
+	assert.Equal(t, ev.PubKey, decoded.(*DuplicateTxVoteEvidence).PubKey)
+
+	// the wrong key does not verify
+	assert.Error(t, ev.Verify(voteSet.ChainID(), privValidators[1].GetPubKey()))
+
+	// votes for different txs are not duplicates
+	badEv := &DuplicateTxVoteEvidence{
+		PubKey: val0.GetPubKey(),
+		VoteA:  voteA,
+		VoteB:  withTxHash(voteB, TxHash(types.Tx("other"))),
+	}
+	assert.Error(t, badEv.Verify(voteSet.ChainID(), val0.GetPubKey()))
+
+	abciEv := TM2PBEvidence(ev, valSet, tmtime.Now())
+	assert.Equal(t, ABCIEvidenceTypeDuplicateTxVote, abciEv.Type)
+	assert.Equal(t, height+1, abciEv.Height)
+}
@@ -0,0 +1,54 @@
+package types
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	cmn "github.com/tendermint/tendermint/libs/common"
+	ttypes "github.com/tendermint/tendermint/types"
+)
+
+// QueryPathJournalSeq is the path of the ABCI Query the app answers with the
+// Seq of the last fast-path tx it committed, see EncodeJournalSeq. Fast-path
+// txs are delivered outside of BeginBlock and EndBlock and their Seqs count
+// up from 1, so the app knows the Seq of a fast-path tx by counting them. The
+// count must be committed with the app state.
+const QueryPathJournalSeq = "/txflow/journal_seq"
+
+// TxJournalEntry records the execution of a fast-path tx.
+// It is saved once before the tx is delivered to the app, without an AppHash,
+// and again with the AppHash the app returned on Commit. Sequence numbers
+// increase by one with every executed tx.
+type TxJournalEntry struct {
+	Seq     int64        `json:"seq"`
+	Height  int64        `json:"height"` // last block height when the tx was applied
+	TxHash  string       `json:"tx_hash"`
+	Tx      ttypes.Tx    `json:"tx"`
+	AppHash cmn.HexBytes `json:"app_hash"` // nil until the app committed the tx
+}
+
+// IsApplied returns true if the app committed the tx.
+func (entry *TxJournalEntry) IsApplied() bool {
+	return entry.AppHash != nil
+}
+
+// String returns a string representation of the entry.
+func (entry *TxJournalEntry) String() string {
+	return fmt.Sprintf("TxJournalEntry{%d %d %s %X}", entry.Seq, entry.Height, entry.TxHash, entry.AppHash)
+}
+
+// EncodeJournalSeq returns the 8 byte big-endian encoding of seq, the value
+// of a QueryPathJournalSeq response.
+func EncodeJournalSeq(seq int64) []byte {
+	bz := make([]byte, 8)
+	binary.BigEndian.PutUint64(bz, uint64(seq))
+	return bz
+}
+
+// DecodeJournalSeq decodes the value of a QueryPathJournalSeq response.
+func DecodeJournalSeq(bz []byte) (int64, error) {
+	if len(bz) != 8 {
+		return 0, fmt.Errorf("Journal seq must be 8 bytes, got %d", len(bz))
+	}
+	return int64(binary.BigEndian.Uint64(bz)), nil
+}
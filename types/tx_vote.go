@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"time"
 
+	abci "github.com/tendermint/tendermint/abci/types"
 	"github.com/tendermint/tendermint/crypto"
 	cmn "github.com/tendermint/tendermint/libs/common"
 	ttypes "github.com/tendermint/tendermint/types"
@@ -14,24 +15,19 @@ import (
 
 const (
 	// MaxVoteBytes is a maximum vote size (including amino overhead).
-	MaxVoteBytes int64 = 223
-)
+	MaxVoteBytes int64 = 263
 
-/*func NewConflictingVoteError(val *types.Validator, voteA, voteB *TxVote) *ErrVoteConflictingVotes {
-	return &types.ErrVoteConflictingVotes{
-		&DuplicateVoteEvidence{
-			PubKey: val.PubKey,
-			VoteA:  voteA,
-			VoteB:  voteB,
-		},
-	}
-}*/
+	// MaxCodespaceBytes is the maximum size of the codespace of a reject vote.
+	MaxCodespaceBytes = 32
+)
 
 var (
 	ErrVoteInvalidSignature          = errors.New("Invalid signature")
 	ErrVoteInvalidTxHash             = errors.New("Invalid tx hash")
 	ErrVoteNonDeterministicSignature = errors.New("Non-deterministic signature")
 	ErrVoteNil                       = errors.New("Nil vote")
+	ErrVoteValidatorRemoved          = errors.New("Validator was removed from the validator set")
+	ErrVoteSetRebased                = errors.New("Vote set was rebased")
 )
 
 // txKey is the fixed length array sha256 hash used as the key in maps.
@@ -45,6 +41,10 @@ func TxHash(tx []byte) string {
 }
 
 // TxVote represents a commit vote from validators for consensus.
+//
+// A vote with a non-zero Code rejects the tx: the validator's CheckTx failed
+// with that code, in Codespace. The reason fields come last, so accept votes
+// encode as before.
 type TxVote struct {
 	Height           int64             `json:"height"`
 	TxHash           string            `json:"tx_hash"` // zero if vote is nil.
@@ -52,6 +52,8 @@ type TxVote struct {
 	Timestamp        time.Time         `json:"timestamp"`
 	ValidatorAddress crypto.Address    `json:"validator_address"`
 	Signature        []byte            `json:"signature"`
+	Code             uint32            `json:"code"`
+	Codespace        string            `json:"codespace"`
 }
 
 func NewTxVote(height int64,
@@ -70,6 +72,37 @@ func NewTxVote(height int64,
 	return txVote
 }
 
+// NewRejectTxVote returns a vote rejecting the tx, with the code and codespace
+// of the failed CheckTx response.
+func NewRejectTxVote(height int64,
+	txHash string,
+	txKey [sha256.Size]byte,
+	res *abci.ResponseCheckTx,
+	validatorAddress crypto.Address,
+) TxVote {
+	txVote := NewTxVote(height, txHash, txKey, validatorAddress)
+	txVote.Code = res.Code
+	txVote.Codespace = res.Codespace
+	return txVote
+}
+
+// IsReject returns true if the vote rejects the tx.
+func (vote *TxVote) IsReject() bool {
+	return vote.Code != abci.CodeTypeOK
+}
+
+// ConflictsWith returns true if both votes are from the same validator, for
+// the same tx and height, but say different things about the tx: one accepts
+// it and the other rejects it, or they reject it with different codes. Votes
+// which only differ in their timestamp, and therefore their signature, don't
+// conflict.
+func (vote *TxVote) ConflictsWith(other *TxVote) bool {
+	return bytes.Equal(vote.ValidatorAddress, other.ValidatorAddress) &&
+		vote.TxHash == other.TxHash &&
+		vote.Height == other.Height &&
+		(vote.Code != other.Code || vote.Codespace != other.Codespace)
+}
+
 // CommitSig converts the Vote to a CommitSig.
 // If the Vote is nil, the CommitSig will be nil.
 func (vote *TxVote) CommitSig() *CommitSig {
@@ -98,6 +131,17 @@ func (vote *TxVote) String() string {
 		return "nil-Vote"
 	}
 
+	if vote.IsReject() {
+		return fmt.Sprintf("TxVote{%X (%v) %X reject %s/%d %X @ %s}",
+			cmn.Fingerprint(vote.ValidatorAddress),
+			vote.Height,
+			vote.TxHash,
+			vote.Codespace,
+			vote.Code,
+			cmn.Fingerprint(vote.Signature),
+			ttypes.CanonicalTime(vote.Timestamp),
+		)
+	}
 	return fmt.Sprintf("TxVote{%X (%v) %X %X @ %s}",
 		cmn.Fingerprint(vote.ValidatorAddress),
 		vote.Height,
@@ -137,6 +181,12 @@ func (vote *TxVote) ValidateBasic() error {
 	if len(vote.Signature) > ttypes.MaxSignatureSize {
 		return fmt.Errorf("Signature is too big (max: %d)", ttypes.MaxSignatureSize)
 	}
+	if len(vote.Codespace) > MaxCodespaceBytes {
+		return fmt.Errorf("Codespace is too big (max: %d)", MaxCodespaceBytes)
+	}
+	if !vote.IsReject() && vote.Codespace != "" {
+		return errors.New("Codespace without a reject code")
+	}
 	return nil
 }
 
@@ -174,12 +224,16 @@ func (cs *CommitSig) toVote() *TxVote {
 	return &v
 }
 
+// CanonicalTxVote is the signed form of a TxVote. The reject reason is
+// signed too, so a reject vote can't be turned into an accept vote.
 type CanonicalTxVote struct {
 	Height    int64 `binary:"fixed64"`
 	TxHash    string
 	TxKey     [sha256.Size]byte
 	Timestamp time.Time
 	ChainID   string
+	Code      uint32
+	Codespace string
 }
 
 func CanonicalizeTxVote(chainID string, vote *TxVote) CanonicalTxVote {
@@ -188,5 +242,7 @@ func CanonicalizeTxVote(chainID string, vote *TxVote) CanonicalTxVote {
 		TxHash:    vote.TxHash,
 		Timestamp: vote.Timestamp,
 		ChainID:   chainID,
+		Code:      vote.Code,
+		Codespace: vote.Codespace,
 	}
 }
@@ -14,6 +14,10 @@ import (
 /*
 	VoteSet helps collect signatures from validators for each tx
 
+	Accept and reject votes are summed up separately. The tx commits once
+	more than 2/3 of the voting power accepts it, and is rejected for good
+	once more than 1/3 rejects it: 2/3 can't accept it anymore.
+
 	NOTE: Assumes that the sum total of voting power does not exceed MaxUInt64.
 */
 type TxVoteSet struct {
@@ -24,10 +28,14 @@ type TxVoteSet struct {
 	TxHash string
 	TxKey  [sha256.Size]byte
 
-	mtx   sync.Mutex
-	votes map[string]*TxVote // Primary votes to share
-	sum   int64              // Sum of voting power for seen votes, discounting conflicts
-	maj23 bool
+	mtx       sync.Mutex
+	votes     map[string]*TxVote // Primary votes to share
+	sum       int64              // Sum of voting power for seen accept votes, discounting conflicts
+	rejectSum int64              // Sum of voting power for seen reject votes
+	maj23     bool
+	rejected  bool
+	// set by Rebase, the votes go to the new set from then on
+	rebased bool
 }
 
 // NewTxVoteSet Constructs a new VoteSet struct used to accumulate votes for given height/round.
@@ -55,6 +63,8 @@ func (voteSet *TxVoteSet) ChainID() string {
 }
 
 func (voteSet *TxVoteSet) GetVotes() []TxVote {
+	voteSet.mtx.Lock()
+	defer voteSet.mtx.Unlock()
 	// Convert map to slice of values.
 	values := []TxVote{}
 	for _, value := range voteSet.votes {
@@ -70,6 +80,48 @@ func (voteSet *TxVoteSet) Height() int64 {
 	return voteSet.height
 }
 
+// ValidatorsHash returns the hash of the validator set the votes are counted
+// against.
+func (voteSet *TxVoteSet) ValidatorsHash() []byte {
+	if voteSet == nil {
+		return nil
+	}
+	return voteSet.valSet.Hash()
+}
+
+// HasValidator returns true if address is in the validator set the votes
+// are counted against.
+func (voteSet *TxVoteSet) HasValidator(address []byte) bool {
+	if voteSet == nil {
+		return false
+	}
+	return voteSet.valSet.HasAddress(address)
+}
+
+// Rebase returns a new TxVoteSet counting the votes of voteSet against
+// valSet, which took effect after height. Votes of validators missing from
+// valSet are dropped, all other votes are counted with their new power.
+// Signatures were checked when the votes were added and are not verified
+// again.
+// voteSet takes no more votes afterwards, adding one fails with
+// ErrVoteSetRebased: it would be missing from the new set.
+func (voteSet *TxVoteSet) Rebase(height int64, valSet *types.ValidatorSet) *TxVoteSet {
+	voteSet.mtx.Lock()
+	defer voteSet.mtx.Unlock()
+
+	voteSet.rebased = true
+
+	newSet := NewTxVoteSet(voteSet.chainID, height, voteSet.TxHash, voteSet.TxKey, valSet)
+	for _, vote := range voteSet.votes {
+		_, val := valSet.GetByAddress(vote.ValidatorAddress)
+		if val == nil {
+			continue
+		}
+		newSet.addVerifiedVote(vote, val.VotingPower)
+	}
+	return newSet
+}
+
 func (voteSet *TxVoteSet) Size() int {
 	if voteSet == nil {
 		return 0
@@ -85,14 +137,31 @@ func (voteSet *TxVoteSet) AddVote(vote *TxVote) (added bool, err error) {
 	voteSet.mtx.Lock()
 	defer voteSet.mtx.Unlock()
 
-	return voteSet.addVote(vote)
+	return voteSet.addVote(vote, false)
+}
+
+// AddPreverifiedVote is like AddVote, but trusts the signature of the vote
+// to have been verified against the key of its validator already, e.g. by a
+// txvotepool.SigVerifier. The key of an address is the same in every
+// validator set, so any set may have been used.
+func (voteSet *TxVoteSet) AddPreverifiedVote(vote *TxVote) (added bool, err error) {
+	if voteSet == nil {
+		panic("AddPreverifiedVote() on nil VoteSet")
+	}
+	voteSet.mtx.Lock()
+	defer voteSet.mtx.Unlock()
+
+	return voteSet.addVote(vote, true)
 }
 
 // addVote NOTE: Validates as much as possible before attempting to verify the signature.
-func (voteSet *TxVoteSet) addVote(vote *TxVote) (added bool, err error) {
+func (voteSet *TxVoteSet) addVote(vote *TxVote, verified bool) (added bool, err error) {
 	if vote == nil {
 		return false, ErrVoteNil
 	}
+	if voteSet.rebased {
+		return false, ErrVoteSetRebased
+	}
 
 	if len(vote.ValidatorAddress) == 0 {
 		return false, errors.Wrap(types.ErrVoteInvalidValidatorAddress, "Empty address")
@@ -106,23 +175,32 @@ func (voteSet *TxVoteSet) addVote(vote *TxVote) (added bool, err error) {
 	}
 
 	// If we already know of this vote, return false.
-	if existing, ok := voteSet.getVote(vote.ValidatorAddress); ok {
-		if bytes.Equal(existing.Signature, vote.Signature) {
-			return false, nil // duplicate
-		}
-		return false, errors.Wrapf(ErrVoteNonDeterministicSignature, "Existing vote: %v; New vote: %v", existing, vote)
+	existing, ok := voteSet.getVote(vote.ValidatorAddress)
+	if ok && bytes.Equal(existing.Signature, vote.Signature) {
+		return false, nil // duplicate
 	}
 
 	// Check signature.
-	if err := vote.Verify(voteSet.chainID, val.PubKey); err != nil {
-		return false, errors.Wrapf(err, "Failed to verify vote with ChainID %s and PubKey %s", voteSet.chainID, val.PubKey)
+	if !verified {
+		if err := vote.Verify(voteSet.chainID, val.PubKey); err != nil {
+			return false, errors.Wrapf(err, "Failed to verify vote with ChainID %s and PubKey %s", voteSet.chainID, val.PubKey)
+		}
+	}
+
+	// A second vote from the same validator saying something else about the
+	// tx at the same height is evidence. Votes only differing in their
+	// timestamp, or from different heights, are just non-deterministic.
+	if ok {
+		if existing.ConflictsWith(vote) {
+			return false, NewConflictingVoteError(val, existing, vote)
+		}
+		return false, errors.Wrapf(ErrVoteNonDeterministicSignature, "Existing vote: %v; New vote: %v", existing, vote)
 	}
 
 	// Add vote and get conflicting vote if any.
 	added, conflicting := voteSet.addVerifiedVote(vote, val.VotingPower)
 	if conflicting != nil {
-		//This is evidence, we need to provide this TODO
-		return added, nil //NewConflictingVoteError(val, conflicting, vote)
+		return added, NewConflictingVoteError(val, conflicting, vote)
 	}
 	if !added {
 		panic("Expected to add non-conflicting vote")
@@ -150,9 +228,13 @@ func (voteSet *TxVoteSet) addVerifiedVote(vote *TxVote, votingPower int64) (adde
 		}
 		// Otherwise don't add it to voteSet.votes
 	} else {
-		// Add to voteSet.votes and incr .sum
+		// Add to voteSet.votes and incr .sum or .rejectSum
 		voteSet.votes[vote.ValidatorAddress.String()] = vote
-		voteSet.sum += votingPower
+		if vote.IsReject() {
+			voteSet.rejectSum += votingPower
+		} else {
+			voteSet.sum += votingPower
+		}
 	}
 
 	quorum := voteSet.valSet.TotalVotingPower()*2/3 + 1
@@ -161,6 +243,9 @@ func (voteSet *TxVoteSet) addVerifiedVote(vote *TxVote, votingPower int64) (adde
 	if quorum <= voteSet.sum {
 		voteSet.maj23 = true
 	}
+	if voteSet.rejectSum > voteSet.valSet.TotalVotingPower()/3 {
+		voteSet.rejected = true
+	}
 
 	return true, conflicting
 }
@@ -193,6 +278,17 @@ func (voteSet *TxVoteSet) IsCommit() bool {
 	return voteSet.maj23
 }
 
+// IsRejected returns true once more than 1/3 of the voting power rejected
+// the tx.
+func (voteSet *TxVoteSet) IsRejected() bool {
+	if voteSet == nil {
+		return false
+	}
+	voteSet.mtx.Lock()
+	defer voteSet.mtx.Unlock()
+	return voteSet.rejected
+}
+
 func (voteSet *TxVoteSet) HasTwoThirdsAny() bool {
 	if voteSet == nil {
 		return false
@@ -211,6 +307,16 @@ func (voteSet *TxVoteSet) Stake() int64 {
 	return voteSet.sum
 }
 
+// RejectStake returns the voting power which rejected the tx.
+func (voteSet *TxVoteSet) RejectStake() int64 {
+	if voteSet == nil {
+		return -1
+	}
+	voteSet.mtx.Lock()
+	defer voteSet.mtx.Unlock()
+	return voteSet.rejectSum
+}
+
 func (voteSet *TxVoteSet) TotalStake() int64 {
 	if voteSet == nil {
 		return -1
@@ -220,10 +326,37 @@ func (voteSet *TxVoteSet) TotalStake() int64 {
 	return voteSet.valSet.TotalVotingPower() * 2 / 3
 }
 
+// TotalVotingPower returns the voting power of the whole validator set.
+func (voteSet *TxVoteSet) TotalVotingPower() int64 {
+	if voteSet == nil {
+		return -1
+	}
+	voteSet.mtx.Lock()
+	defer voteSet.mtx.Unlock()
+	return voteSet.valSet.TotalVotingPower()
+}
+
+// MissingValidators returns the validators which have not voted yet, in
+// validator set order.
+func (voteSet *TxVoteSet) MissingValidators() []*types.Validator {
+	if voteSet == nil {
+		return nil
+	}
+	voteSet.mtx.Lock()
+	defer voteSet.mtx.Unlock()
+	missing := make([]*types.Validator, 0, voteSet.valSet.Size()-len(voteSet.votes))
+	for _, val := range voteSet.valSet.Validators {
+		if _, ok := voteSet.votes[val.Address.String()]; !ok {
+			missing = append(missing, val)
+		}
+	}
+	return missing
+}
+
 func (voteSet *TxVoteSet) HasAll() bool {
 	voteSet.mtx.Lock()
 	defer voteSet.mtx.Unlock()
-	return voteSet.sum == voteSet.valSet.TotalVotingPower()
+	return voteSet.sum+voteSet.rejectSum == voteSet.valSet.TotalVotingPower()
 }
 
 // return the power voted, the total, and the fraction
@@ -248,14 +381,31 @@ func (voteSet *TxVoteSet) MakeCommit() *Commit {
 		panic("Cannot MakeCommit() unless a blockhash has +2/3")
 	}
 
-	// For every validator, get the precommit
-	commitSigs := make([]*CommitSig, len(voteSet.votes))
-	i := 0
+	return NewCommit(voteSet.TxHash, voteSet.commitSigs(false))
+}
+
+// MakeRejectCommit constructs the reject certificate of the tx, a Commit
+// holding its reject votes. Panics unless more than 1/3 rejected the tx.
+func (voteSet *TxVoteSet) MakeRejectCommit() *Commit {
+	voteSet.mtx.Lock()
+	defer voteSet.mtx.Unlock()
+
+	if !voteSet.rejected {
+		panic("Cannot MakeRejectCommit() unless a tx has +1/3 rejects")
+	}
+	return NewCommit(voteSet.TxHash, voteSet.commitSigs(true))
+}
+
+// commitSigs returns the reject or the accept votes. Assumes voteSet.mtx is
+// held.
+func (voteSet *TxVoteSet) commitSigs(reject bool) []*CommitSig {
+	commitSigs := make([]*CommitSig, 0, len(voteSet.votes))
 	for _, v := range voteSet.votes {
-		commitSigs[i] = v.CommitSig()
-		i = i + 1
+		if v.IsReject() == reject {
+			commitSigs = append(commitSigs, v.CommitSig())
+		}
 	}
-	return NewCommit(voteSet.TxHash, commitSigs)
+	return commitSigs
 }
 
 //-------------------------------------
@@ -286,6 +436,86 @@ func NewCommit(txHash string, commits []*CommitSig) *Commit {
 	}
 }
 
+// Height returns the highest height of the commit's votes. The votes are
+// counted against the validator set of the next height.
+func (commit *Commit) Height() int64 {
+	if commit.height == 0 {
+		for _, cs := range commit.Commits {
+			if cs != nil && cs.Height > commit.height {
+				commit.height = cs.Height
+			}
+		}
+	}
+	return commit.height
+}
+
+// VerifyTx verifies that the commit holds valid votes for tx from more than
+// 2/3 of the voting power of valSet. Reject votes are not counted.
+func (commit *Commit) VerifyTx(chainID string, tx types.Tx, valSet *types.ValidatorSet) error {
+	talliedVotingPower, err := commit.tally(chainID, TxHash(tx), valSet, false)
+	if err != nil {
+		return err
+	}
+	if talliedVotingPower <= valSet.TotalVotingPower()*2/3 {
+		return errors.Errorf("Invalid commit -- insufficient voting power: got %v, needed %v",
+			talliedVotingPower, valSet.TotalVotingPower()*2/3+1)
+	}
+	return nil
+}
+
+// VerifyReject verifies that the commit is a reject certificate: it holds
+// valid reject votes for the tx from more than 1/3 of the voting power of
+// valSet. Accept votes are not counted.
+func (commit *Commit) VerifyReject(chainID string, txHash string, valSet *types.ValidatorSet) error {
+	talliedVotingPower, err := commit.tally(chainID, txHash, valSet, true)
+	if err != nil {
+		return err
+	}
+	if talliedVotingPower <= valSet.TotalVotingPower()/3 {
+		return errors.Errorf("Invalid reject certificate -- insufficient voting power: got %v, needed %v",
+			talliedVotingPower, valSet.TotalVotingPower()/3+1)
+	}
+	return nil
+}
+
+// tally verifies the accept or the reject votes of the commit and returns
+// their voting power.
+func (commit *Commit) tally(chainID string, txHash string, valSet *types.ValidatorSet, reject bool) (int64, error) {
+	if commit.TxHash != txHash {
+		return 0, errors.Wrapf(ErrVoteInvalidTxHash, "Commit is for tx %s, not %s", commit.TxHash, txHash)
+	}
+
+	talliedVotingPower := int64(0)
+	seen := make(map[string]bool, len(commit.Commits))
+	for idx, cs := range commit.Commits {
+		if cs == nil {
+			continue
+		}
+		vote := cs.toVote()
+		if vote.IsReject() != reject {
+			continue
+		}
+		if vote.TxHash != txHash {
+			return 0, errors.Wrapf(ErrVoteInvalidTxHash, "Invalid commit -- wrong tx hash in vote #%d: %s", idx, vote.TxHash)
+		}
+		if seen[vote.ValidatorAddress.String()] {
+			return 0, errors.Errorf("Invalid commit -- duplicate vote of validator %X", vote.ValidatorAddress)
+		}
+		seen[vote.ValidatorAddress.String()] = true
+
+		_, val := valSet.GetByAddress(vote.ValidatorAddress)
+		if val == nil {
+			return 0, errors.Wrapf(types.ErrVoteInvalidValidatorIndex,
+				"Cannot find validator %X in valSet of size %d", vote.ValidatorAddress, valSet.Size())
+		}
+		if err := vote.Verify(chainID, val.PubKey); err != nil {
+			return 0, errors.Wrapf(err, "Invalid commit -- invalid signature in vote #%d", idx)
+		}
+		talliedVotingPower += val.VotingPower
+	}
+	return talliedVotingPower, nil
+}
+
 //--------------------------------------------------------------------------------
 
 // VoteSetReader Common interface between *consensus.VoteSet and types.Commit
@@ -1,9 +1,13 @@
 package types
 
 import (
+	"bytes"
 	"sort"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
 	cmn "github.com/tendermint/tendermint/libs/common"
 	"github.com/tendermint/tendermint/types"
 	ttypes "github.com/tendermint/tendermint/types"
@@ -391,3 +395,170 @@ func TestConflicts(t *testing.T) {
 	}
 
 }
+
+func TestTxVoteSetRebase(t *testing.T) {
+	height := int64(1)
+	voteSet, valSet, privValidators := RandTxVoteSet(height, 4, 1)
+
+	voteProto := &TxVote{
+		Height:    height,
+		Timestamp: tmtime.Now(),
+		TxHash:    voteSet.TxHash,
+		TxKey:     voteSet.TxKey,
+	}
+	for i := 0; i < 2; i++ {
+		addr := privValidators[i].GetPubKey().Address()
+		added, err := signAddVote(privValidators[i], withValidator(voteProto, addr, i), voteSet)
+		require.True(t, added)
+		require.NoError(t, err)
+	}
+	require.False(t, voteSet.HasTwoThirdsMajority())
+
+	// val0 is removed and val1 gets most of the power
+	removed := privValidators[0].GetPubKey().Address()
+	newVals := make([]*types.Validator, 0, valSet.Size())
+	for _, val := range valSet.Validators {
+		if bytes.Equal(val.Address, removed) {
+			continue
+		}
+		val = val.Copy()
+		if bytes.Equal(val.Address, privValidators[1].GetPubKey().Address()) {
+			val.VotingPower = 10
+		}
+		newVals = append(newVals, val)
+	}
+	newValSet := types.NewValidatorSet(newVals)
+
+	rebased := voteSet.Rebase(height+1, newValSet)
+	assert.Equal(t, height+1, rebased.Height())
+	assert.Equal(t, newValSet.Hash(), rebased.ValidatorsHash())
+	assert.False(t, rebased.HasValidator(removed))
+	assert.Nil(t, rebased.GetByAddress(removed))
+	assert.Equal(t, int64(10), rebased.Stake())
+	assert.True(t, rebased.HasTwoThirdsMajority())
+
+	// the original set is left alone, and takes no more votes
+	assert.Equal(t, int64(2), voteSet.Stake())
+	addr := privValidators[2].GetPubKey().Address()
+	added, err := signAddVote(privValidators[2], withValidator(voteProto, addr, 2), voteSet)
+	assert.False(t, added)
+	assert.Equal(t, ErrVoteSetRebased, err)
+}
+
+func TestCommitVerifyTx(t *testing.T) {
+	height := int64(1)
+	voteSet, valSet, privValidators := RandTxVoteSet(height, 4, 1)
+	tx := types.Tx{}
+
+	voteProto := &TxVote{
+		Height:    height,
+		Timestamp: tmtime.Now(),
+		TxHash:    voteSet.TxHash,
+		TxKey:     voteSet.TxKey,
+	}
+	for i := 0; i < 3; i++ {
+		addr := privValidators[i].GetPubKey().Address()
+		_, err := signAddVote(privValidators[i], withValidator(voteProto, addr, i), voteSet)
+		require.NoError(t, err)
+	}
+	commit := voteSet.MakeCommit()
+	assert.Equal(t, height, commit.Height())
+	assert.NoError(t, commit.VerifyTx(voteSet.ChainID(), tx, valSet))
+
+	// Wrong chain, wrong tx
+	assert.Error(t, commit.VerifyTx("other_chain_id", tx, valSet))
+	assert.Error(t, commit.VerifyTx(voteSet.ChainID(), types.Tx("foo"), valSet))
+
+	// The signers lost their power
+	otherValSet, _ := RandValidatorSet(4, 1)
+	assert.Error(t, commit.VerifyTx(voteSet.ChainID(), tx, otherValSet))
+
+	// A vote counted twice does not add up
+	dup := NewCommit(commit.TxHash, []*CommitSig{commit.Commits[0], commit.Commits[0], commit.Commits[1]})
+	assert.Error(t, dup.VerifyTx(voteSet.ChainID(), tx, valSet))
+
+	// Too few votes
+	short := NewCommit(commit.TxHash, commit.Commits[:2])
+	assert.Error(t, short.VerifyTx(voteSet.ChainID(), tx, valSet))
+}
+
+func TestTxVoteSetReject(t *testing.T) {
+	height := int64(1)
+	voteSet, valSet, privValidators := RandTxVoteSet(height, 10, 1)
+
+	voteProto := &TxVote{
+		Height:    height,
+		Timestamp: tmtime.Now(),
+		TxHash:    voteSet.TxHash,
+		TxKey:     voteSet.TxKey,
+	}
+	rejectProto := voteProto.Copy()
+	rejectProto.Code = 2
+	rejectProto.Codespace = "app"
+
+	// An accept vote does not count towards the reject stake
+	addr := privValidators[0].GetPubKey().Address()
+	_, err := signAddVote(privValidators[0], withValidator(voteProto, addr, 0), voteSet)
+	require.NoError(t, err)
+
+	// 3 of 10 is not more than 1/3
+	for i := 1; i < 4; i++ {
+		addr := privValidators[i].GetPubKey().Address()
+		_, err := signAddVote(privValidators[i], withValidator(rejectProto, addr, i), voteSet)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(1), voteSet.Stake())
+	assert.Equal(t, int64(3), voteSet.RejectStake())
+	assert.False(t, voteSet.IsRejected())
+	assert.Panics(t, func() { voteSet.MakeRejectCommit() })
+
+	addr = privValidators[4].GetPubKey().Address()
+	_, err = signAddVote(privValidators[4], withValidator(rejectProto, addr, 4), voteSet)
+	require.NoError(t, err)
+	assert.True(t, voteSet.IsRejected())
+	assert.False(t, voteSet.HasTwoThirdsMajority())
+
+	cert := voteSet.MakeRejectCommit()
+	assert.Len(t, cert.Commits, 4)
+	assert.NoError(t, cert.VerifyReject(voteSet.ChainID(), voteSet.TxHash, valSet))
+	// The reject votes can't pass for a commit
+	assert.Error(t, cert.VerifyTx(voteSet.ChainID(), types.Tx{}, valSet))
+
+	// The reason is signed
+	forged := NewCommit(cert.TxHash, cert.Commits)
+	forgedSig := *cert.Commits[0]
+	forgedSig.Code = 3
+	forged.Commits = append([]*CommitSig{&forgedSig}, cert.Commits[1:]...)
+	assert.Error(t, forged.VerifyReject(voteSet.ChainID(), voteSet.TxHash, valSet))
+}
+
+func TestAddPreverifiedVote(t *testing.T) {
+	height := int64(1)
+	voteSet, _, privValidators := RandTxVoteSet(height, 4, 1)
+
+	voteProto := &TxVote{
+		Height:    height,
+		Timestamp: tmtime.Now(),
+		TxHash:    voteSet.TxHash,
+		TxKey:     voteSet.TxKey,
+	}
+	addr := privValidators[0].GetPubKey().Address()
+	vote := withValidator(voteProto, addr, 0)
+	require.NoError(t, privValidators[0].SignTxVote(voteSet.ChainID(), vote))
+	vote.Signature[0] ^= 0xFF
+
+	// The signature is only checked by AddVote
+	added, err := voteSet.AddVote(vote)
+	assert.False(t, added)
+	assert.Error(t, err)
+	added, err = voteSet.AddPreverifiedVote(vote)
+	assert.True(t, added)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), voteSet.Stake())
+
+	// Everything else still is
+	other := withValidator(voteProto, cmn.RandBytes(20), 1)
+	added, err = voteSet.AddPreverifiedVote(other)
+	assert.False(t, added)
+	assert.Error(t, err)
+}
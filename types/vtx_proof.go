@@ -0,0 +1,59 @@
+package types
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+
+	cmn "github.com/tendermint/tendermint/libs/common"
+	"github.com/tendermint/tendermint/types"
+)
+
+// VtxProof proves that a fast-path tx was recorded in the Vtxs of the block
+// at Height, and so was committed before the block was.
+type VtxProof struct {
+	Height   int64        `json:"height"`
+	DataHash cmn.HexBytes `json:"data_hash"`
+	// Root of the block's Txs, the sibling of the Vtxs root in the data hash
+	TxsHash cmn.HexBytes `json:"txs_hash"`
+	// Proof of the tx in the Vtxs, its RootHash is the Vtxs root
+	Proof types.TxProof `json:"proof"`
+}
+
+// VtxProof returns a Merkle inclusion proof of tx in the block's Vtxs.
+func (b *Block) VtxProof(tx types.Tx) (*VtxProof, error) {
+	if b == nil {
+		return nil, errors.New("nil block")
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	i := b.Vtxs.Index(tx)
+	if i < 0 {
+		return nil, errors.Errorf("Tx %X is not a Vtx of block %d", tx.Hash(), b.Height)
+	}
+	b.fillHeader()
+	return &VtxProof{
+		Height:   b.Height,
+		DataHash: b.DataHash,
+		TxsHash:  b.Txs.Hash(),
+		Proof:    b.Vtxs.Proof(i),
+	}, nil
+}
+
+// Validate verifies the proof against the DataHash of the block header at
+// Height. It returns nil if the proof is valid, otherwise an error.
+func (vp *VtxProof) Validate(headerDataHash []byte) error {
+	if !bytes.Equal(headerDataHash, vp.DataHash) {
+		return errors.New("Proof matches different data hash")
+	}
+	if !bytes.Equal(vp.DataHash, dataHash(vp.TxsHash, vp.Proof.RootHash)) {
+		return errors.New("Vtxs root does not match data hash")
+	}
+	return vp.Proof.Validate(vp.Proof.RootHash)
+}
+
+// Tx returns the proven tx.
+func (vp *VtxProof) Tx() types.Tx {
+	return vp.Proof.Data
+}